@@ -0,0 +1,416 @@
+//go:build go1.16
+// +build go1.16
+
+// Package camfs exposes the storages of a camera as an fs.FS.
+//
+// The storages are the top-level directories, named by their storage ID in
+// hex such as "00010001", and associations are folders. Files are read with
+// GetPartialObject, so they can be streamed with io.Copy or served with
+// http.FileServer(http.FS(fsys)) without loading whole objects.
+//
+// File names are the Filename of the objects with "%" and "/" escaped as
+// "%25" and "%2F". Objects of the same name in a folder are told apart by
+// their handle: the object with the lowest handle keeps the name, the others
+// get a "~" and the handle in hex before the extension, e.g.
+// "DSC00001~0000002A.JPG".
+//
+// Directory listings and ObjectInfo are cached. The cache is dropped as the
+// camera reports added, removed and changed objects and storages; call
+// Invalidate after changing objects through another client or when events
+// may have been missed.
+package camfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// rootParent selects the objects in the root of a storage in GetObjectHandles
+	rootParent uint32 = 0xFFFFFFFF
+)
+
+// nameEscaper escapes the characters of a Filename that are not allowed or
+// have a meaning in a path element.
+var nameEscaper = strings.NewReplacer("%", "%25", "/", "%2F")
+
+var (
+	_ fs.ReadDirFS = (*FS)(nil)
+	_ fs.StatFS    = (*FS)(nil)
+)
+
+// FS is a read-only fs.FS over a connected Client with an open session.
+type FS struct {
+	c      *ptpip.Client
+	cancel func()
+
+	// mu guards the cache: infos holds the ObjectInfo of the handles seen so
+	// far and dirs the listings of the directories. gen counts the
+	// invalidations, a listing fetched across one is not cached.
+	mu    sync.Mutex
+	infos map[uint32]*packet.ObjectInfo
+	dirs  map[dirKey]*listing
+	gen   uint64
+}
+
+// dirKey identifies a directory: the root is the zero key, a storage has
+// handle 0.
+type dirKey struct {
+	storageID uint32
+	handle    uint32
+}
+
+// listing is a directory sorted by name.
+type listing struct {
+	nodes  []*node
+	byName map[string]*node
+}
+
+// New returns the FS of c. It watches the events of c to keep its cache up
+// to date until Close is called or the event connection closes.
+func New(c *ptpip.Client) *FS {
+	events, cancel := c.Subscribe()
+	f := &FS{
+		c:      c,
+		cancel: cancel,
+		infos:  make(map[uint32]*packet.ObjectInfo),
+		dirs:   make(map[dirKey]*listing),
+	}
+	go f.watch(events)
+	return f
+}
+
+// Close stops watching the events of the Client.
+func (f *FS) Close() error {
+	f.cancel()
+	return nil
+}
+
+// Invalidate drops the cached ObjectInfo of handle and the cached directory
+// listings. Handle 0 drops the whole cache.
+func (f *FS) Invalidate(handle uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if handle == 0 {
+		f.infos = make(map[uint32]*packet.ObjectInfo)
+	} else {
+		delete(f.infos, handle)
+	}
+	// the parent of an added object is not known without GetObjectInfo,
+	// listings are cheap to fetch again as the infos stay cached
+	f.dirs = make(map[dirKey]*listing)
+	f.gen++
+}
+
+func (f *FS) watch(events <-chan *packet.EventPacket) {
+	for e := range events {
+		switch packet.EventCode(e.EventCode) {
+		case packet.EventCodeObjectAdded, packet.EventCodeObjectRemoved, packet.EventCodeObjectInfoChanged:
+			f.Invalidate(e.P1)
+		case packet.EventCodeStoreAdded, packet.EventCodeStoreRemoved, packet.EventCodeDeviceReset:
+			f.Invalidate(0)
+		}
+	}
+}
+
+// node is a resolved name.
+type node struct {
+	name      string
+	storageID uint32
+	// handle is the object, or 0 for the root and storages.
+	handle uint32
+	info   *packet.ObjectInfo
+}
+
+func (n *node) isDir() bool {
+	return n.info == nil || packet.ObjectFormatCode(n.info.ObjectFormat) == packet.ObjectFormatCodeAssociation
+}
+
+// Open ...
+func (f *FS) Open(name string) (fs.File, error) {
+	n, err := f.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.isDir() {
+		return &dir{fsys: f, n: n}, nil
+	}
+
+	r, err := f.c.NewObjectReader(n.handle)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{ObjectReader: r, n: n}, nil
+}
+
+// Stat ...
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	n, err := f.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{n}, nil
+}
+
+// ReadDir returns the entries of the directory sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := f.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	children, err := f.children(n)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	entries := make([]fs.DirEntry, len(children))
+	for i, c := range children {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{c})
+	}
+	return entries, nil
+}
+
+// resolve walks name from the root through the cached listings.
+func (f *FS) resolve(op, name string) (n *node, err error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	n = &node{name: "."}
+	if name == "." {
+		return n, nil
+	}
+
+	for _, elem := range strings.Split(name, "/") {
+		if !n.isDir() {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		l, err := f.list(n)
+		if err != nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		next, ok := l.byName[elem]
+		if !ok {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		n = next
+	}
+	return n, nil
+}
+
+// children lists a directory sorted by name. The slice is shared with the
+// cache and must not be modified.
+func (f *FS) children(n *node) (children []*node, err error) {
+	l, err := f.list(n)
+	if err != nil {
+		return nil, err
+	}
+	return l.nodes, nil
+}
+
+// list returns the cached listing of a directory, fetching it if needed.
+func (f *FS) list(n *node) (l *listing, err error) {
+	key := dirKey{storageID: n.storageID, handle: n.handle}
+
+	f.mu.Lock()
+	l, ok := f.dirs[key]
+	gen := f.gen
+	f.mu.Unlock()
+	if ok {
+		return l, nil
+	}
+
+	nodes, err := f.fetch(n)
+	if err != nil {
+		return nil, err
+	}
+	l = &listing{nodes: nodes, byName: make(map[string]*node, len(nodes))}
+	for _, c := range nodes {
+		l.byName[c.name] = c
+	}
+
+	f.mu.Lock()
+	if f.gen == gen {
+		f.dirs[key] = l
+	}
+	f.mu.Unlock()
+	return l, nil
+}
+
+// fetch lists a directory from the device.
+func (f *FS) fetch(n *node) (children []*node, err error) {
+	if n.name == "." && n.storageID == 0 {
+		ids, err := f.c.GetStorageIDs()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			children = append(children, &node{name: fmt.Sprintf("%08X", id), storageID: id})
+		}
+		return children, nil
+	}
+
+	parent := n.handle
+	if n.info == nil {
+		parent = rootParent
+	}
+	handles, err := f.c.GetObjectHandles(n.storageID, 0, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	// the object with the lowest handle keeps a name used more than once
+	sort.Slice(handles, func(i, j int) bool { return handles[i] < handles[j] })
+	used := make(map[string]bool, len(handles))
+	for _, handle := range handles {
+		info, err := f.info(handle)
+		if err != nil {
+			return nil, err
+		}
+		name := escapeName(info.Filename)
+		if used[name] || name == "" || name == "." || name == ".." {
+			ext := path.Ext(name)
+			if ext == name {
+				ext = ""
+			}
+			name = fmt.Sprintf("%s~%08X%s", strings.TrimSuffix(name, ext), handle, ext)
+		}
+		used[name] = true
+		children = append(children, &node{name: name, storageID: n.storageID, handle: handle, info: info})
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children, nil
+}
+
+// escapeName makes a Filename usable as a path element.
+func escapeName(filename string) string {
+	return nameEscaper.Replace(filename)
+}
+
+func (f *FS) info(handle uint32) (info *packet.ObjectInfo, err error) {
+	f.mu.Lock()
+	info, ok := f.infos[handle]
+	f.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	if info, err = f.c.GetObjectInfo(handle); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.infos[handle] = info
+	f.mu.Unlock()
+	return info, nil
+}
+
+// file is an open object. The embedded ObjectReader provides Read, ReadAt and Seek.
+type file struct {
+	*ptpip.ObjectReader
+	n *node
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return fileInfo{f.n}, nil }
+
+func (f *file) Close() error { return nil }
+
+// dir is an open directory.
+type dir struct {
+	fsys    *FS
+	n       *node
+	entries []*node
+	read    bool
+	off     int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return fileInfo{d.n}, nil }
+
+func (d *dir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.n.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fsys.children(d.n)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: d.n.name, Err: err}
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	n := len(d.entries) - d.off
+	if count > 0 && n == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && n > count {
+		n = count
+	}
+	entries := make([]fs.DirEntry, n)
+	for i := range entries {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{d.entries[d.off+i]})
+	}
+	d.off += n
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo. Sys returns the *packet.ObjectInfo of objects.
+type fileInfo struct {
+	n *node
+}
+
+func (fi fileInfo) Name() string { return fi.n.name }
+
+func (fi fileInfo) Size() int64 {
+	if fi.n.isDir() {
+		return 0
+	}
+	return int64(fi.n.info.ObjectCompressedSize)
+}
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.n.isDir() {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// ModTime is the ModificationDate of the object, or the CaptureDate if not set.
+func (fi fileInfo) ModTime() time.Time {
+	if fi.n.info == nil {
+		return time.Time{}
+	}
+	if !fi.n.info.ModificationDate.IsZero() {
+		return fi.n.info.ModificationDate
+	}
+	return fi.n.info.CaptureDate
+}
+
+func (fi fileInfo) IsDir() bool { return fi.n.isDir() }
+
+func (fi fileInfo) Sys() interface{} {
+	if fi.n.info == nil {
+		return nil
+	}
+	return fi.n.info
+}
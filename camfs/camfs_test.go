@@ -0,0 +1,180 @@
+//go:build go1.16
+// +build go1.16
+
+package camfs_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/camfs"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const storageID = 0x00010001
+
+// storageTransport serves the objects in the root of one storage and counts
+// the GetObjectHandles requests.
+type storageTransport struct {
+	mu        sync.Mutex
+	objects   map[uint32]string
+	listCalls int
+
+	events chan *packet.EventPacket
+}
+
+func (t *storageTransport) Connect() error { return nil }
+
+func (t *storageTransport) Close() error {
+	close(t.events)
+	return nil
+}
+
+func (t *storageTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp := &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}
+	switch packet.OperationCode(req.OperationCode) {
+	case packet.OperationCodeGetStorageIDs:
+		return u32Array(storageID), resp, nil
+	case packet.OperationCodeGetObjectHandles:
+		t.listCalls++
+		var handles []uint32
+		for h := range t.objects {
+			handles = append(handles, h)
+		}
+		return u32Array(handles...), resp, nil
+	case packet.OperationCodeGetObjectInfo:
+		name, ok := t.objects[req.P1]
+		if !ok {
+			resp.ResponseCode = packet.ResponseCodeInvalidObjectHandle
+			return nil, resp, nil
+		}
+		data, err := packet.MarshalObjectInfo(&packet.ObjectInfo{StorageID: storageID, ObjectFormat: uint16(packet.ObjectFormatCodeEXIFJPEG), ParentObject: 0, Filename: name})
+		if err != nil {
+			return nil, nil, err
+		}
+		return data, resp, nil
+	}
+	resp.ResponseCode = packet.ResponseCodeOperationNotSupported
+	return nil, resp, nil
+}
+
+func (t *storageTransport) RecvEvent() (*packet.EventPacket, error) {
+	e, ok := <-t.events
+	if !ok {
+		return nil, fs.ErrClosed
+	}
+	return e, nil
+}
+
+func (t *storageTransport) Cancel(transactionID uint32) error { return nil }
+
+func u32Array(a ...uint32) []byte {
+	b := make([]byte, 4+4*len(a))
+	binary.LittleEndian.PutUint32(b, uint32(len(a)))
+	for i, v := range a {
+		binary.LittleEndian.PutUint32(b[4+4*i:], v)
+	}
+	return b
+}
+
+func readDirNames(t *testing.T, fsys fs.ReadDirFS, name string) []string {
+	t.Helper()
+	entries, err := fsys.ReadDir(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestNames(t *testing.T) {
+	tr := &storageTransport{
+		objects: map[uint32]string{1: "DSC00001.JPG", 0x2A: "DSC00001.JPG", 3: "A/B.JPG", 4: "100%.JPG"},
+		events:  make(chan *packet.EventPacket),
+	}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	fsys := camfs.New(c)
+	defer fsys.Close()
+
+	expect := []string{"100%25.JPG", "A%2FB.JPG", "DSC00001.JPG", "DSC00001~0000002A.JPG"}
+	if names := readDirNames(t, fsys, "00010001"); !reflect.DeepEqual(names, expect) {
+		t.Errorf("got %q expected %q", names, expect)
+	}
+
+	for handle, name := range map[uint32]string{0x2A: "00010001/DSC00001~0000002A.JPG", 3: "00010001/A%2FB.JPG"} {
+		fi, err := fsys.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info := fi.Sys().(*packet.ObjectInfo); info.Filename != tr.objects[handle] {
+			t.Errorf("%s: got %q", name, info.Filename)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	tr := &storageTransport{
+		objects: map[uint32]string{1: "DSC00001.JPG", 2: "DSC00002.JPG"},
+		events:  make(chan *packet.EventPacket),
+	}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	fsys := camfs.New(c)
+	defer fsys.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := fsys.Stat("00010001/DSC00002.JPG"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tr.listCalls != 1 {
+		t.Errorf("got %d GetObjectHandles expected 1", tr.listCalls)
+	}
+
+	tr.mu.Lock()
+	tr.objects[3] = "DSC00003.JPG"
+	tr.mu.Unlock()
+	fsys.Invalidate(3)
+	if _, err := fsys.Stat("00010001/DSC00003.JPG"); err != nil {
+		t.Errorf("after Invalidate: %v", err)
+	}
+
+	tr.mu.Lock()
+	delete(tr.objects, 2)
+	tr.mu.Unlock()
+	tr.events <- &packet.EventPacket{EventCode: uint16(packet.EventCodeObjectRemoved), P1: 2}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := fsys.Stat("00010001/DSC00002.JPG")
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("got %v expected ErrNotExist", err)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ObjectRemoved did not invalidate the cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
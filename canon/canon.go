@@ -0,0 +1,285 @@
+// Package canon implements the Canon EOS extension to PTP on top of ptpip.Client.
+//
+// Canon EOS bodies report property changes and new objects through the data
+// of the GetEvent operation rather than the PTP-IP event channel, so after
+// SetRemoteMode and SetEventMode the initiator has to poll GetEvent.
+package canon
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// VendorExtensionID is the VendorExtensionID reported by Canon in DeviceInfo.
+	VendorExtensionID uint32 = 0x0000000B
+)
+
+// Operation Code
+const (
+	OperationCodeGetStorageIDs        uint16 = 0x9101
+	OperationCodeGetStorageInfo       uint16 = 0x9102
+	OperationCodeGetObject            uint16 = 0x9104
+	OperationCodeDeleteObject         uint16 = 0x9105
+	OperationCodeFormatStore          uint16 = 0x9106
+	OperationCodeGetPartialObject     uint16 = 0x9107
+	OperationCodeGetDeviceInfoEx      uint16 = 0x9108
+	OperationCodeGetObjectInfoEx      uint16 = 0x9109
+	OperationCodeGetThumbEx           uint16 = 0x910A
+	OperationCodeRemoteRelease        uint16 = 0x910F
+	OperationCodeSetDevicePropValueEx uint16 = 0x9110
+	OperationCodeGetRemoteMode        uint16 = 0x9113
+	OperationCodeSetRemoteMode        uint16 = 0x9114
+	OperationCodeSetEventMode         uint16 = 0x9115
+	OperationCodeGetEvent             uint16 = 0x9116
+	OperationCodeTransferComplete     uint16 = 0x9117
+	OperationCodeCancelTransfer       uint16 = 0x9118
+	OperationCodeKeepDeviceOn         uint16 = 0x911D
+	OperationCodeRemoteReleaseOn      uint16 = 0x9128
+	OperationCodeRemoteReleaseOff     uint16 = 0x9129
+	OperationCodeInitiateViewfinder   uint16 = 0x9151
+	OperationCodeTerminateViewfinder  uint16 = 0x9152
+	OperationCodeGetViewFinderData    uint16 = 0x9153
+	OperationCodeDoAf                 uint16 = 0x9154
+	OperationCodeDriveLens            uint16 = 0x9155
+	OperationCodeAfCancel             uint16 = 0x9160
+)
+
+// Event Code
+const (
+	EventCodeRequestGetEvent        uint16 = 0xC101
+	EventCodeObjectAddedEx          uint16 = 0xC181
+	EventCodeObjectRemoved          uint16 = 0xC182
+	EventCodeRequestGetObjectInfoEx uint16 = 0xC183
+	EventCodeStorageStatusChanged   uint16 = 0xC184
+	EventCodeStorageInfoChanged     uint16 = 0xC185
+	EventCodeRequestObjectTransfer  uint16 = 0xC186
+	EventCodeObjectInfoChangedEx    uint16 = 0xC187
+	EventCodeObjectContentChanged   uint16 = 0xC188
+	EventCodePropValueChanged       uint16 = 0xC189
+	EventCodeAvailListChanged       uint16 = 0xC18A
+	EventCodeCameraStatusChanged    uint16 = 0xC18B
+	EventCodeWillSoonShutdown       uint16 = 0xC18D
+	EventCodeShutdownTimerUpdated   uint16 = 0xC18E
+	EventCodeStoreAdded             uint16 = 0xC192
+	EventCodeStoreRemoved           uint16 = 0xC193
+	EventCodeBulbExposureTime       uint16 = 0xC194
+)
+
+// Device Property Code
+const (
+	DevicePropCodeAperture           uint16 = 0xD101
+	DevicePropCodeShutterSpeed       uint16 = 0xD102
+	DevicePropCodeISOSpeed           uint16 = 0xD103
+	DevicePropCodeExpCompensation    uint16 = 0xD104
+	DevicePropCodeAutoExposureMode   uint16 = 0xD105
+	DevicePropCodeDriveMode          uint16 = 0xD106
+	DevicePropCodeMeteringMode       uint16 = 0xD107
+	DevicePropCodeFocusMode          uint16 = 0xD108
+	DevicePropCodeWhiteBalance       uint16 = 0xD109
+	DevicePropCodeColorTemperature   uint16 = 0xD10A
+	DevicePropCodeBatteryPower       uint16 = 0xD111
+	DevicePropCodeAvailableShots     uint16 = 0xD11B
+	DevicePropCodeCaptureDestination uint16 = 0xD11C
+	DevicePropCodeEVFOutputDevice    uint16 = 0xD1B0
+	DevicePropCodeEVFMode            uint16 = 0xD1B3
+)
+
+// Response Code
+const (
+	ResponseCodeNotReady uint16 = 0xA102
+)
+
+// RemoteReleaseOn / RemoteReleaseOff parameter
+const (
+	ReleaseHalf uint32 = 0x00000001 // shutter button half pressed (focus)
+	ReleaseFull uint32 = 0x00000003 // shutter button fully pressed
+)
+
+// CaptureDestination value
+const (
+	CaptureDestinationCard uint32 = 0x00000002
+	CaptureDestinationHost uint32 = 0x00000004
+)
+
+// EVFOutputDevice value
+const (
+	EVFOutputDeviceTFT uint32 = 0x00000001
+	EVFOutputDevicePC  uint32 = 0x00000002
+)
+
+// Vendor is the Canon EOS vendor extension registered with ptpip.
+var Vendor = &ptpip.Vendor{
+	Name:              "canon",
+	VendorExtensionID: VendorExtensionID,
+	Manufacturer:      "Canon",
+	Operations: map[uint16]string{
+		OperationCodeGetStorageIDs:        "GetStorageIDs",
+		OperationCodeGetStorageInfo:       "GetStorageInfo",
+		OperationCodeGetObject:            "GetObject",
+		OperationCodeDeleteObject:         "DeleteObject",
+		OperationCodeFormatStore:          "FormatStore",
+		OperationCodeGetPartialObject:     "GetPartialObject",
+		OperationCodeGetDeviceInfoEx:      "GetDeviceInfoEx",
+		OperationCodeGetObjectInfoEx:      "GetObjectInfoEx",
+		OperationCodeGetThumbEx:           "GetThumbEx",
+		OperationCodeRemoteRelease:        "RemoteRelease",
+		OperationCodeSetDevicePropValueEx: "SetDevicePropValueEx",
+		OperationCodeGetRemoteMode:        "GetRemoteMode",
+		OperationCodeSetRemoteMode:        "SetRemoteMode",
+		OperationCodeSetEventMode:         "SetEventMode",
+		OperationCodeGetEvent:             "GetEvent",
+		OperationCodeTransferComplete:     "TransferComplete",
+		OperationCodeCancelTransfer:       "CancelTransfer",
+		OperationCodeKeepDeviceOn:         "KeepDeviceOn",
+		OperationCodeRemoteReleaseOn:      "RemoteReleaseOn",
+		OperationCodeRemoteReleaseOff:     "RemoteReleaseOff",
+		OperationCodeInitiateViewfinder:   "InitiateViewfinder",
+		OperationCodeTerminateViewfinder:  "TerminateViewfinder",
+		OperationCodeGetViewFinderData:    "GetViewFinderData",
+		OperationCodeDoAf:                 "DoAf",
+		OperationCodeDriveLens:            "DriveLens",
+		OperationCodeAfCancel:             "AfCancel",
+	},
+	Events: map[uint16]string{
+		EventCodeRequestGetEvent:        "RequestGetEvent",
+		EventCodeObjectAddedEx:          "ObjectAddedEx",
+		EventCodeObjectRemoved:          "ObjectRemoved",
+		EventCodeRequestGetObjectInfoEx: "RequestGetObjectInfoEx",
+		EventCodeStorageStatusChanged:   "StorageStatusChanged",
+		EventCodeStorageInfoChanged:     "StorageInfoChanged",
+		EventCodeRequestObjectTransfer:  "RequestObjectTransfer",
+		EventCodeObjectInfoChangedEx:    "ObjectInfoChangedEx",
+		EventCodeObjectContentChanged:   "ObjectContentChanged",
+		EventCodePropValueChanged:       "PropValueChanged",
+		EventCodeAvailListChanged:       "AvailListChanged",
+		EventCodeCameraStatusChanged:    "CameraStatusChanged",
+		EventCodeWillSoonShutdown:       "WillSoonShutdown",
+		EventCodeShutdownTimerUpdated:   "ShutdownTimerUpdated",
+		EventCodeStoreAdded:             "StoreAdded",
+		EventCodeStoreRemoved:           "StoreRemoved",
+		EventCodeBulbExposureTime:       "BulbExposureTime",
+	},
+	DeviceProps: map[uint16]string{
+		DevicePropCodeAperture:           "Aperture",
+		DevicePropCodeShutterSpeed:       "ShutterSpeed",
+		DevicePropCodeISOSpeed:           "ISOSpeed",
+		DevicePropCodeExpCompensation:    "ExpCompensation",
+		DevicePropCodeAutoExposureMode:   "AutoExposureMode",
+		DevicePropCodeDriveMode:          "DriveMode",
+		DevicePropCodeMeteringMode:       "MeteringMode",
+		DevicePropCodeFocusMode:          "FocusMode",
+		DevicePropCodeWhiteBalance:       "WhiteBalance",
+		DevicePropCodeColorTemperature:   "ColorTemperature",
+		DevicePropCodeBatteryPower:       "BatteryPower",
+		DevicePropCodeAvailableShots:     "AvailableShots",
+		DevicePropCodeCaptureDestination: "CaptureDestination",
+		DevicePropCodeEVFOutputDevice:    "EVFOutputDevice",
+		DevicePropCodeEVFMode:            "EVFMode",
+	},
+	Responses: map[uint16]string{
+		ResponseCodeNotReady: "NotReady",
+	},
+	Decoders: map[uint16]ptpip.DatasetDecoder{
+		OperationCodeGetEvent: func(data []byte) (interface{}, error) {
+			return ParseEvents(data)
+		},
+	},
+}
+
+func init() {
+	ptpip.RegisterVendor(Vendor)
+}
+
+// Camera wraps a ptpip.Client connected to a Canon EOS body.
+type Camera struct {
+	c *ptpip.Client
+}
+
+// New ...
+func New(c *ptpip.Client) *Camera {
+	return &Camera{c: c}
+}
+
+// Client returns the underlying ptpip.Client.
+func (cam *Camera) Client() *ptpip.Client {
+	return cam.c
+}
+
+// Init enables remote mode and event mode. It must be called after OpenSession.
+func (cam *Camera) Init() (err error) {
+	if err = cam.SetRemoteMode(1); err != nil {
+		return err
+	}
+	if err = cam.SetEventMode(1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetRemoteMode ...
+func (cam *Camera) SetRemoteMode(mode uint32) (err error) {
+	_, err = cam.c.Transaction(OperationCodeSetRemoteMode, packet.DataPhaseInfoNoDataOrDataIn, mode, 0, 0, 0, nil)
+	return err
+}
+
+// SetEventMode ...
+func (cam *Camera) SetEventMode(mode uint32) (err error) {
+	_, err = cam.c.Transaction(OperationCodeSetEventMode, packet.DataPhaseInfoNoDataOrDataIn, mode, 0, 0, 0, nil)
+	return err
+}
+
+// SetDevicePropValueEx sets a 32 bit Canon device property.
+func (cam *Camera) SetDevicePropValueEx(propCode uint16, value uint32) (err error) {
+	v := make([]byte, 4)
+	binary.LittleEndian.PutUint32(v, value)
+	return cam.SetDevicePropValueExRaw(propCode, v)
+}
+
+// SetDevicePropValueExRaw sets a Canon device property to an already encoded value.
+func (cam *Camera) SetDevicePropValueExRaw(propCode uint16, value []byte) (err error) {
+	// Size(4) PropertyCode(4) Value
+	data := make([]byte, 8+len(value))
+	binary.LittleEndian.PutUint32(data[0:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[4:8], uint32(propCode))
+	copy(data[8:], value)
+
+	_, err = cam.c.Transaction(OperationCodeSetDevicePropValueEx, packet.DataPhaseInfoDataOut, 0, 0, 0, 0, data)
+	return err
+}
+
+// RemoteRelease takes a picture with the legacy release operation.
+func (cam *Camera) RemoteRelease() (err error) {
+	_, err = cam.c.Transaction(OperationCodeRemoteRelease, packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil)
+	return err
+}
+
+// RemoteReleaseOn presses the shutter button. mode is ReleaseHalf or ReleaseFull.
+func (cam *Camera) RemoteReleaseOn(mode uint32, af bool) (err error) {
+	var p2 uint32
+	if !af {
+		p2 = 1
+	}
+	_, err = cam.c.Transaction(OperationCodeRemoteReleaseOn, packet.DataPhaseInfoNoDataOrDataIn, mode, p2, 0, 0, nil)
+	return err
+}
+
+// RemoteReleaseOff releases the shutter button. mode is ReleaseHalf or ReleaseFull.
+func (cam *Camera) RemoteReleaseOff(mode uint32) (err error) {
+	_, err = cam.c.Transaction(OperationCodeRemoteReleaseOff, packet.DataPhaseInfoNoDataOrDataIn, mode, 0, 0, 0, nil)
+	return err
+}
+
+// Capture takes a picture by fully pressing and releasing the shutter button.
+func (cam *Camera) Capture(af bool) (err error) {
+	if err = cam.RemoteReleaseOn(ReleaseFull, af); err != nil {
+		return err
+	}
+	if err = cam.RemoteReleaseOff(ReleaseFull); err != nil {
+		return fmt.Errorf("canon: release off: %v", err)
+	}
+	return nil
+}
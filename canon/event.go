@@ -0,0 +1,168 @@
+package canon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Event is a record decoded from the GetEvent data.
+type Event interface {
+	// Type returns the Canon event code of the record.
+	Type() uint16
+}
+
+// PropertyChangedEvent reports the new value of a device property.
+type PropertyChangedEvent struct {
+	PropCode uint16
+	// Value holds the first 32 bits of Data, which covers most properties.
+	Value uint32
+	Data  []byte
+}
+
+// Type ...
+func (e *PropertyChangedEvent) Type() uint16 { return EventCodePropValueChanged }
+
+// AvailListChangedEvent reports the values a device property can currently take.
+type AvailListChangedEvent struct {
+	PropCode uint16
+	DataType uint32
+	Values   []uint32
+}
+
+// Type ...
+func (e *AvailListChangedEvent) Type() uint16 { return EventCodeAvailListChanged }
+
+// ObjectAddedEvent reports a new object on the camera, usually a captured image.
+type ObjectAddedEvent struct {
+	ObjectHandle uint32
+	StorageID    uint32
+	ObjectFormat uint16
+	Size         uint32
+	ParentObject uint32
+	Filename     string
+}
+
+// Type ...
+func (e *ObjectAddedEvent) Type() uint16 { return EventCodeObjectAddedEx }
+
+// UnknownEvent is a record this package does not decode.
+type UnknownEvent struct {
+	Code uint16
+	Data []byte
+}
+
+// Type ...
+func (e *UnknownEvent) Type() uint16 { return e.Code }
+
+// ParseEvents decodes the record stream returned by GetEvent. Each record is
+// Size(4) Type(4) followed by Size-8 bytes, and the stream ends with a record
+// of Type 0.
+func ParseEvents(data []byte) (events []Event, err error) {
+	for off := 0; off+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[off:]))
+		code := binary.LittleEndian.Uint32(data[off+4:])
+		if code == 0 { // terminator
+			break
+		}
+		if size < 8 || len(data)-off < size {
+			return events, fmt.Errorf("canon: invalid event record size %d at 0x%x", size, off)
+		}
+
+		e, err := parseEvent(uint16(code), data[off+8:off+size])
+		if err != nil {
+			return events, err
+		}
+		events = append(events, e)
+
+		off += size
+	}
+
+	return events, nil
+}
+
+func parseEvent(code uint16, body []byte) (e Event, err error) {
+	le := binary.LittleEndian
+
+	switch code {
+	case EventCodePropValueChanged:
+		// PropertyCode(4) Value
+		if len(body) < 4 {
+			break
+		}
+		pe := &PropertyChangedEvent{PropCode: uint16(le.Uint32(body)), Data: body[4:]}
+		if 4 <= len(pe.Data) {
+			pe.Value = le.Uint32(pe.Data)
+		}
+		return pe, nil
+	case EventCodeAvailListChanged:
+		// PropertyCode(4) DataType(4) Count(4) Values(4 each)
+		if len(body) < 12 {
+			break
+		}
+		ae := &AvailListChangedEvent{PropCode: uint16(le.Uint32(body)), DataType: le.Uint32(body[4:])}
+		n := int(le.Uint32(body[8:]))
+		if (len(body)-12)/4 < n {
+			return nil, fmt.Errorf("canon: invalid avail list len %d", n)
+		}
+		for i := 0; i < n; i++ {
+			ae.Values = append(ae.Values, le.Uint32(body[12+i*4:]))
+		}
+		return ae, nil
+	case EventCodeObjectAddedEx:
+		// ObjectHandle(4) StorageID(4) ObjectFormat(2) ... Size(4)@0x14 Parent(4)@0x18 Filename@0x20
+		if len(body) < 0x20 {
+			break
+		}
+		oe := &ObjectAddedEvent{
+			ObjectHandle: le.Uint32(body[0x00:]),
+			StorageID:    le.Uint32(body[0x04:]),
+			ObjectFormat: le.Uint16(body[0x08:]),
+			Size:         le.Uint32(body[0x14:]),
+			ParentObject: le.Uint32(body[0x18:]),
+		}
+		name := body[0x20:]
+		if i := bytes.IndexByte(name, 0); 0 <= i {
+			name = name[:i]
+		}
+		oe.Filename = string(name)
+		return oe, nil
+	}
+
+	return &UnknownEvent{Code: code, Data: body}, nil
+}
+
+// GetEvent polls the camera for pending events.
+func (cam *Camera) GetEvent() (events []Event, err error) {
+	data, err := cam.c.Transaction(OperationCodeGetEvent, packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEvents(data)
+}
+
+// PollEvents calls GetEvent every interval and passes each event to fn
+// until stop is closed or GetEvent fails.
+func (cam *Camera) PollEvents(stop <-chan struct{}, interval time.Duration, fn func(Event)) (err error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := cam.GetEvent()
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			fn(e)
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
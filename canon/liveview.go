@@ -0,0 +1,63 @@
+package canon
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/takurooo/ptpip/liveview"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// viewFinderBufferSize is the maximum data size requested from GetViewFinderData
+	viewFinderBufferSize uint32 = 0x00200000
+
+	// viewFinderRecordImage is the record type carrying the JPEG image
+	viewFinderRecordImage uint32 = 0x00000001
+)
+
+// ErrNoLiveViewImage is returned by LiveViewImage when the camera has no frame ready yet.
+var ErrNoLiveViewImage error = liveview.NoFrameError("canon: no live view image")
+
+// StartLiveView routes the electronic viewfinder to the host.
+func (cam *Camera) StartLiveView() (err error) {
+	if err = cam.SetDevicePropValueEx(DevicePropCodeEVFMode, 1); err != nil {
+		return err
+	}
+	return cam.SetDevicePropValueEx(DevicePropCodeEVFOutputDevice, EVFOutputDevicePC)
+}
+
+// StopLiveView routes the electronic viewfinder back to the camera display.
+func (cam *Camera) StopLiveView() (err error) {
+	return cam.SetDevicePropValueEx(DevicePropCodeEVFOutputDevice, EVFOutputDeviceTFT)
+}
+
+// LiveViewImage returns the current live view frame as JPEG.
+func (cam *Camera) LiveViewImage() (jpeg []byte, err error) {
+	data, err := cam.c.Transaction(OperationCodeGetViewFinderData, packet.DataPhaseInfoNoDataOrDataIn, viewFinderBufferSize, 0, 0, 0, nil)
+	if err != nil {
+		// the first frames after StartLiveView are answered with NotReady
+		if re, ok := err.(*packet.ResponseError); ok && (re.Code == packet.ResponseCodeDeviceBusy || re.Code == ResponseCodeNotReady) {
+			return nil, ErrNoLiveViewImage
+		}
+		return nil, err
+	}
+	return parseViewFinderData(data)
+}
+
+// parseViewFinderData extracts the JPEG from the GetViewFinderData records.
+// Each record is Size(4) Type(4) followed by Size-8 bytes.
+func parseViewFinderData(data []byte) (jpeg []byte, err error) {
+	for off := 0; off+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[off:]))
+		typ := binary.LittleEndian.Uint32(data[off+4:])
+		if size < 8 || len(data)-off < size {
+			return nil, fmt.Errorf("canon: invalid viewfinder record size %d at 0x%x", size, off)
+		}
+		if typ == viewFinderRecordImage {
+			return data[off+8 : off+size], nil
+		}
+		off += size
+	}
+	return nil, ErrNoLiveViewImage
+}
@@ -0,0 +1,102 @@
+package canon_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/canon"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// viewFinderTransport answers GetViewFinderData with code and data. Like
+// the transports of ptpip it returns a ResponseError for a code other than OK.
+type viewFinderTransport struct {
+	code   uint16
+	data   []byte
+	closed chan struct{}
+}
+
+func (t *viewFinderTransport) Connect() error { return nil }
+
+func (t *viewFinderTransport) Close() error {
+	close(t.closed)
+	return nil
+}
+
+func (t *viewFinderTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	resp := &packet.OperationResponsePacket{ResponseCode: t.code, TransactionID: req.TransactionID}
+	if req.OperationCode != canon.OperationCodeGetViewFinderData {
+		resp.ResponseCode = packet.ResponseCodeOperationNotSupported
+		return nil, resp, &packet.ResponseError{Code: resp.ResponseCode}
+	}
+	if t.code != packet.ResponseCodeOK {
+		return nil, resp, &packet.ResponseError{Code: t.code}
+	}
+	return t.data, resp, nil
+}
+
+func (t *viewFinderTransport) RecvEvent() (*packet.EventPacket, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *viewFinderTransport) Cancel(transactionID uint32) error { return nil }
+
+func record(typ uint32, body []byte) []byte {
+	b := make([]byte, 8, 8+len(body))
+	binary.LittleEndian.PutUint32(b[0:], uint32(8+len(body)))
+	binary.LittleEndian.PutUint32(b[4:], typ)
+	return append(b, body...)
+}
+
+func TestLiveViewImage(t *testing.T) {
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xD9}
+	data := append(record(0x00000002, []byte{1, 2, 3, 4}), record(0x00000001, jpeg)...)
+
+	tests := []struct {
+		name   string
+		code   uint16
+		data   []byte
+		expect []byte
+		err    error
+	}{
+		{"image", packet.ResponseCodeOK, data, jpeg, nil},
+		{"no image record", packet.ResponseCodeOK, record(0x00000002, nil), nil, canon.ErrNoLiveViewImage},
+		{"DeviceBusy", packet.ResponseCodeDeviceBusy, nil, nil, canon.ErrNoLiveViewImage},
+		{"NotReady", canon.ResponseCodeNotReady, nil, nil, canon.ErrNoLiveViewImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ptpip.NewClientTransport(&viewFinderTransport{code: tt.code, data: tt.data, closed: make(chan struct{})})
+			if err := c.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer c.Disconnect()
+
+			got, err := canon.New(c).LiveViewImage()
+			if err != tt.err {
+				t.Fatalf("got %v expected %v", err, tt.err)
+			}
+			if !bytes.Equal(got, tt.expect) {
+				t.Errorf("got % x expected % x", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestLiveViewImageError(t *testing.T) {
+	c := ptpip.NewClientTransport(&viewFinderTransport{code: packet.ResponseCodeGeneralError, closed: make(chan struct{})})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	_, err := canon.New(c).LiveViewImage()
+	if re, ok := err.(*packet.ResponseError); !ok || re.Code != packet.ResponseCodeGeneralError {
+		t.Errorf("got %v expected GeneralError", err)
+	}
+}
@@ -0,0 +1,340 @@
+// Package cardsync copies the objects of a camera to a local directory
+// incrementally.
+//
+// A Manifest in the directory records the objects already copied. Sync
+// downloads only the objects that are new or whose size changed, and
+// resumes partial downloads left by an interrupted sync.
+package cardsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// DefaultManifest is the manifest file name in the sync directory.
+	DefaultManifest = ".ptpip-sync.json"
+
+	// partSuffix is appended to the local path of a partial download
+	partSuffix = ".part"
+
+	// maxFolderDepth guards against parent loops reported by the device
+	maxFolderDepth = 32
+
+	// saveEvery is the number of objects transferred between manifest saves
+	saveEvery = 32
+)
+
+// Action is what Sync does with an object.
+type Action int
+
+// Actions
+const (
+	// ActionSkip is an object already copied.
+	ActionSkip Action = iota
+	// ActionNew is an object not in the manifest.
+	ActionNew
+	// ActionChanged is an object whose size differs from the manifest.
+	ActionChanged
+	// ActionResume is an object whose download was interrupted.
+	ActionResume
+	// ActionMissing is an object whose local copy was removed.
+	ActionMissing
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionSkip:
+		return "skip"
+	case ActionNew:
+		return "new"
+	case ActionChanged:
+		return "changed"
+	case ActionResume:
+		return "resume"
+	case ActionMissing:
+		return "missing"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Options ...
+type Options struct {
+	// Dir is the destination directory. It is created if needed.
+	Dir string
+	// Manifest is the manifest path, default DefaultManifest in Dir.
+	Manifest string
+	// DryRun only reports what would be transferred.
+	DryRun bool
+	// OnResult is called for every object after it was handled.
+	OnResult func(r *Result)
+}
+
+// Result is the outcome of one object.
+type Result struct {
+	Handle uint32
+	Info   *packet.ObjectInfo
+	Action Action
+	// Path is the local copy.
+	Path string
+	// Bytes is the number of bytes downloaded.
+	Bytes int64
+	Err   error
+}
+
+type syncer struct {
+	c       *ptpip.Client
+	opts    Options
+	m       *Manifest
+	paths   map[string]bool
+	folders map[uint32]*packet.ObjectInfo
+
+	// unsaved is the number of entries changed since the manifest was saved
+	unsaved int
+}
+
+// Sync copies the new and changed objects of all storages to opts.Dir. It
+// returns the results of all objects; an error is returned only if the
+// sync could not run, failures of single objects are in their Result.
+//
+// The manifest is saved every saveEvery transferred objects and when Sync
+// returns. A download interrupted before its entry was saved is resumed
+// from the partial file as long as the object gets the same local path.
+func Sync(c *ptpip.Client, opts Options) (results []*Result, err error) {
+	if opts.Manifest == "" {
+		opts.Manifest = filepath.Join(opts.Dir, DefaultManifest)
+	}
+	if !opts.DryRun {
+		if err = os.MkdirAll(opts.Dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	m, err := LoadManifest(opts.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	s := &syncer{c: c, opts: opts, m: m, paths: m.paths(), folders: make(map[uint32]*packet.ObjectInfo)}
+	defer func() {
+		if e := s.save(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	storageIDs, err := c.GetStorageIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, storageID := range storageIDs {
+		handles, err := c.GetObjectHandles(storageID, 0, 0)
+		if err != nil {
+			return results, err
+		}
+
+		for _, handle := range handles {
+			r := s.sync(storageID, handle)
+			if r == nil {
+				continue
+			}
+			results = append(results, r)
+			if opts.OnResult != nil {
+				opts.OnResult(r)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// sync handles one object. It returns nil for folders.
+func (s *syncer) sync(storageID, handle uint32) (r *Result) {
+	r = &Result{Handle: handle}
+
+	r.Info, r.Err = s.info(handle)
+	if r.Err != nil {
+		return r
+	}
+	if packet.ObjectFormatCode(r.Info.ObjectFormat) == packet.ObjectFormatCodeAssociation {
+		return nil
+	}
+
+	dir, err := s.folder(r.Info.ParentObject, 0)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	camPath := filepath.Join(fmt.Sprintf("%08X", storageID), dir, safeName(r.Info.Filename))
+	key := camPath + "@" + packet.FormatDateTime(r.Info.CaptureDate)
+
+	e, ok := s.m.Entries[key]
+	switch {
+	case !ok:
+		r.Action = ActionNew
+		e = &Entry{StorageID: storageID, Filename: r.Info.Filename, CaptureDate: r.Info.CaptureDate, Path: s.uniquePath(camPath)}
+		// reserve the path in a dry run as well, so that objects of the
+		// same name report the paths they would be copied to
+		s.paths[e.Path] = true
+	case e.Size != r.Info.ObjectCompressedSize:
+		r.Action = ActionChanged
+		e.Complete = false
+	case !e.Complete:
+		r.Action = ActionResume
+	case !s.exists(e):
+		r.Action = ActionMissing
+		e.Complete = false
+	default:
+		r.Action = ActionSkip
+	}
+	r.Path = filepath.Join(s.opts.Dir, e.Path)
+
+	if r.Action == ActionSkip || s.opts.DryRun {
+		return r
+	}
+
+	e.Handle = handle
+	e.Size = r.Info.ObjectCompressedSize
+	e.SHA256 = ""
+	if r.Action == ActionChanged || r.Action == ActionMissing {
+		os.Remove(r.Path + partSuffix)
+	}
+
+	// record the entry before downloading so that its path is kept on resume
+	s.m.Entries[key] = e
+	s.unsaved++
+
+	r.Bytes, r.Err = s.download(handle, r.Path)
+	if r.Err == nil {
+		if e.SHA256, r.Err = hashFile(r.Path); r.Err == nil {
+			e.Complete = true
+		}
+	}
+
+	if saveEvery <= s.unsaved {
+		if err := s.save(); err != nil && r.Err == nil {
+			r.Err = err
+		}
+	}
+	return r
+}
+
+// save writes the manifest if entries changed since the last save.
+func (s *syncer) save() error {
+	if s.unsaved == 0 {
+		return nil
+	}
+	s.unsaved = 0
+	return s.m.Save(s.opts.Manifest)
+}
+
+func (s *syncer) info(handle uint32) (info *packet.ObjectInfo, err error) {
+	if info, ok := s.folders[handle]; ok {
+		return info, nil
+	}
+	return s.c.GetObjectInfo(handle)
+}
+
+// folder returns the path of the folder handle on the camera.
+func (s *syncer) folder(handle uint32, depth int) (dir string, err error) {
+	if handle == 0 || handle == 0xFFFFFFFF {
+		return "", nil
+	}
+	if depth == maxFolderDepth {
+		return "", fmt.Errorf("folder depth exceeds %d", maxFolderDepth)
+	}
+
+	info, ok := s.folders[handle]
+	if !ok {
+		if info, err = s.c.GetObjectInfo(handle); err != nil {
+			return "", err
+		}
+		s.folders[handle] = info
+	}
+
+	parent, err := s.folder(info.ParentObject, depth+1)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, safeName(info.Filename)), nil
+}
+
+// uniquePath returns path, or path with a -1, -2, ... suffix if another
+// entry uses it, e.g. a file name reused by the camera after formatting.
+func (s *syncer) uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	p := path
+	for i := 1; s.paths[p]; i++ {
+		p = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	return p
+}
+
+func (s *syncer) exists(e *Entry) bool {
+	fi, err := os.Stat(filepath.Join(s.opts.Dir, e.Path))
+	return err == nil && fi.Size() == int64(e.Size)
+}
+
+// download writes the object to path + partSuffix, continuing a partial
+// download, and renames it to path when complete.
+func (s *syncer) download(handle uint32, path string) (n int64, err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+
+	part := path + partSuffix
+	f, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	offset := fi.Size()
+
+	end, err := s.c.DownloadObject(handle, f, offset)
+	if err != nil {
+		f.Close()
+		return end - offset, err
+	}
+	if err = f.Close(); err != nil {
+		return end - offset, err
+	}
+	if err = os.Rename(part, path); err != nil {
+		return end - offset, err
+	}
+	return end - offset, nil
+}
+
+func hashFile(path string) (sum string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// safeName removes path separators from a name reported by the camera.
+func safeName(s string) string {
+	s = strings.NewReplacer("/", "_", "\\", "_").Replace(s)
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
@@ -0,0 +1,368 @@
+package cardsync_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/cardsync"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	storageID = 0x00010001
+	folder    = 0x100
+)
+
+type cardObject struct {
+	info *packet.ObjectInfo
+	data []byte
+}
+
+// cardTransport serves the objects of one storage in the order of handles.
+// GetPartialObject fails from offset failAt of an object if it is set.
+type cardTransport struct {
+	mu       sync.Mutex
+	handles  []uint32
+	objects  map[uint32]cardObject
+	failAt   map[uint32]int
+	partials int
+
+	closed chan struct{}
+}
+
+func newCardTransport() *cardTransport {
+	t := &cardTransport{objects: make(map[uint32]cardObject), failAt: make(map[uint32]int), closed: make(chan struct{})}
+	t.add(folder, &packet.ObjectInfo{ObjectFormat: uint16(packet.ObjectFormatCodeAssociation), Filename: "100MSDCF"}, nil)
+	return t
+}
+
+// add adds an object, a JPEG in the folder if info is nil.
+func (t *cardTransport) add(handle uint32, info *packet.ObjectInfo, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if info == nil {
+		info = &packet.ObjectInfo{
+			ObjectFormat: uint16(packet.ObjectFormatCodeEXIFJPEG),
+			ParentObject: folder,
+			Filename:     fmt.Sprintf("DSC%05d.JPG", handle),
+			CaptureDate:  time.Date(2020, 9, 6, 9, 36, 30, 0, time.UTC),
+		}
+	}
+	info.StorageID = storageID
+	info.ObjectCompressedSize = uint32(len(data))
+	if _, ok := t.objects[handle]; !ok {
+		t.handles = append(t.handles, handle)
+	}
+	t.objects[handle] = cardObject{info, data}
+}
+
+func (t *cardTransport) Connect() error { return nil }
+
+func (t *cardTransport) Close() error {
+	close(t.closed)
+	return nil
+}
+
+func (t *cardTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp := &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}
+	switch packet.OperationCode(req.OperationCode) {
+	case packet.OperationCodeGetStorageIDs:
+		return u32Array(storageID), resp, nil
+	case packet.OperationCodeGetObjectHandles:
+		return u32Array(t.handles...), resp, nil
+	}
+
+	o, ok := t.objects[req.P1]
+	if !ok {
+		return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeInvalidObjectHandle}
+	}
+	switch packet.OperationCode(req.OperationCode) {
+	case packet.OperationCodeGetObjectInfo:
+		data, err := packet.MarshalObjectInfo(o.info)
+		if err != nil {
+			return nil, nil, err
+		}
+		return data, resp, nil
+	case packet.OperationCodeGetPartialObject:
+		t.partials++
+		off, end := int(req.P2), int(req.P2+req.P3)
+		if len(o.data) < end {
+			end = len(o.data)
+		}
+		if failAt, ok := t.failAt[req.P1]; ok {
+			if failAt <= off {
+				return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeIncompleteTransfer}
+			}
+			if failAt < end {
+				end = failAt
+			}
+		}
+		return o.data[off:end], resp, nil
+	}
+	return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeOperationNotSupported}
+}
+
+func (t *cardTransport) RecvEvent() (*packet.EventPacket, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *cardTransport) Cancel(transactionID uint32) error { return nil }
+
+func u32Array(a ...uint32) []byte {
+	b := make([]byte, 4+4*len(a))
+	binary.LittleEndian.PutUint32(b, uint32(len(a)))
+	for i, v := range a {
+		binary.LittleEndian.PutUint32(b[4+4*i:], v)
+	}
+	return b
+}
+
+func connect(t *testing.T, tr *cardTransport) *ptpip.Client {
+	t.Helper()
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cardsync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func actions(results []*cardsync.Result) (a []string) {
+	for _, r := range results {
+		a = append(a, fmt.Sprintf("%d:%v", r.Handle, r.Action))
+	}
+	return a
+}
+
+func TestSync(t *testing.T) {
+	tr := newCardTransport()
+	tr.add(1, nil, []byte{0xFF, 0xD8, 1})
+	tr.add(2, nil, []byte{0xFF, 0xD8, 2})
+	tr.add(3, nil, []byte{0xFF, 0xD8, 3})
+	c := connect(t, tr)
+	defer c.Disconnect()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	local := func(handle uint32) string {
+		return filepath.Join(dir, "00010001", "100MSDCF", fmt.Sprintf("DSC%05d.JPG", handle))
+	}
+
+	// the steps run in order on the same directory
+	steps := []struct {
+		name   string
+		change func()
+		expect []string
+	}{
+		{"first", func() {}, []string{"1:new", "2:new", "3:new"}},
+		{"unchanged", func() {}, []string{"1:skip", "2:skip", "3:skip"}},
+		{"size changed", func() { tr.add(2, tr.objects[2].info, []byte{0xFF, 0xD8, 2, 2}) }, []string{"1:skip", "2:changed", "3:skip"}},
+		{"local removed", func() { os.Remove(local(3)) }, []string{"1:skip", "2:skip", "3:missing"}},
+		{"object added", func() { tr.add(4, nil, []byte{0xFF, 0xD8, 4}) }, []string{"1:skip", "2:skip", "3:skip", "4:new"}},
+	}
+
+	for _, step := range steps {
+		step.change()
+		results, err := cardsync.Sync(c, cardsync.Options{Dir: dir})
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := actions(results); !reflect.DeepEqual(got, step.expect) {
+			t.Errorf("%s: got %v expected %v", step.name, got, step.expect)
+		}
+		for _, r := range results {
+			if r.Err != nil {
+				t.Errorf("%s: handle %d: %v", step.name, r.Handle, r.Err)
+				continue
+			}
+			if r.Path != local(r.Handle) {
+				t.Errorf("%s: got path %s expected %s", step.name, r.Path, local(r.Handle))
+			}
+			data, err := ioutil.ReadFile(r.Path)
+			if err != nil {
+				t.Errorf("%s: %v", step.name, err)
+			} else if expect := tr.objects[r.Handle].data; !bytes.Equal(data, expect) {
+				t.Errorf("%s: %s: got % x expected % x", step.name, r.Path, data, expect)
+			}
+		}
+	}
+}
+
+func TestSyncResume(t *testing.T) {
+	object := bytes.Repeat([]byte{0xFF, 0xD8}, 64)
+	tr := newCardTransport()
+	tr.add(1, nil, object)
+	tr.failAt[1] = 40
+	c := connect(t, tr)
+	defer c.Disconnect()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	results, err := cardsync.Sync(c, cardsync.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Err == nil || results[0].Bytes != 40 {
+		t.Fatalf("got %+v expected a failed download of 40 bytes", results[0])
+	}
+
+	// the entry of the interrupted download is in the manifest
+	m, err := cardsync.LoadManifest(filepath.Join(dir, cardsync.DefaultManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 1 {
+		t.Fatalf("got %d manifest entries expected 1", len(m.Entries))
+	}
+	for _, e := range m.Entries {
+		if e.Complete {
+			t.Error("interrupted entry is complete")
+		}
+	}
+
+	delete(tr.failAt, 1)
+	results, err = cardsync.Sync(c, cardsync.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := results[0]
+	if r.Err != nil || r.Action != cardsync.ActionResume || r.Bytes != int64(len(object)-40) {
+		t.Fatalf("got action %v bytes %d error %v expected resume of %d bytes", r.Action, r.Bytes, r.Err, len(object)-40)
+	}
+	if data, err := ioutil.ReadFile(r.Path); err != nil || !bytes.Equal(data, object) {
+		t.Errorf("got % x error %v", data, err)
+	}
+	if _, err := os.Stat(r.Path + ".part"); !os.IsNotExist(err) {
+		t.Errorf("partial file left: %v", err)
+	}
+}
+
+func TestSyncDryRun(t *testing.T) {
+	// a file name reused by the camera with another capture date
+	reused := &packet.ObjectInfo{
+		ObjectFormat: uint16(packet.ObjectFormatCodeEXIFJPEG),
+		ParentObject: folder,
+		Filename:     "DSC00001.JPG",
+		CaptureDate:  time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	tr := newCardTransport()
+	tr.add(1, nil, []byte{0xFF, 0xD8, 1})
+	tr.add(2, reused, []byte{0xFF, 0xD8, 2})
+	c := connect(t, tr)
+	defer c.Disconnect()
+	parent := tempDir(t)
+	defer os.RemoveAll(parent)
+	dir := filepath.Join(parent, "sync")
+
+	results, err := cardsync.Sync(c, cardsync.Options{Dir: dir, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{
+		filepath.Join(dir, "00010001", "100MSDCF", "DSC00001.JPG"),
+		filepath.Join(dir, "00010001", "100MSDCF", "DSC00001-1.JPG"),
+	}
+	var paths []string
+	for _, r := range results {
+		if r.Err != nil || r.Action != cardsync.ActionNew {
+			t.Errorf("handle %d: got action %v error %v", r.Handle, r.Action, r.Err)
+		}
+		paths = append(paths, r.Path)
+	}
+	if !reflect.DeepEqual(paths, expect) {
+		t.Errorf("got %q expected %q", paths, expect)
+	}
+	if tr.partials != 0 {
+		t.Errorf("got %d GetPartialObject in a dry run", tr.partials)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("dry run created the directory: %v", err)
+	}
+}
+
+func TestSyncManifestBatches(t *testing.T) {
+	const objects = 40
+	const saveEvery = 32
+
+	tr := newCardTransport()
+	for h := uint32(1); h <= objects; h++ {
+		tr.add(h, nil, []byte{0xFF, 0xD8, byte(h)})
+	}
+	c := connect(t, tr)
+	defer c.Disconnect()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	manifest := filepath.Join(dir, cardsync.DefaultManifest)
+
+	// the manifest on disk while objects are transferred
+	var saved []int
+	onResult := func(r *cardsync.Result) {
+		m, err := cardsync.LoadManifest(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved = append(saved, len(m.Entries))
+	}
+	if _, err := cardsync.Sync(c, cardsync.Options{Dir: dir, OnResult: onResult}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, n := range saved {
+		expect := 0
+		if saveEvery <= i+1 {
+			expect = saveEvery
+		}
+		if n != expect {
+			t.Errorf("object %d: got %d saved entries expected %d", i+1, n, expect)
+		}
+	}
+	m, err := cardsync.LoadManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != objects {
+		t.Errorf("got %d entries after Sync expected %d", len(m.Entries), objects)
+	}
+
+	// a sync without transfers does not rewrite the manifest
+	fi, err := os.Stat(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chtimes(manifest, fi.ModTime(), time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cardsync.Sync(c, cardsync.Options{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err = os.Stat(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(time.Unix(0, 0)) {
+		t.Error("manifest saved without changes")
+	}
+}
@@ -0,0 +1,93 @@
+package cardsync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const manifestVersion = 1
+
+// Entry records one object of the camera and its local copy.
+type Entry struct {
+	StorageID   uint32    `json:"storageID"`
+	Handle      uint32    `json:"handle"`
+	Filename    string    `json:"filename"`
+	CaptureDate time.Time `json:"captureDate"`
+	Size        uint32    `json:"size"`
+	// Path is the local copy relative to the sync directory.
+	Path string `json:"path"`
+	// SHA256 is the hash of the local copy, set when it is complete.
+	SHA256   string `json:"sha256,omitempty"`
+	Complete bool   `json:"complete"`
+}
+
+// Manifest is the set of objects synced to a directory, keyed by the path of
+// the object on the camera and its capture date. Handles are recorded but
+// not used as key as cameras may assign new handles in every session.
+type Manifest struct {
+	Version int               `json:"version"`
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// LoadManifest reads the manifest at path. A missing file is an empty manifest.
+func LoadManifest(path string) (m *Manifest, err error) {
+	m = &Manifest{Version: manifestVersion, Entries: make(map[string]*Entry)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if m.Entries == nil {
+		m.Entries = make(map[string]*Entry)
+	}
+	return m, nil
+}
+
+// Save writes the manifest to path through a temporary file, so that an
+// interrupted sync leaves either the old or the new manifest.
+func (m *Manifest) Save(path string) (err error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// paths returns the local paths in use.
+func (m *Manifest) paths() map[string]bool {
+	paths := make(map[string]bool, len(m.Entries))
+	for _, e := range m.Entries {
+		paths[e.Path] = true
+	}
+	return paths
+}
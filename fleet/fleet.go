@@ -0,0 +1,425 @@
+// Package fleet drives many cameras at once, e.g. a photogrammetry rig.
+//
+// A Manager owns one ptpip.Client per camera keyed by the camera GUID.
+// Operations are broadcast to all connected cameras in parallel and return
+// a Result per camera, so that one camera failing or being offline does not
+// affect the others. SetTimeout bounds the time a camera that hangs can hold
+// up an operation.
+package fleet
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// eventBufferSize is the number of events buffered per subscriber
+	eventBufferSize = 256
+)
+
+// ErrNotConnected is the Result error of a camera that is not connected.
+var ErrNotConnected = errors.New("fleet: camera not connected")
+
+// ErrTimeout is the Result error of a camera that did not complete an
+// operation within the timeout of the Manager.
+var ErrTimeout = errors.New("fleet: camera timed out")
+
+// Result is the outcome of an operation on one camera.
+type Result struct {
+	GUID string
+	// Start is when the operation was issued to the camera.
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Results are sorted by GUID.
+type Results []Result
+
+// Err returns the first error of the results, or nil if all succeeded.
+func (rs Results) Err() error {
+	for _, r := range rs {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+// Failed returns the results with an error.
+func (rs Results) Failed() Results {
+	var failed Results
+	for _, r := range rs {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Skew returns the time between the first and the last camera the
+// operation was issued to. Cameras that were not connected are ignored.
+func (rs Results) Skew() time.Duration {
+	var first, last time.Time
+	for _, r := range rs {
+		if r.Start.IsZero() {
+			continue
+		}
+		if first.IsZero() || r.Start.Before(first) {
+			first = r.Start
+		}
+		if r.Start.After(last) {
+			last = r.Start
+		}
+	}
+	return last.Sub(first)
+}
+
+// Event is an event of the camera with GUID.
+type Event struct {
+	GUID string
+	*packet.EventPacket
+}
+
+// camera states
+const (
+	stateNew = iota
+	stateConnected
+	// stateClosed is a camera that was disconnected or lost its connection.
+	// ptpip.Client cannot connect again, so it stays closed until replaced.
+	stateClosed
+)
+
+// camera is a client of the Manager.
+type camera struct {
+	c     *ptpip.Client
+	state int
+
+	// closeOnce guards Disconnect of c, which a timed out operation may race
+	closeOnce sync.Once
+}
+
+// disconnect disconnects the client once.
+func (cam *camera) disconnect() (err error) {
+	cam.closeOnce.Do(func() {
+		err = cam.c.Disconnect()
+	})
+	return err
+}
+
+// Manager ...
+type Manager struct {
+	mu      sync.Mutex
+	cameras map[string]*camera
+	timeout time.Duration
+
+	subsMu sync.Mutex
+	subs   map[chan *Event]struct{}
+}
+
+// New returns an empty Manager.
+func New() *Manager {
+	return &Manager{cameras: make(map[string]*camera), subs: make(map[chan *Event]struct{})}
+}
+
+// Add adds the camera with guid. A camera already added with guid is
+// replaced, e.g. with a new client to reconnect it after its connection was
+// lost; the old client is not disconnected.
+func (m *Manager) Add(guid string, c *ptpip.Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cameras[guid] = &camera{c: c}
+}
+
+// SetTimeout sets the time a camera has to complete an operation of the
+// Manager, including Connect. A camera that takes longer gets ErrTimeout and
+// is disconnected as soon as possible, it stays closed until replaced like
+// a camera that lost its connection. The default 0 waits forever.
+func (m *Manager) SetTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.timeout = d
+}
+
+// Remove removes the camera with guid. It does not disconnect the client.
+func (m *Manager) Remove(guid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.cameras, guid)
+}
+
+// Client returns the client of the camera with guid, or nil.
+func (m *Manager) Client(guid string) *ptpip.Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cam, ok := m.cameras[guid]; ok {
+		return cam.c
+	}
+	return nil
+}
+
+// GUIDs returns the GUIDs of all cameras in order.
+func (m *Manager) GUIDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	guids := make([]string, 0, len(m.cameras))
+	for guid := range m.cameras {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+	return guids
+}
+
+// Connected returns the GUIDs of the connected cameras in order. A camera
+// is no longer connected once its event connection closes.
+func (m *Manager) Connected() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var guids []string
+	for guid, cam := range m.cameras {
+		if cam.state == stateConnected {
+			guids = append(guids, guid)
+		}
+	}
+	sort.Strings(guids)
+	return guids
+}
+
+// Connect connects the new cameras in parallel and opens a session with
+// sessionID. Cameras that are already connected succeed, cameras that were
+// closed get ErrNotConnected. The events of the connected cameras are
+// delivered to the subscribers of the Manager.
+func (m *Manager) Connect(sessionID uint32) Results {
+	return m.run(func(cam *camera) bool { return cam.state != stateClosed }, func(guid string, cam *camera) error {
+		if m.selected(connected, cam) {
+			return nil
+		}
+		if err := cam.c.Connect(); err != nil {
+			m.setState(cam, stateClosed)
+			return err
+		}
+		if err := cam.c.OpenSession(sessionID); err != nil {
+			cam.disconnect()
+			m.setState(cam, stateClosed)
+			return err
+		}
+
+		events, _ := cam.c.Subscribe()
+		m.setState(cam, stateConnected)
+		go m.forward(guid, cam, events)
+		return nil
+	})
+}
+
+// Disconnect closes the sessions and connections of the connected cameras.
+func (m *Manager) Disconnect() Results {
+	return m.run(connected, func(guid string, cam *camera) error {
+		err := cam.c.CloseSession()
+		if derr := cam.disconnect(); err == nil {
+			err = derr
+		}
+		m.setState(cam, stateClosed)
+		return err
+	})
+}
+
+// Do calls fn for each connected camera in parallel. The calls are released
+// together once all goroutines are ready to minimize the skew between the
+// cameras. Cameras that are not connected get ErrNotConnected.
+func (m *Manager) Do(fn func(guid string, c *ptpip.Client) error) Results {
+	return m.run(connected, func(guid string, cam *camera) error {
+		return fn(guid, cam.c)
+	})
+}
+
+// SetDevicePropValue sets the property on all connected cameras.
+// value is encoded as for ptpip.Client.SetDevicePropValue.
+func (m *Manager) SetDevicePropValue(propCode uint16, value []byte) Results {
+	return m.Do(func(guid string, c *ptpip.Client) error {
+		return c.SetDevicePropValue(propCode, value)
+	})
+}
+
+// InitiateCapture triggers a capture on all connected cameras.
+func (m *Manager) InitiateCapture(storageID uint32, objectFormat uint16) Results {
+	return m.Do(func(guid string, c *ptpip.Client) error {
+		return c.InitiateCapture(storageID, objectFormat)
+	})
+}
+
+func connected(cam *camera) bool { return cam.state == stateConnected }
+
+// run calls fn for the cameras selected by sel in parallel and returns the
+// results of all cameras. Unselected cameras get ErrNotConnected, cameras
+// that do not return within the timeout ErrTimeout.
+func (m *Manager) run(sel func(*camera) bool, fn func(guid string, cam *camera) error) Results {
+	m.mu.Lock()
+	guids := make([]string, 0, len(m.cameras))
+	cameras := make(map[string]*camera, len(m.cameras))
+	for guid, cam := range m.cameras {
+		guids = append(guids, guid)
+		cameras[guid] = cam
+	}
+	timeout := m.timeout
+	m.mu.Unlock()
+	sort.Strings(guids)
+
+	// the goroutines send their result with its index instead of writing to
+	// results, so that a timed out one cannot write to it after run returns
+	type indexed struct {
+		i int
+		r Result
+	}
+	results := make(Results, len(guids))
+	running := make(map[int]chan struct{})
+	finished := make(chan indexed, len(guids))
+	var ready sync.WaitGroup
+	start := make(chan struct{})
+
+	for i, guid := range guids {
+		results[i].GUID = guid
+		cam := cameras[guid]
+		if !m.selected(sel, cam) {
+			results[i].Err = ErrNotConnected
+			continue
+		}
+
+		done := make(chan struct{})
+		running[i] = done
+		ready.Add(1)
+		go func(i int, cam *camera, done chan struct{}) {
+			defer close(done)
+			ready.Done()
+			<-start
+
+			r := Result{GUID: guids[i], Start: time.Now()}
+			r.Err = fn(r.GUID, cam)
+			r.Duration = time.Since(r.Start)
+			finished <- indexed{i, r}
+		}(i, cam, done)
+	}
+
+	ready.Wait()
+	close(start)
+	released := time.Now()
+
+	var expired <-chan time.Time
+	if 0 < timeout {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for 0 < len(running) {
+		select {
+		case f := <-finished:
+			results[f.i] = f.r
+			delete(running, f.i)
+		case <-expired:
+			for i, done := range running {
+				results[i].Start = released
+				results[i].Duration = time.Since(released)
+				results[i].Err = ErrTimeout
+				m.abandon(cameras[guids[i]], done)
+			}
+			return results
+		}
+	}
+
+	return results
+}
+
+// abandon closes a camera whose operation timed out. The client is
+// disconnected to unblock a transaction in progress; a client still
+// connecting is disconnected once Connect returns.
+func (m *Manager) abandon(cam *camera, done <-chan struct{}) {
+	m.setState(cam, stateClosed)
+
+	go func() {
+		if isOpen(cam.c) {
+			cam.disconnect()
+		}
+		<-done
+		if isOpen(cam.c) {
+			cam.disconnect()
+		}
+	}()
+}
+
+// isOpen reports whether c was connected and not yet disconnected.
+func isOpen(c *ptpip.Client) bool {
+	state := c.State()
+	return state == ptpip.StateConnected || state == ptpip.StateDead
+}
+
+func (m *Manager) selected(sel func(*camera) bool, cam *camera) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return sel(cam)
+}
+
+// setState sets the state of cam. A closed camera stays closed, so that a
+// timed out Connect that completes late does not revive it.
+func (m *Manager) setState(cam *camera, state int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cam.state == stateClosed {
+		return
+	}
+	cam.state = state
+}
+
+// forward delivers the events of one camera until its event connection closes.
+func (m *Manager) forward(guid string, cam *camera, events <-chan *packet.EventPacket) {
+	for e := range events {
+		m.publish(&Event{GUID: guid, EventPacket: e})
+	}
+	m.setState(cam, stateClosed)
+}
+
+// Subscribe returns a channel receiving the events of all connected
+// cameras and a function that ends the subscription. Events are dropped for
+// a subscriber that does not keep up. The channel is closed when cancel is
+// called.
+func (m *Manager) Subscribe() (events <-chan *Event, cancel func()) {
+	ch := make(chan *Event, eventBufferSize)
+
+	m.subsMu.Lock()
+	m.subs[ch] = struct{}{}
+	m.subsMu.Unlock()
+
+	cancel = func() {
+		m.subsMu.Lock()
+		defer m.subsMu.Unlock()
+		if _, ok := m.subs[ch]; ok {
+			delete(m.subs, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+func (m *Manager) publish(e *Event) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+
+	for ch := range m.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package fleet_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/fleet"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// camTransport answers every operation with OK unless it is listed in
+// codes, or blocks until Close for an operation listed in hang.
+type camTransport struct {
+	codes   map[packet.OperationCode]uint16
+	hang    map[packet.OperationCode]bool
+	connect chan struct{} // Connect waits for it if not nil
+
+	events chan *packet.EventPacket
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newCamTransport() *camTransport {
+	return &camTransport{
+		codes:  map[packet.OperationCode]uint16{},
+		hang:   map[packet.OperationCode]bool{},
+		events: make(chan *packet.EventPacket, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+func (t *camTransport) Connect() error {
+	if t.connect != nil {
+		<-t.connect
+	}
+	return nil
+}
+
+func (t *camTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+func (t *camTransport) isClosed() bool {
+	select {
+	case <-t.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *camTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	op := packet.OperationCode(req.OperationCode)
+	if t.hang[op] {
+		<-t.closed
+		return nil, nil, errors.New("connection closed")
+	}
+	resp := &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}
+	if code, ok := t.codes[op]; ok {
+		resp.ResponseCode = code
+		return nil, resp, &packet.ResponseError{Code: code}
+	}
+	return nil, resp, nil
+}
+
+func (t *camTransport) RecvEvent() (*packet.EventPacket, error) {
+	select {
+	case e := <-t.events:
+		return e, nil
+	case <-t.closed:
+		return nil, errors.New("connection closed")
+	}
+}
+
+func (t *camTransport) Cancel(transactionID uint32) error { return nil }
+
+func newManager(transports map[string]*camTransport) *fleet.Manager {
+	m := fleet.New()
+	for guid, tr := range transports {
+		m.Add(guid, ptpip.NewClientTransport(tr))
+	}
+	return m
+}
+
+func errs(rs fleet.Results) []error {
+	var errs []error
+	for _, r := range rs {
+		errs = append(errs, r.Err)
+	}
+	return errs
+}
+
+func isResponseError(err error, code uint16) bool {
+	re, ok := err.(*packet.ResponseError)
+	return ok && re.Code == code
+}
+
+func TestConnect(t *testing.T) {
+	ok, fails := newCamTransport(), newCamTransport()
+	fails.codes[packet.OperationCodeOpenSession] = packet.ResponseCodeDeviceBusy
+	m := newManager(map[string]*camTransport{"A": ok, "B": fails})
+	defer m.Disconnect()
+
+	rs := m.Connect(1)
+	if rs[0].GUID != "A" || rs[0].Err != nil || rs[1].GUID != "B" || !isResponseError(rs[1].Err, packet.ResponseCodeDeviceBusy) {
+		t.Fatalf("got %v", rs)
+	}
+	if guids := m.Connected(); !reflect.DeepEqual(guids, []string{"A"}) {
+		t.Errorf("got connected %v expected [A]", guids)
+	}
+	if !fails.isClosed() {
+		t.Error("the camera failing OpenSession was not disconnected")
+	}
+
+	// a connected camera succeeds again, a closed one is not reconnected
+	rs = m.Connect(1)
+	if e := errs(rs); e[0] != nil || e[1] != fleet.ErrNotConnected {
+		t.Errorf("second Connect got %v", e)
+	}
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    uint16
+		hang    bool
+		timeout time.Duration
+		check   func(err error) bool
+		closed  bool
+	}{
+		{"ok", packet.ResponseCodeOK, false, 0, func(err error) bool { return err == nil }, false},
+		{"response error", packet.ResponseCodeStoreFull, false, 0, func(err error) bool { return isResponseError(err, packet.ResponseCodeStoreFull) }, false},
+		{"timeout", packet.ResponseCodeOK, true, 50 * time.Millisecond, func(err error) bool { return err == fleet.ErrTimeout }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			good, cam := newCamTransport(), newCamTransport()
+			if tt.code != packet.ResponseCodeOK {
+				cam.codes[packet.OperationCodeInitiateCapture] = tt.code
+			}
+			cam.hang[packet.OperationCodeInitiateCapture] = tt.hang
+			m := newManager(map[string]*camTransport{"A": good, "B": cam})
+			m.SetTimeout(tt.timeout)
+			if err := m.Connect(1).Err(); err != nil {
+				t.Fatal(err)
+			}
+			defer m.Disconnect()
+
+			rs := m.InitiateCapture(0, 0)
+			if rs[0].Err != nil {
+				t.Errorf("A: got %v", rs[0].Err)
+			}
+			if !tt.check(rs[1].Err) {
+				t.Errorf("B: got %v", rs[1].Err)
+			}
+			if rs[1].Start.IsZero() {
+				t.Error("B: no Start")
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for cam.isClosed() != tt.closed && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if cam.isClosed() != tt.closed {
+				t.Errorf("B: got closed %v expected %v", cam.isClosed(), tt.closed)
+			}
+			if connected := len(m.Connected()) == 2; connected == tt.closed {
+				t.Errorf("got connected %v", m.Connected())
+			}
+		})
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	cam := newCamTransport()
+	cam.connect = make(chan struct{})
+	m := newManager(map[string]*camTransport{"A": cam})
+	m.SetTimeout(50 * time.Millisecond)
+
+	if e := errs(m.Connect(1)); e[0] != fleet.ErrTimeout {
+		t.Fatalf("got %v expected ErrTimeout", e)
+	}
+	// the late Connect does not revive the camera, which is disconnected
+	close(cam.connect)
+	select {
+	case <-cam.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("camera not disconnected after its Connect returned")
+	}
+	if guids := m.Connected(); len(guids) != 0 {
+		t.Errorf("got connected %v", guids)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	a, b := newCamTransport(), newCamTransport()
+	m := newManager(map[string]*camTransport{"A": a, "B": b})
+	events, cancel := m.Subscribe()
+	defer cancel()
+	if err := m.Connect(1).Err(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Disconnect()
+
+	b.events <- &packet.EventPacket{EventCode: uint16(packet.EventCodeObjectAdded), P1: 0x10}
+	select {
+	case e := <-events:
+		if e.GUID != "B" || e.P1 != 0x10 {
+			t.Errorf("got %s %+v", e.GUID, e.EventPacket)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+
+	// a camera whose event connection closes is no longer connected
+	a.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(m.Connected()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if guids := m.Connected(); !reflect.DeepEqual(guids, []string{"B"}) {
+		t.Errorf("got connected %v expected [B]", guids)
+	}
+	if e := errs(m.InitiateCapture(0, 0)); e[0] != fleet.ErrNotConnected || e[1] != nil {
+		t.Errorf("got %v", e)
+	}
+}
+
+func TestResults(t *testing.T) {
+	t0 := time.Now()
+	errA := errors.New("a")
+	rs := fleet.Results{
+		{GUID: "A", Start: t0.Add(2 * time.Millisecond), Err: errA},
+		{GUID: "B", Start: t0},
+		{GUID: "C", Err: fleet.ErrNotConnected},
+	}
+	if err := rs.Err(); err != errA {
+		t.Errorf("Err got %v", err)
+	}
+	if failed := rs.Failed(); len(failed) != 2 || failed[0].GUID != "A" || failed[1].GUID != "C" {
+		t.Errorf("Failed got %v", failed)
+	}
+	if skew := rs.Skew(); skew != 2*time.Millisecond {
+		t.Errorf("Skew got %v expected 2ms", skew)
+	}
+}
@@ -0,0 +1,204 @@
+// Package fuji implements the Fujifilm extension to PTP on top of ptpip.Client.
+//
+// Fujifilm bodies deviate from PTP-IP in their handshake and framing, so the
+// ptpip.Client must be created with NewClient, which installs Protocol.
+//
+// In remote shooting the bodies stream live view frames on a third
+// connection, see DialLiveView.
+package fuji
+
+import (
+	"io"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// VendorExtensionID is the VendorExtensionID reported by Fujifilm in DeviceInfo.
+	VendorExtensionID uint32 = 0x0000000E
+
+	captureReadyInterval = 100 * time.Millisecond
+	captureReadyTimeout  = 10 * time.Second
+)
+
+// Device Property Code
+const (
+	DevicePropCodeFilmSimulation     uint16 = 0xD001
+	DevicePropCodeFilmSimulationTune uint16 = 0xD002
+	DevicePropCodeDRangeMode         uint16 = 0xD007
+	DevicePropCodeColorMode          uint16 = 0xD008
+	DevicePropCodeColorSpace         uint16 = 0xD00A
+	DevicePropCodeColorTemperature   uint16 = 0xD017
+	DevicePropCodeQuality            uint16 = 0xD018
+	DevicePropCodeNoiseReduction     uint16 = 0xD01C
+	DevicePropCodeFaceDetectionMode  uint16 = 0xD020
+	DevicePropCodeRawCompression     uint16 = 0xD022
+	DevicePropCodeGrainEffect        uint16 = 0xD023
+	DevicePropCodeFocusPoints        uint16 = 0xD025
+	DevicePropCodePriorityMode       uint16 = 0xD207
+	DevicePropCodeCaptureControl     uint16 = 0xD208
+)
+
+// PriorityMode value
+const (
+	PriorityModeCamera uint16 = 0x0001
+	PriorityModePC     uint16 = 0x0002
+)
+
+// CaptureControl value
+const (
+	CaptureControlAutoFocus uint16 = 0x0200
+	CaptureControlShoot     uint16 = 0x0304
+)
+
+// Mode is the connection mode selected on the camera.
+type Mode int
+
+const (
+	// ModeRemote is remote shooting: the host controls the camera and
+	// downloads what it captures.
+	ModeRemote Mode = iota
+	// ModePCAutoSave is "PC AutoSave": the camera offers the images not yet
+	// transferred and the host downloads all of them.
+	ModePCAutoSave
+)
+
+// Vendor is the Fujifilm vendor extension registered with ptpip.
+var Vendor = &ptpip.Vendor{
+	Name:              "fuji",
+	VendorExtensionID: VendorExtensionID,
+	Manufacturer:      "FUJIFILM",
+	DeviceProps: map[uint16]string{
+		DevicePropCodeFilmSimulation:     "FilmSimulation",
+		DevicePropCodeFilmSimulationTune: "FilmSimulationTune",
+		DevicePropCodeDRangeMode:         "DRangeMode",
+		DevicePropCodeColorMode:          "ColorMode",
+		DevicePropCodeColorSpace:         "ColorSpace",
+		DevicePropCodeColorTemperature:   "ColorTemperature",
+		DevicePropCodeQuality:            "Quality",
+		DevicePropCodeNoiseReduction:     "NoiseReduction",
+		DevicePropCodeFaceDetectionMode:  "FaceDetectionMode",
+		DevicePropCodeRawCompression:     "RawCompression",
+		DevicePropCodeGrainEffect:        "GrainEffect",
+		DevicePropCodeFocusPoints:        "FocusPoints",
+		DevicePropCodePriorityMode:       "PriorityMode",
+		DevicePropCodeCaptureControl:     "CaptureControl",
+	},
+	DevicePropTypes: map[uint16]uint16{
+		DevicePropCodePriorityMode:   packet.DataTypeUInt16,
+		DevicePropCodeCaptureControl: packet.DataTypeUInt16,
+	},
+}
+
+func init() {
+	ptpip.RegisterVendor(Vendor)
+}
+
+// NewClient returns a ptpip.Client speaking the Fujifilm Protocol.
+func NewClient(host string, initiator *ptpip.Initiator) *ptpip.Client {
+	c := ptpip.NewClient(host, initiator)
+	c.SetProtocol(Protocol{})
+	return c
+}
+
+// Camera wraps a ptpip.Client connected to a Fujifilm body.
+type Camera struct {
+	c    *ptpip.Client
+	mode Mode
+}
+
+// New returns a Camera for c, which must have been created with NewClient.
+func New(c *ptpip.Client, mode Mode) *Camera {
+	return &Camera{c: c, mode: mode}
+}
+
+// Client returns the underlying ptpip.Client.
+func (cam *Camera) Client() *ptpip.Client {
+	return cam.c
+}
+
+// Connect establishes the connection and opens a session. In ModeRemote it
+// also gives the host priority over the camera controls.
+func (cam *Camera) Connect(sessionID uint32) (err error) {
+	if err = cam.c.Connect(); err != nil {
+		return err
+	}
+	if err = cam.c.OpenSession(sessionID); err != nil {
+		return err
+	}
+	if cam.mode == ModeRemote {
+		return cam.setU16(DevicePropCodePriorityMode, PriorityModePC)
+	}
+	return nil
+}
+
+// Capture focuses and takes a picture. Only available in ModeRemote.
+func (cam *Camera) Capture() (err error) {
+	if err = cam.setU16(DevicePropCodeCaptureControl, CaptureControlAutoFocus); err != nil {
+		return err
+	}
+	if err = cam.initiateCapture(); err != nil {
+		return err
+	}
+	if err = cam.setU16(DevicePropCodeCaptureControl, CaptureControlShoot); err != nil {
+		return err
+	}
+	return cam.initiateCapture()
+}
+
+// initiateCapture retries InitiateCapture while the camera is busy with the previous step.
+func (cam *Camera) initiateCapture() (err error) {
+	deadline := time.Now().Add(captureReadyTimeout)
+	for {
+		err = cam.c.InitiateCapture(0, 0)
+		re, ok := err.(*packet.ResponseError)
+		if !ok || re.Code != packet.ResponseCodeDeviceBusy || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(captureReadyInterval)
+	}
+}
+
+// Objects returns the handles of the objects offered by the camera. In
+// ModePCAutoSave these are the images not yet transferred.
+func (cam *Camera) Objects() (handles []uint32, err error) {
+	return cam.c.GetObjectHandles(0xFFFFFFFF, 0, 0)
+}
+
+// Download writes the object to w.
+func (cam *Camera) Download(handle uint32, w io.Writer) (err error) {
+	data, err := cam.c.GetObject(handle)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// DownloadAll downloads every object offered by the camera and passes each to fn.
+func (cam *Camera) DownloadAll(fn func(handle uint32, data []byte) error) (err error) {
+	handles, err := cam.Objects()
+	if err != nil {
+		return err
+	}
+	for _, h := range handles {
+		data, err := cam.c.GetObject(h)
+		if err != nil {
+			return err
+		}
+		if err = fn(h, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cam *Camera) setU16(propCode uint16, value uint16) (err error) {
+	data, err := packet.EncodeValue(value, packet.DataTypeUInt16)
+	if err != nil {
+		return err
+	}
+	return cam.c.SetDevicePropValue(propCode, data)
+}
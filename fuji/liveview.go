@@ -0,0 +1,121 @@
+package fuji
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/takurooo/ptpip/liveview"
+)
+
+const (
+	liveViewPort string = ":55742"
+
+	// liveViewHeaderSize is the size of the header preceding the JPEG in a
+	// frame: Length(4) Type(2) Code(2) TransactionID(4) and 2 more bytes
+	liveViewHeaderSize = 14
+	// maxLiveViewFrameSize bounds the frame length read from the stream
+	maxLiveViewFrameSize = 8 << 20
+)
+
+// ErrNoLiveViewImage is returned by LiveViewImage when no frame arrived since the previous call.
+var ErrNoLiveViewImage error = liveview.NoFrameError("fuji: no live view image")
+
+// LiveView receives the live view stream the camera sends in ModeRemote.
+// It implements liveview.Source.
+type LiveView struct {
+	conn net.Conn
+	done chan struct{}
+
+	mu   sync.Mutex
+	jpeg []byte // newest frame not yet returned by LiveViewImage
+	err  error
+}
+
+// DialLiveView connects to the live view port of host, the host passed to
+// NewClient. The camera sends frames once Camera.Connect succeeded in
+// ModeRemote.
+func DialLiveView(host string) (lv *LiveView, err error) {
+	conn, err := net.Dial("tcp", host+liveViewPort)
+	if err != nil {
+		return nil, err
+	}
+	return NewLiveView(conn), nil
+}
+
+// NewLiveView returns a LiveView receiving the frames from conn.
+func NewLiveView(conn net.Conn) *LiveView {
+	lv := &LiveView{conn: conn, done: make(chan struct{})}
+	go lv.receive()
+	return lv
+}
+
+// LiveViewImage returns the newest frame as JPEG. Frames are pushed by the
+// camera, the frames arriving between two calls are dropped but the newest.
+func (lv *LiveView) LiveViewImage() (jpeg []byte, err error) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	if lv.jpeg != nil {
+		jpeg, lv.jpeg = lv.jpeg, nil
+		return jpeg, nil
+	}
+	if lv.err != nil {
+		return nil, lv.err
+	}
+	return nil, ErrNoLiveViewImage
+}
+
+// Close closes the connection.
+func (lv *LiveView) Close() (err error) {
+	err = lv.conn.Close()
+	<-lv.done
+	return err
+}
+
+func (lv *LiveView) receive() {
+	defer close(lv.done)
+
+	for {
+		jpeg, err := readLiveViewFrame(lv.conn)
+		if err == ErrNoLiveViewImage {
+			continue
+		}
+
+		lv.mu.Lock()
+		if err != nil {
+			lv.err = err
+			lv.mu.Unlock()
+			return
+		}
+		lv.jpeg = jpeg
+		lv.mu.Unlock()
+	}
+}
+
+// readLiveViewFrame reads a frame and returns its JPEG. The Length includes
+// the header.
+func readLiveViewFrame(r io.Reader) (jpeg []byte, err error) {
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(header)
+	if length < liveViewHeaderSize || maxLiveViewFrameSize < length {
+		return nil, fmt.Errorf("fuji: invalid live view frame len %d", length)
+	}
+
+	frame := make([]byte, length)
+	copy(frame, header)
+	if _, err = io.ReadFull(r, frame[4:]); err != nil {
+		return nil, err
+	}
+
+	jpeg = frame[liveViewHeaderSize:]
+	if len(jpeg) < 2 || jpeg[0] != 0xFF || jpeg[1] != 0xD8 {
+		return nil, ErrNoLiveViewImage
+	}
+	return jpeg, nil
+}
@@ -0,0 +1,84 @@
+package fuji_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip/fuji"
+)
+
+func liveViewFrame(jpeg []byte) []byte {
+	frame := make([]byte, 14+len(jpeg))
+	binary.LittleEndian.PutUint32(frame, uint32(len(frame)))
+	copy(frame[14:], jpeg)
+	return frame
+}
+
+// waitImage polls lv until it returns a frame or an error other than ErrNoLiveViewImage.
+func waitImage(t *testing.T, lv *fuji.LiveView) ([]byte, error) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		jpeg, err := lv.LiveViewImage()
+		if err != fuji.ErrNoLiveViewImage {
+			return jpeg, err
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no frame received")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLiveView(t *testing.T) {
+	conn, dev := net.Pipe()
+	lv := fuji.NewLiveView(conn)
+	defer lv.Close()
+
+	if _, err := lv.LiveViewImage(); err != fuji.ErrNoLiveViewImage {
+		t.Errorf("before the first frame: got %v expected ErrNoLiveViewImage", err)
+	}
+
+	first := []byte{0xFF, 0xD8, 0x01, 0xFF, 0xD9}
+	second := []byte{0xFF, 0xD8, 0x02, 0xFF, 0xD9}
+	// a frame without JPEG is skipped
+	dev.Write(liveViewFrame([]byte{0x00, 0x00}))
+	dev.Write(liveViewFrame(first))
+	jpeg, err := waitImage(t, lv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(jpeg, first) {
+		t.Errorf("got % x expected % x", jpeg, first)
+	}
+	if _, err = lv.LiveViewImage(); err != fuji.ErrNoLiveViewImage {
+		t.Errorf("frame returned twice: %v", err)
+	}
+
+	dev.Write(liveViewFrame(second))
+	if jpeg, err = waitImage(t, lv); err != nil || !bytes.Equal(jpeg, second) {
+		t.Errorf("got % x, %v expected % x", jpeg, err, second)
+	}
+
+	dev.Close()
+	if _, err = waitImage(t, lv); err == nil {
+		t.Error("closed stream: got nil expected error")
+	}
+}
+
+func TestLiveViewFrameTooLong(t *testing.T) {
+	conn, dev := net.Pipe()
+	lv := fuji.NewLiveView(conn)
+	defer lv.Close()
+	defer dev.Close()
+
+	header := make([]byte, 4)
+	binary.LittleEndian.PutUint32(header, 0xFFFFFFFF)
+	dev.Write(header)
+	if _, err := waitImage(t, lv); err == nil {
+		t.Error("got nil expected error")
+	}
+}
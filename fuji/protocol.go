@@ -0,0 +1,119 @@
+package fuji
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"unicode/utf16"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/usb"
+)
+
+const (
+	commandPort string = ":55740"
+	eventPort   string = ":55741"
+
+	// initProtocolVersion replaces the ProtocolVersion of InitCommandRequest
+	initProtocolVersion uint32 = 0x8F53E4F2
+	// initFriendlyNameSize is the fixed size of the UTF-16 friendly name
+	initFriendlyNameSize = 54
+	// maxInitAckSize bounds the InitCommandAck read from the device, which
+	// is far smaller
+	maxInitAckSize = 1024
+)
+
+// Protocol is the ptpip.Protocol spoken by Fujifilm bodies. The
+// InitCommandRequest carries a fixed magic instead of the protocol version
+// and a fixed size friendly name, the event connection has no handshake,
+// and after the handshake transactions use the USB container format
+// Length(4) Type(2) Code(2) TransactionID(4) Payload of package usb instead
+// of PTP-IP packets.
+type Protocol struct{}
+
+// CommandPort ...
+func (Protocol) CommandPort() string { return commandPort }
+
+// EventPort ...
+func (Protocol) EventPort() string { return eventPort }
+
+// InitCommand ...
+func (Protocol) InitCommand(conn net.Conn, ini *ptpip.Initiator) (connectionNumber uint32, err error) {
+	if 16 < len(ini.GUID) {
+		return 0, fmt.Errorf("fuji: invalid initiator GUID len 16 < %d", len(ini.GUID))
+	}
+
+	name := utf16.Encode([]rune(ini.FriendlyName))
+	if initFriendlyNameSize/2-1 < len(name) {
+		return 0, fmt.Errorf("fuji: invalid initiator FriendlyName len %d < %d", initFriendlyNameSize/2-1, len(name))
+	}
+
+	// Length(4) Type(4) Version(4) GUID(16) FriendlyName(54)
+	req := make([]byte, 28+initFriendlyNameSize)
+	binary.LittleEndian.PutUint32(req[0:], uint32(len(req)))
+	binary.LittleEndian.PutUint32(req[4:], packet.PacketTypeInitCommandRequest)
+	binary.LittleEndian.PutUint32(req[8:], initProtocolVersion)
+	copy(req[12:28], ini.GUID)
+	for i, c := range name {
+		binary.LittleEndian.PutUint16(req[28+i*2:], c)
+	}
+
+	if _, err = conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	// Length(4) Type(4) Body
+	header := make([]byte, 8)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return 0, err
+	}
+	length := binary.LittleEndian.Uint32(header[0:])
+	packetType := binary.LittleEndian.Uint32(header[4:])
+	if length < 8 || maxInitAckSize < length {
+		return 0, fmt.Errorf("fuji: invalid init ack len %d", length)
+	}
+	body := make([]byte, length-8)
+	if _, err = io.ReadFull(conn, body); err != nil {
+		return 0, err
+	}
+
+	switch packetType {
+	case packet.PacketTypeInitCommandAck:
+	case packet.PacketTypeInitFail:
+		if len(body) < 4 {
+			return 0, &packet.InitFailError{Reason: packet.InitFailReasonUnspecified}
+		}
+		return 0, &packet.InitFailError{Reason: binary.LittleEndian.Uint32(body)}
+	default:
+		return 0, fmt.Errorf("fuji: invalid packet type 0x%08x expected 0x%08x", packetType, packet.PacketTypeInitCommandAck)
+	}
+
+	if 4 <= len(body) {
+		connectionNumber = binary.LittleEndian.Uint32(body)
+	}
+	return connectionNumber, nil
+}
+
+// InitEvent does nothing, the event connection needs no handshake.
+func (Protocol) InitEvent(conn net.Conn, connectionNumber uint32) error {
+	return nil
+}
+
+// Cancel is not supported: USB cancels by a class request that has no
+// equivalent in the container format over TCP.
+func (Protocol) Cancel(cConn, eConn net.Conn, transactionID uint32) error {
+	return errors.New("fuji: cancel not supported")
+}
+
+// OperationRequest ...
+func (Protocol) OperationRequest(conn net.Conn, req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	return usb.OperationRequest(conn, conn, req, sendData)
+}
+
+// RecvEvent ...
+func (Protocol) RecvEvent(conn net.Conn) (e *packet.EventPacket, err error) {
+	return usb.RecvEvent(conn)
+}
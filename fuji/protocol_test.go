@@ -0,0 +1,35 @@
+package fuji_test
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/fuji"
+	"github.com/takurooo/ptpip/packet"
+)
+
+func TestInitCommandAckTooLong(t *testing.T) {
+	conn, dev := net.Pipe()
+	defer conn.Close()
+	go func() {
+		defer dev.Close()
+		req := make([]byte, 82)
+		if _, err := io.ReadFull(dev, req); err != nil {
+			return
+		}
+		ack := make([]byte, 8)
+		binary.LittleEndian.PutUint32(ack[0:], 0xFFFFFFFF)
+		binary.LittleEndian.PutUint32(ack[4:], packet.PacketTypeInitCommandAck)
+		dev.Write(ack)
+		io.Copy(ioutil.Discard, dev)
+	}()
+
+	ini := &ptpip.Initiator{GUID: make([]byte, 16), FriendlyName: "ptpip"}
+	if _, err := (fuji.Protocol{}).InitCommand(conn, ini); err == nil {
+		t.Error("got nil expected error")
+	}
+}
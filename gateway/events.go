@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// event is the JSON form of a camera event.
+type event struct {
+	Code          uint16    `json:"code"`
+	Name          string    `json:"name,omitempty"`
+	TransactionID uint32    `json:"transactionID"`
+	Params        [3]uint32 `json:"params"`
+}
+
+func (h *Handler) getEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+
+	events, cancel := h.c.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(h.event(e))
+			if err != nil {
+				return
+			}
+			if _, err = fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) event(e *packet.EventPacket) event {
+	name, ok := h.c.Vendor().EventName(e.EventCode)
+	if !ok {
+		name = packet.EventCode(e.EventCode).String()
+	}
+	return event{
+		Code:          e.EventCode,
+		Name:          name,
+		TransactionID: e.TransactionID,
+		Params:        [3]uint32{e.P1, e.P2, e.P3},
+	}
+}
@@ -0,0 +1,452 @@
+// Package gateway exposes a connected ptpip.Client as an HTTP/JSON API, so
+// that programs not written in Go can drive a camera.
+//
+//	GET    /device                        DeviceInfo
+//	GET    /storages                      storage IDs
+//	GET    /storages/{id}/objects         ObjectInfo of the objects in a storage (?parent=)
+//	POST   /storages/{id}/objects         upload the request body (?name=&format=&parent=)
+//	GET    /objects/{handle}              object data, Range requests use GetPartialObject
+//	GET    /objects/{handle}/info         ObjectInfo
+//	GET    /objects/{handle}/thumb        thumbnail
+//	DELETE /objects/{handle}              delete the object
+//	GET    /properties/{code}             DevicePropDesc
+//	PUT    /properties/{code}             set the value, body {"value": ...}
+//	POST   /capture                       InitiateCapture (?storage=&format=)
+//	GET    /events                        Server-Sent Events stream of camera events
+//
+// IDs, handles and codes are accepted in decimal or 0x prefixed hexadecimal.
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Handler is an http.Handler serving the API for one PTP session.
+type Handler struct {
+	c *ptpip.Client
+
+	// mu serializes the requests on the single session, so that the
+	// transactions of one request, e.g. SendObjectInfo and SendObject, are
+	// not interleaved with another's. A download holds it only for each
+	// GetPartialObject, not while the response is written.
+	mu sync.Mutex
+}
+
+// maxObjectSize is the largest upload, ObjectCompressedSize is 32 bits.
+const maxObjectSize = 0xFFFFFFFF
+
+// New ...
+func New(c *ptpip.Client) *Handler {
+	return &Handler{c: c}
+}
+
+// ServeHTTP ...
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(path.Clean(r.URL.Path), "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "device":
+		h.allow(w, r, http.MethodGet, h.getDevice)
+	case len(parts) == 1 && parts[0] == "storages":
+		h.allow(w, r, http.MethodGet, h.getStorages)
+	case len(parts) == 3 && parts[0] == "storages" && parts[2] == "objects":
+		id, ok := parseID(w, parts[1])
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.getObjects(w, r, id)
+		case http.MethodPost:
+			h.postObject(w, r, id)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	case len(parts) == 2 && parts[0] == "objects":
+		handle, ok := parseID(w, parts[1])
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			h.getObject(w, r, handle)
+		case http.MethodDelete:
+			h.deleteObject(w, r, handle)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	case len(parts) == 3 && parts[0] == "objects" && (parts[2] == "info" || parts[2] == "thumb"):
+		handle, ok := parseID(w, parts[1])
+		if !ok {
+			return
+		}
+		if parts[2] == "info" {
+			h.allow(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { h.getObjectInfo(w, r, handle) })
+		} else {
+			h.allow(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { h.getThumb(w, r, handle) })
+		}
+	case len(parts) == 2 && parts[0] == "properties":
+		code, ok := parseID(w, parts[1])
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.getProperty(w, r, uint16(code))
+		case http.MethodPut:
+			h.putProperty(w, r, uint16(code))
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPut)
+		}
+	case len(parts) == 1 && parts[0] == "capture":
+		h.allow(w, r, http.MethodPost, h.postCapture)
+	case len(parts) == 1 && parts[0] == "events":
+		h.allow(w, r, http.MethodGet, h.getEvents)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint %s", r.URL.Path))
+	}
+}
+
+func (h *Handler) allow(w http.ResponseWriter, r *http.Request, method string, fn http.HandlerFunc) {
+	if r.Method != method {
+		methodNotAllowed(w, method)
+		return
+	}
+	fn(w, r)
+}
+
+func (h *Handler) getDevice(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	info, err := h.c.GetDeviceInfo()
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (h *Handler) getStorages(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids, err := h.c.GetStorageIDs()
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ids)
+}
+
+// object is the JSON form of an object in a listing.
+type object struct {
+	Handle uint32             `json:"handle"`
+	Info   *packet.ObjectInfo `json:"info"`
+}
+
+func (h *Handler) getObjects(w http.ResponseWriter, r *http.Request, storageID uint32) {
+	parent, ok := queryID(w, r, "parent", 0)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	handles, err := h.c.GetObjectHandles(storageID, 0, parent)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	objects := make([]object, 0, len(handles))
+	for _, handle := range handles {
+		info, err := h.c.GetObjectInfo(handle)
+		if err != nil {
+			writeDeviceError(w, err)
+			return
+		}
+		objects = append(objects, object{Handle: handle, Info: info})
+	}
+	writeJSON(w, http.StatusOK, objects)
+}
+
+func (h *Handler) postObject(w http.ResponseWriter, r *http.Request, storageID uint32) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing name"))
+		return
+	}
+	format, ok := queryID(w, r, "format", uint32(packet.ObjectFormatCodeUndefined))
+	if !ok {
+		return
+	}
+	parent, ok := queryID(w, r, "parent", 0)
+	if !ok {
+		return
+	}
+
+	if maxObjectSize < r.ContentLength {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("object larger than %d bytes", int64(maxObjectSize)))
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxObjectSize))
+	if err != nil {
+		// MaxBytesReader returns the bytes up to the limit before its error
+		if int64(len(data)) == maxObjectSize {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("object larger than %d bytes", int64(maxObjectSize)))
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	info := &packet.ObjectInfo{
+		StorageID:            storageID,
+		ObjectFormat:         uint16(format),
+		ObjectCompressedSize: uint32(len(data)),
+		ParentObject:         parent,
+		Filename:             name,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, _, handle, err := h.c.SendObjectInfo(storageID, parent, info)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	if err = h.c.SendObject(data); err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]uint32{"handle": handle})
+}
+
+func (h *Handler) getObject(w http.ResponseWriter, r *http.Request, handle uint32) {
+	h.mu.Lock()
+	or, err := h.c.NewObjectReader(handle)
+	h.mu.Unlock()
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	info := or.Info()
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", info.Filename))
+	http.ServeContent(w, r, info.Filename, time.Time{}, &lockedReader{mu: &h.mu, r: or})
+}
+
+// lockedReader holds mu while the ObjectReader issues GetPartialObject, so
+// that a slow client does not block the other requests while ServeContent
+// writes the response.
+type lockedReader struct {
+	mu *sync.Mutex
+	r  *ptpip.ObjectReader
+}
+
+// Read ...
+func (l *lockedReader) Read(p []byte) (n int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(p)
+}
+
+// Seek ...
+func (l *lockedReader) Seek(offset int64, whence int) (int64, error) {
+	return l.r.Seek(offset, whence)
+}
+
+func (h *Handler) getObjectInfo(w http.ResponseWriter, r *http.Request, handle uint32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	info, err := h.c.GetObjectInfo(handle)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (h *Handler) getThumb(w http.ResponseWriter, r *http.Request, handle uint32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	data, err := h.c.GetThumb(handle)
+	if err == ptpip.ErrNoThumbnailPresent {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (h *Handler) deleteObject(w http.ResponseWriter, r *http.Request, handle uint32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.c.DeleteObject(handle); err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) getProperty(w http.ResponseWriter, r *http.Request, code uint16) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	desc, err := h.c.GetDevicePropDesc(code)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, desc)
+}
+
+func (h *Handler) putProperty(w http.ResponseWriter, r *http.Request, code uint16) {
+	var body struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Value == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body, expected {\"value\": ...}"))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	desc, err := h.c.GetDevicePropDesc(code)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	v, err := parseValue(body.Value, desc.DataType)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	data, err := packet.EncodeValue(v, desc.DataType)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = h.c.SetDevicePropValue(code, data); err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) postCapture(w http.ResponseWriter, r *http.Request) {
+	storageID, ok := queryID(w, r, "storage", 0)
+	if !ok {
+		return
+	}
+	format, ok := queryID(w, r, "format", 0)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.c.InitiateCapture(storageID, uint16(format)); err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parseValue converts a JSON number or string to the Go type EncodeValue expects for dataType.
+func parseValue(raw json.RawMessage, dataType uint16) (v interface{}, err error) {
+	if dataType == packet.DataTypeString {
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("invalid string value %s", raw)
+		}
+		return s, nil
+	}
+
+	s := strings.Trim(string(raw), `"`)
+	switch dataType {
+	case packet.DataTypeInt8, packet.DataTypeInt16, packet.DataTypeInt32, packet.DataTypeInt64:
+		return strconv.ParseInt(s, 0, 8*packet.DataTypeSize(dataType))
+	case packet.DataTypeUInt8, packet.DataTypeUInt16, packet.DataTypeUInt32, packet.DataTypeUInt64:
+		return strconv.ParseUint(s, 0, 8*packet.DataTypeSize(dataType))
+	}
+	return nil, fmt.Errorf("unsupported data type 0x%04x", dataType)
+}
+
+func parseID(w http.ResponseWriter, s string) (id uint32, ok bool) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid id %q", s))
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+func queryID(w http.ResponseWriter, r *http.Request, key string, def uint32) (id uint32, ok bool) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return def, true
+	}
+	return parseID(w, s)
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeDeviceError maps an error from the device to an HTTP status.
+func writeDeviceError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	if re, ok := err.(*packet.ResponseError); ok {
+		switch re.Code {
+		case packet.ResponseCodeInvalidObjectHandle, packet.ResponseCodeInvalidStorageID, packet.ResponseCodeDevicePropNotSupported:
+			status = http.StatusNotFound
+		case packet.ResponseCodeOperationNotSupported:
+			status = http.StatusNotImplemented
+		case packet.ResponseCodeDeviceBusy:
+			status = http.StatusServiceUnavailable
+		case packet.ResponseCodeAccessDenied, packet.ResponseCodeObjectWriteProtected, packet.ResponseCodeStoreReadOnly:
+			status = http.StatusForbidden
+		case packet.ResponseCodeStoreFull:
+			status = http.StatusInsufficientStorage
+		case packet.ResponseCodeInvalidParameter, packet.ResponseCodeInvalidDevicePropValue, packet.ResponseCodeInvalidDevicePropFormat:
+			status = http.StatusBadRequest
+		}
+	}
+	writeError(w, status, err)
+}
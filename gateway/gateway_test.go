@@ -0,0 +1,193 @@
+package gateway_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/gateway"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// sessionTransport serves one object and accepts uploads. It fails
+// SendObject when another operation was run after SendObjectInfo, as a
+// device does.
+type sessionTransport struct {
+	object []byte
+
+	mu          sync.Mutex
+	objectInfo  bool
+	interleaved bool
+
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (t *sessionTransport) Connect() error { return nil }
+
+func (t *sessionTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+func (t *sessionTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	// give the other requests a chance to run in between
+	time.Sleep(time.Millisecond)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp := &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}
+	objectInfo := t.objectInfo
+	t.objectInfo = false
+
+	switch packet.OperationCode(req.OperationCode) {
+	case packet.OperationCodeGetObjectInfo:
+		data, err := packet.MarshalObjectInfo(&packet.ObjectInfo{ObjectCompressedSize: uint32(len(t.object)), Filename: "DSC00001.JPG"})
+		if err != nil {
+			return nil, nil, err
+		}
+		return data, resp, nil
+	case packet.OperationCodeGetPartialObject:
+		off, n := int(req.P2), int(req.P3)
+		if len(t.object) < off+n {
+			n = len(t.object) - off
+		}
+		return t.object[off : off+n], resp, nil
+	case packet.OperationCodeSendObjectInfo:
+		t.objectInfo = true
+		resp.P1, resp.P2, resp.P3 = req.P1, req.P2, 0x20
+	case packet.OperationCodeSendObject:
+		if !objectInfo {
+			t.interleaved = true
+			resp.ResponseCode = packet.ResponseCodeNoValidObjectInfo
+		}
+	default:
+		resp.ResponseCode = packet.ResponseCodeOperationNotSupported
+	}
+	return nil, resp, nil
+}
+
+func (t *sessionTransport) RecvEvent() (*packet.EventPacket, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *sessionTransport) Cancel(transactionID uint32) error { return nil }
+
+func TestUploadDuringDownload(t *testing.T) {
+	object := bytes.Repeat([]byte{0xFF, 0xD8}, 3<<20/2)
+	tr := &sessionTransport{object: object, closed: make(chan struct{})}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	srv := httptest.NewServer(gateway.New(c))
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(srv.URL + "/objects/0x10")
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer resp.Body.Close()
+			data, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if resp.StatusCode != http.StatusOK || !bytes.Equal(data, object) {
+				t.Errorf("download: status %d len %d", resp.StatusCode, len(data))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(srv.URL+"/storages/0x10001/objects?name=A.JPG", "image/jpeg", bytes.NewReader([]byte{0xFF, 0xD8}))
+			if err != nil {
+				errs <- err
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusCreated {
+				t.Errorf("upload: status %d", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if tr.interleaved {
+		t.Error("an operation was run between SendObjectInfo and SendObject")
+	}
+}
+
+func TestSlowDownload(t *testing.T) {
+	object := bytes.Repeat([]byte{0xFF, 0xD8}, 8<<20/2)
+	tr := &sessionTransport{object: object, closed: make(chan struct{})}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	srv := httptest.NewServer(gateway.New(c))
+	defer srv.Close()
+
+	// the download stalls once the connection buffers are full
+	resp, err := http.Get(srv.URL + "/objects/0x10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(srv.URL + "/objects/0x10/info")
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked by the unread download")
+	}
+}
+
+func TestUploadTooLarge(t *testing.T) {
+	tr := &sessionTransport{closed: make(chan struct{})}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	r := httptest.NewRequest(http.MethodPost, "/storages/0x10001/objects?name=A.JPG", bytes.NewReader([]byte{0xFF, 0xD8}))
+	r.ContentLength = 1 << 32
+	w := httptest.NewRecorder()
+	gateway.New(c).ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d expected %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
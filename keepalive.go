@@ -0,0 +1,211 @@
+package ptpip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProbeInterval = 10 * time.Second
+	defaultProbeTimeout  = 5 * time.Second
+)
+
+// ErrPeerDead is reported when the responder does not answer a probe in time.
+var ErrPeerDead = errors.New("responder does not answer probe")
+
+// ConnState is the state of the connection of a Client.
+type ConnState int
+
+// Connection states
+const (
+	// StateDisconnected is the state before Connect.
+	StateDisconnected ConnState = iota
+	// StateConnected is the state after Connect.
+	StateConnected
+	// StateDead is the state after the responder was lost: a probe was not
+	// answered, the event connection failed or the protocol was violated.
+	// Both connections are closed and Disconnect must still be called.
+	StateDead
+	// StateClosed is the state after Disconnect.
+	StateClosed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnected:
+		return "connected"
+	case StateDead:
+		return "dead"
+	case StateClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("ConnState(%d)", int(s))
+}
+
+// KeepaliveOptions ...
+type KeepaliveOptions struct {
+	// Interval is the time between probes, default 10s.
+	Interval time.Duration
+	// Timeout is the time to wait for data from the responder after a
+	// probe, default 5s.
+	Timeout time.Duration
+	// TCPKeepAlive is the keepalive period of both sockets. Zero uses the
+	// system default period, a negative value disables TCP keepalive.
+	TCPKeepAlive time.Duration
+}
+
+// SetKeepalive enables probing the responder on the event connection of a
+// Client using PTP-IP. A responder that sends nothing within Timeout after a
+// ProbeRequest is dead: both connections are closed, so that a transaction in
+// progress fails, and the state changes to StateDead. It must be called
+// before Connect.
+func (c *Client) SetKeepalive(opts KeepaliveOptions) {
+	if opts.Interval == 0 {
+		opts.Interval = defaultProbeInterval
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultProbeTimeout
+	}
+	c.keepaliveOpts = &opts
+}
+
+// NotifyState sets a function called on every change of the connection
+// state. err is the cause of StateDead and nil otherwise. It must be called
+// before Connect.
+func (c *Client) NotifyState(f func(state ConnState, err error)) {
+	c.onState = f
+}
+
+// State returns the connection state.
+func (c *Client) State() ConnState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	return c.state
+}
+
+// setState changes the state and returns the previous state. A closed
+// client stays closed and a dead client does not change to dead again, so
+// that only the first cause is reported.
+func (c *Client) setState(state ConnState, err error) (prev ConnState) {
+	c.stateMu.Lock()
+	prev = c.state
+	if prev == state || prev == StateClosed || (prev == StateDead && state == StateConnected) {
+		c.stateMu.Unlock()
+		return prev
+	}
+	c.state = state
+	c.stateMu.Unlock()
+
+	if c.onState != nil {
+		c.onState(state, err)
+	}
+	return prev
+}
+
+// dead closes both connections after the responder was lost.
+func (c *Client) dead(err error) {
+	if c.setState(StateDead, err) != StateConnected {
+		return
+	}
+	c.t.Close()
+}
+
+// startKeepalive starts probing the device of a PTP-IP Transport. Other
+// transports detect a lost device by their I/O errors.
+func (c *Client) startKeepalive() (err error) {
+	t, ok := c.t.(*ipTransport)
+	if !ok {
+		return nil
+	}
+
+	opts := *c.keepaliveOpts
+	conn, p, err := t.startKeepalive(opts.TCPKeepAlive)
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		go c.keepalive(conn, p, opts)
+	}
+	return nil
+}
+
+func (c *Client) keepalive(conn *aliveConn, p Prober, opts KeepaliveOptions) {
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		sent := time.Now()
+		if err := p.Probe(conn); err != nil {
+			c.dead(err)
+			return
+		}
+
+		timer := time.NewTimer(opts.Timeout)
+		select {
+		case <-c.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// any data read since the probe, the ProbeResponse or an event, proves the responder alive
+		if conn.lastRead().Before(sent) {
+			c.dead(ErrPeerDead)
+			return
+		}
+	}
+}
+
+func setTCPKeepAlive(conn net.Conn, period time.Duration) (err error) {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	if period < 0 {
+		return tc.SetKeepAlive(false)
+	}
+	if err = tc.SetKeepAlive(true); err != nil {
+		return err
+	}
+	if 0 < period {
+		return tc.SetKeepAlivePeriod(period)
+	}
+	return nil
+}
+
+// aliveConn records the time of the last read from the responder.
+type aliveConn struct {
+	net.Conn
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (c *aliveConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	if 0 < n {
+		c.mu.Lock()
+		c.last = time.Now()
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *aliveConn) lastRead() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.last
+}
@@ -0,0 +1,203 @@
+package ptpip
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/ptpiptest"
+)
+
+// localProtocol is PTP-IP on the port of a local listener.
+type localProtocol struct {
+	ptpipProtocol
+	port string
+}
+
+func (p localProtocol) CommandPort() string { return p.port }
+
+func (p localProtocol) EventPort() string { return p.port }
+
+// stateRecorder records the notifications of NotifyState.
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []ConnState
+	errs   []error
+	dead   chan struct{}
+}
+
+func (r *stateRecorder) notify(state ConnState, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, state)
+	r.errs = append(r.errs, err)
+	if state == StateDead {
+		close(r.dead)
+	}
+}
+
+func (r *stateRecorder) get() (states []ConnState, errs []error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ConnState(nil), r.states...), append([]error(nil), r.errs...)
+}
+
+func (r *stateRecorder) waitDead(t *testing.T) {
+	t.Helper()
+	select {
+	case <-r.dead:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not dead")
+	}
+}
+
+// connectLocal connects a Client to a responder on a local port. The
+// command connection completes the handshake, the event connection runs
+// the handshake followed by script.
+func connectLocal(t *testing.T, opts *KeepaliveOptions, script func(p *ptpiptest.Peer)) (c *Client, r *stateRecorder, ePeer *ptpiptest.Peer) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	peers := make(chan *ptpiptest.Peer, 2)
+	go func() {
+		defer close(peers)
+		for i := 0; i < 2; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			p := ptpiptest.NewPeerConn(conn)
+			if i == 0 {
+				p.ExpectType(packet.PacketTypeInitCommandRequest).Send(ptpiptest.Golden["InitCommandAck"])
+				// hold the command connection open
+				p.ExpectType(packet.PacketTypeOperationRequest)
+			} else {
+				p.ExpectType(packet.PacketTypeInitEventRequest).Send(ptpiptest.Golden["InitEventAck"])
+				script(p)
+			}
+			p.Start()
+			peers <- p
+		}
+	}()
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = NewClient("127.0.0.1", nil)
+	c.SetProtocol(localProtocol{port: ":" + port})
+	if opts != nil {
+		c.SetKeepalive(*opts)
+	}
+	r = &stateRecorder{dead: make(chan struct{})}
+	c.NotifyState(r.notify)
+	if err = c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	<-peers
+	return c, r, <-peers
+}
+
+func expectStates(t *testing.T, r *stateRecorder, expect []ConnState) {
+	t.Helper()
+	states, _ := r.get()
+	if len(states) != len(expect) {
+		t.Fatalf("got states %v expected %v", states, expect)
+	}
+	for i := range states {
+		if states[i] != expect[i] {
+			t.Fatalf("got states %v expected %v", states, expect)
+		}
+	}
+}
+
+func TestKeepaliveNoProbeResponse(t *testing.T) {
+	opts := &KeepaliveOptions{Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond}
+	c, r, peer := connectLocal(t, opts, func(p *ptpiptest.Peer) {
+		// the second probe is never sent, the peer waits until closed
+		p.ExpectType(packet.PacketTypeProbeRequest).ExpectType(packet.PacketTypeProbeRequest)
+	})
+
+	r.waitDead(t)
+	if _, errs := r.get(); errs[len(errs)-1] != ErrPeerDead {
+		t.Errorf("got cause %v expected ErrPeerDead", errs[len(errs)-1])
+	}
+	if state := c.State(); state != StateDead {
+		t.Errorf("got state %v expected dead", state)
+	}
+	// a transaction fails on the closed connection
+	if _, err := c.Transaction(uint16(packet.OperationCodeGetStorageIDs), packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil); err == nil {
+		t.Error("transaction of a dead client succeeded")
+	}
+
+	if err := c.Disconnect(); err != nil {
+		t.Errorf("Disconnect of a dead client: %v", err)
+	}
+	expectStates(t, r, []ConnState{StateConnected, StateDead, StateClosed})
+	peer.Wait()
+}
+
+func TestKeepaliveProbeResponse(t *testing.T) {
+	opts := &KeepaliveOptions{Interval: 10 * time.Millisecond, Timeout: 100 * time.Millisecond}
+	c, r, peer := connectLocal(t, opts, func(p *ptpiptest.Peer) {
+		for i := 0; i < 3; i++ {
+			p.ExpectType(packet.PacketTypeProbeRequest).Send(ptpiptest.Golden["ProbeResponse"])
+		}
+		// the script ends by closing the event connection
+	})
+
+	if err := peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	r.waitDead(t)
+	if _, errs := r.get(); errs[len(errs)-1] != io.EOF {
+		t.Errorf("got cause %v expected EOF of the closed event connection", errs[len(errs)-1])
+	}
+	c.Disconnect()
+	expectStates(t, r, []ConnState{StateConnected, StateDead, StateClosed})
+}
+
+func TestKeepaliveDisconnect(t *testing.T) {
+	opts := &KeepaliveOptions{Interval: 10 * time.Millisecond, Timeout: time.Hour}
+	c, r, peer := connectLocal(t, opts, func(p *ptpiptest.Peer) {
+		p.ExpectType(packet.PacketTypeProbeRequest).ExpectType(packet.PacketTypeProbeRequest)
+	})
+
+	// Disconnect while the probe is waiting for its timeout
+	time.Sleep(50 * time.Millisecond)
+	done := make(chan error, 1)
+	go func() { done <- c.Disconnect() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Disconnect blocked")
+	}
+	peer.Wait()
+
+	expectStates(t, r, []ConnState{StateConnected, StateClosed})
+	if state := c.State(); state != StateClosed {
+		t.Errorf("got state %v expected closed", state)
+	}
+}
+
+func TestEventConnectionLost(t *testing.T) {
+	c, r, peer := connectLocal(t, nil, func(p *ptpiptest.Peer) {})
+
+	peer.Wait()
+	r.waitDead(t)
+	if _, errs := r.get(); errs[len(errs)-1] != io.EOF {
+		t.Errorf("got cause %v expected EOF", errs[len(errs)-1])
+	}
+	c.Disconnect()
+	expectStates(t, r, []ConnState{StateConnected, StateDead, StateClosed})
+}
@@ -0,0 +1,217 @@
+// Package liveview streams live view frames from a camera.
+//
+// A Stream polls a Source at a target frame rate and delivers the frames on
+// a channel. Handler serves the same frames to browsers as an MJPEG stream.
+// The vendor packages provide the Source, e.g. canon.Camera, nikon.Camera,
+// sony.Camera and fuji.LiveView.
+package liveview
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFPS is the frame rate of a Stream created with a rate that is not positive.
+	DefaultFPS = 10
+
+	// maxConsecutiveErrors is the number of failed polls in a row after which a Stream stops
+	maxConsecutiveErrors = 10
+)
+
+// ErrStopped is returned by Stream.Err after Stop.
+var ErrStopped = errors.New("liveview: stopped")
+
+// Source extracts the current live view frame from the device as JPEG.
+type Source interface {
+	LiveViewImage() (jpeg []byte, err error)
+}
+
+// NoFrameError is returned by a Source that has no frame ready yet. A
+// Stream counts it as missed but does not stop on it, as on other errors.
+type NoFrameError string
+
+func (e NoFrameError) Error() string { return string(e) }
+
+// Temporary reports true, the next poll may return a frame.
+func (e NoFrameError) Temporary() bool { return true }
+
+// temporary is implemented by NoFrameError and by errors of other packages
+// reporting the same, e.g. net.Error.
+type temporary interface {
+	Temporary() bool
+}
+
+// Frame ...
+type Frame struct {
+	JPEG []byte
+	// Time is when the frame was received.
+	Time time.Time
+	// Seq numbers the frames received by the Stream, starting at 1.
+	Seq uint64
+}
+
+// Stats ...
+type Stats struct {
+	// Frames is the number of frames received from the Source.
+	Frames uint64
+	// Dropped is the number of frames replaced by a newer one before they were read from Frames.
+	Dropped uint64
+	// Missed is the number of polls that returned an error, e.g. no frame ready yet.
+	Missed uint64
+}
+
+// Stream polls a Source at a target frame rate.
+type Stream struct {
+	src      Source
+	interval time.Duration
+	frames   chan *Frame
+	stop     chan struct{}
+	done     chan struct{}
+
+	mu     sync.Mutex
+	stats  Stats
+	latest *Frame
+	notify chan struct{} // closed and replaced on every new frame
+	err    error
+}
+
+// NewStream returns a Stream polling src fps times per second, or DefaultFPS
+// times if fps is not positive. Call Start to begin.
+func NewStream(src Source, fps float64) *Stream {
+	if !(0 < fps) {
+		fps = DefaultFPS
+	}
+	// a rate above one poll per nanosecond polls as fast as possible
+	interval := time.Duration(float64(time.Second) / fps)
+	if interval <= 0 {
+		interval = 1
+	}
+
+	return &Stream{
+		src:      src,
+		interval: interval,
+		frames:   make(chan *Frame, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		notify:   make(chan struct{}),
+	}
+}
+
+// Start begins polling in a new goroutine.
+func (s *Stream) Start() {
+	go s.poll()
+}
+
+// Stop stops polling and closes Frames.
+func (s *Stream) Stop() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+}
+
+// Frames returns the channel the frames are delivered on. Only the newest
+// frame is kept, a frame not read before the next one arrives is dropped.
+// The channel is closed when the Stream stops.
+func (s *Stream) Frames() <-chan *Frame {
+	return s.frames
+}
+
+// Done is closed when the Stream stops.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that stopped the Stream.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Stats ...
+func (s *Stream) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Latest returns the newest frame, or nil before the first one, and a
+// channel that is closed when a newer frame arrives.
+func (s *Stream) Latest() (f *Frame, next <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest, s.notify
+}
+
+func (s *Stream) poll() {
+	defer close(s.done)
+	defer close(s.frames)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var errs int
+	for {
+		jpeg, err := s.src.LiveViewImage()
+		if err != nil {
+			// no frame ready yet is not a failure
+			if te, ok := err.(temporary); !ok || !te.Temporary() {
+				errs++
+			}
+			s.mu.Lock()
+			s.stats.Missed++
+			if maxConsecutiveErrors <= errs {
+				s.err = err
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Unlock()
+		} else {
+			errs = 0
+			s.publish(jpeg)
+		}
+
+		select {
+		case <-s.stop:
+			s.mu.Lock()
+			s.err = ErrStopped
+			s.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Stream) publish(jpeg []byte) {
+	s.mu.Lock()
+	s.stats.Frames++
+	f := &Frame{JPEG: jpeg, Time: time.Now(), Seq: s.stats.Frames}
+	s.latest = f
+	close(s.notify)
+	s.notify = make(chan struct{})
+	s.mu.Unlock()
+
+	select {
+	case s.frames <- f:
+		return
+	default:
+	}
+
+	// replace the unread frame
+	select {
+	case <-s.frames:
+		s.mu.Lock()
+		s.stats.Dropped++
+		s.mu.Unlock()
+	default:
+	}
+	select {
+	case s.frames <- f:
+	default:
+	}
+}
@@ -0,0 +1,153 @@
+package liveview_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip/liveview"
+)
+
+// fakeSource returns the results in order, then repeats the last one.
+type fakeSource struct {
+	mu      sync.Mutex
+	results []result
+	polls   int
+}
+
+type result struct {
+	jpeg []byte
+	err  error
+}
+
+func (s *fakeSource) LiveViewImage() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.results[len(s.results)-1]
+	if s.polls < len(s.results) {
+		r = s.results[s.polls]
+	}
+	s.polls++
+	return r.jpeg, r.err
+}
+
+var (
+	errNoFrame = liveview.NoFrameError("fake: no frame")
+	errDevice  = errors.New("fake: device error")
+	jpeg       = []byte{0xFF, 0xD8, 0xFF, 0xD9}
+)
+
+func repeat(r result, n int) []result {
+	rs := make([]result, n)
+	for i := range rs {
+		rs[i] = r
+	}
+	return rs
+}
+
+func waitDone(t *testing.T, s *liveview.Stream) {
+	t.Helper()
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop")
+	}
+}
+
+func TestNoFrameDoesNotStop(t *testing.T) {
+	src := &fakeSource{results: append(repeat(result{err: errNoFrame}, 30), result{jpeg: jpeg})}
+	s := liveview.NewStream(src, 1000)
+	s.Start()
+	defer s.Stop()
+
+	select {
+	case f := <-s.Frames():
+		if f.Seq != 1 {
+			t.Errorf("got Seq %d expected 1", f.Seq)
+		}
+	case <-s.Done():
+		t.Fatalf("stopped on %v", s.Err())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no frame")
+	}
+	if stats := s.Stats(); stats.Missed != 30 {
+		t.Errorf("got Missed %d expected 30", stats.Missed)
+	}
+}
+
+func TestErrorsStop(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []result
+		missed  uint64
+	}{
+		{"errors", repeat(result{err: errDevice}, 1), 10},
+		{"frame resets the count", append(append(repeat(result{err: errDevice}, 9), result{jpeg: jpeg}), result{err: errDevice}), 19},
+		{"no frame between errors", append(append(repeat(result{err: errDevice}, 5), repeat(result{err: errNoFrame}, 5)...), result{err: errDevice}), 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := liveview.NewStream(&fakeSource{results: tt.results}, 1000)
+			s.Start()
+			waitDone(t, s)
+
+			if err := s.Err(); err != errDevice {
+				t.Errorf("got %v expected %v", err, errDevice)
+			}
+			if stats := s.Stats(); stats.Missed != tt.missed {
+				t.Errorf("got Missed %d expected %d", stats.Missed, tt.missed)
+			}
+			for range s.Frames() {
+			}
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	s := liveview.NewStream(&fakeSource{results: []result{{jpeg: jpeg}}}, 1000)
+	s.Start()
+
+	f, next := s.Latest()
+	select {
+	case <-next:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no frame")
+	}
+	if f, _ = s.Latest(); f == nil || string(f.JPEG) != string(jpeg) {
+		t.Errorf("got %v expected a frame", f)
+	}
+
+	s.Stop()
+	if err := s.Err(); err != liveview.ErrStopped {
+		t.Errorf("got %v expected ErrStopped", err)
+	}
+	for range s.Frames() {
+	}
+	// a second Stop returns
+	s.Stop()
+}
+
+func TestDropped(t *testing.T) {
+	s := liveview.NewStream(&fakeSource{results: []result{{jpeg: jpeg}}}, 1000)
+	s.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats().Frames < 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("no frames")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.Stop()
+
+	stats := s.Stats()
+	var read uint64
+	for range s.Frames() {
+		read++
+	}
+	if stats.Dropped+read != stats.Frames {
+		t.Errorf("dropped %d read %d of %d frames", stats.Dropped, read, stats.Frames)
+	}
+}
@@ -0,0 +1,61 @@
+package liveview
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	mjpegBoundary = "ptpipframe"
+)
+
+// Handler serves the frames of a Stream as a multipart MJPEG stream, which
+// browsers display in an <img> element. Any number of clients can be served
+// at once; each receives the newest frame and skips the ones it was too slow for.
+type Handler struct {
+	s *Stream
+}
+
+// NewHandler ...
+func NewHandler(s *Stream) *Handler {
+	return &Handler{s: s}
+}
+
+// ServeHTTP ...
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mjpegBoundary)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	var seq uint64
+	for {
+		f, next := h.s.Latest()
+		if f != nil && f.Seq != seq {
+			seq = f.Seq
+			if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", mjpegBoundary, len(f.JPEG)); err != nil {
+				return
+			}
+			if _, err := w.Write(f.JPEG); err != nil {
+				return
+			}
+			if _, err := w.Write([]byte("\r\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+
+		select {
+		case <-next:
+		case <-h.s.Done():
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package mtp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Form Flag of ObjectPropDesc, in addition to packet.FormFlagNone,
+// packet.FormFlagRange and packet.FormFlagEnumeration.
+const (
+	FormFlagDateTime          uint8 = 0x03
+	FormFlagFixedLengthArray  uint8 = 0x04
+	FormFlagRegularExpression uint8 = 0x05
+	FormFlagByteArray         uint8 = 0x06
+	FormFlagLongString        uint8 = 0xFF
+)
+
+// ObjectPropDesc ...
+type ObjectPropDesc struct {
+	PropCode       uint16
+	DataType       uint16
+	GetSet         uint8
+	FactoryDefault interface{}
+	GroupCode      uint32
+	FormFlag       uint8
+
+	// FormFlagRange
+	Min  interface{}
+	Max  interface{}
+	Step interface{}
+
+	// FormFlagEnumeration
+	Values []interface{}
+
+	// FormFlagFixedLengthArray, FormFlagByteArray and FormFlagLongString
+	MaxLength uint32
+
+	// FormFlagRegularExpression
+	RegEx string
+}
+
+// ParseObjectPropDesc decodes the ObjectPropDesc dataset returned by
+// GetObjectPropDesc. Values are decoded with packet.DecodeValue.
+func ParseObjectPropDesc(data []byte) (d *ObjectPropDesc, err error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("mtp: invalid ObjectPropDesc len %d", len(data))
+	}
+
+	d = &ObjectPropDesc{
+		PropCode: binary.LittleEndian.Uint16(data[0:]),
+		DataType: binary.LittleEndian.Uint16(data[2:]),
+		GetSet:   data[4],
+	}
+	off := 5
+
+	value := func(dataType uint16) (v interface{}) {
+		if err != nil {
+			return nil
+		}
+		v, n, e := packet.DecodeValue(data[off:], dataType)
+		err = e
+		off += n
+		return v
+	}
+	u16 := func() uint16 {
+		if err == nil && len(data) < off+2 {
+			err = fmt.Errorf("short data at %d", off)
+		}
+		if err != nil {
+			return 0
+		}
+		off += 2
+		return binary.LittleEndian.Uint16(data[off-2:])
+	}
+	u32 := func() uint32 {
+		if err == nil && len(data) < off+4 {
+			err = fmt.Errorf("short data at %d", off)
+		}
+		if err != nil {
+			return 0
+		}
+		off += 4
+		return binary.LittleEndian.Uint32(data[off-4:])
+	}
+
+	d.FactoryDefault = value(d.DataType)
+	d.GroupCode = u32()
+	if err == nil && off < len(data) {
+		d.FormFlag = data[off]
+		off++
+	}
+
+	switch d.FormFlag {
+	case packet.FormFlagRange:
+		d.Min = value(d.DataType)
+		d.Max = value(d.DataType)
+		d.Step = value(d.DataType)
+	case packet.FormFlagEnumeration:
+		n := int(u16())
+		for i := 0; i < n && err == nil; i++ {
+			d.Values = append(d.Values, value(d.DataType))
+		}
+	case FormFlagFixedLengthArray:
+		d.MaxLength = uint32(u16())
+	case FormFlagRegularExpression:
+		if s, ok := value(packet.DataTypeString).(string); ok {
+			d.RegEx = s
+		}
+	case FormFlagByteArray, FormFlagLongString:
+		d.MaxLength = u32()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mtp: invalid ObjectPropDesc: %v", err)
+	}
+
+	return d, nil
+}
+
+// ObjectProp is an element of an ObjectPropList.
+type ObjectProp struct {
+	ObjectHandle uint32
+	PropCode     uint16
+	DataType     uint16
+	Value        interface{}
+}
+
+// ObjectPropList is the dataset of GetObjectPropList and SetObjectPropList.
+type ObjectPropList []*ObjectProp
+
+// objectPropHeaderSize is the size of ObjectHandle(4) PropCode(2) DataType(2).
+const objectPropHeaderSize = 8
+
+// ParseObjectPropList decodes the ObjectPropList dataset returned by
+// GetObjectPropList: Count(4) followed by Count elements of ObjectHandle(4)
+// PropCode(2) DataType(2) Value. Values are decoded with packet.DecodeValue.
+func ParseObjectPropList(data []byte) (list ObjectPropList, err error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("mtp: invalid ObjectPropList len %d", len(data))
+	}
+
+	// every element takes at least its header and one byte of value, so a
+	// corrupt count cannot force a huge allocation
+	n := binary.LittleEndian.Uint32(data)
+	if uint32((len(data)-4)/(objectPropHeaderSize+1)) < n {
+		return nil, fmt.Errorf("mtp: invalid ObjectPropList count %d", n)
+	}
+
+	list = make(ObjectPropList, 0, n)
+	off := 4
+	for i := 0; i < int(n); i++ {
+		if len(data) < off+objectPropHeaderSize {
+			return nil, fmt.Errorf("mtp: invalid ObjectPropList element %d len %d", i, len(data)-off)
+		}
+		p := &ObjectProp{
+			ObjectHandle: binary.LittleEndian.Uint32(data[off:]),
+			PropCode:     binary.LittleEndian.Uint16(data[off+4:]),
+			DataType:     binary.LittleEndian.Uint16(data[off+6:]),
+		}
+		off += objectPropHeaderSize
+
+		v, size, err := packet.DecodeValue(data[off:], p.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("mtp: invalid ObjectPropList element %d: %v", i, err)
+		}
+		p.Value = v
+		off += size
+
+		list = append(list, p)
+	}
+
+	return list, nil
+}
+
+// MarshalObjectPropList encodes the ObjectPropList dataset sent with
+// SetObjectPropList. Values are encoded with packet.EncodeValue.
+func MarshalObjectPropList(list ObjectPropList) (data []byte, err error) {
+	data = make([]byte, 4, 4+len(list)*(objectPropHeaderSize+4))
+	binary.LittleEndian.PutUint32(data, uint32(len(list)))
+
+	for i, p := range list {
+		v, err := packet.EncodeValue(p.Value, p.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("mtp: ObjectPropList element %d: %v", i, err)
+		}
+
+		var h [objectPropHeaderSize]byte
+		binary.LittleEndian.PutUint32(h[0:], p.ObjectHandle)
+		binary.LittleEndian.PutUint16(h[4:], p.PropCode)
+		binary.LittleEndian.PutUint16(h[6:], p.DataType)
+		data = append(data, h[:]...)
+		data = append(data, v...)
+	}
+
+	return data, nil
+}
+
+// ByHandle groups the property values of list by object handle and property code.
+func (list ObjectPropList) ByHandle() map[uint32]map[uint16]interface{} {
+	objects := make(map[uint32]map[uint16]interface{})
+	for _, p := range list {
+		props, ok := objects[p.ObjectHandle]
+		if !ok {
+			props = make(map[uint16]interface{})
+			objects[p.ObjectHandle] = props
+		}
+		props[p.PropCode] = p.Value
+	}
+	return objects
+}
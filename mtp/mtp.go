@@ -0,0 +1,242 @@
+// Package mtp implements the object property operations of the MTP extension
+// to PTP on top of ptpip.Client.
+//
+// GetObjectPropList reads properties of many objects in one transaction,
+// e.g. the names and parents of all objects of a storage:
+//
+//	d := mtp.New(c)
+//	list, err := d.GetObjectPropList(mtp.ObjectHandleAll, 0, mtp.ObjectPropCodeAll, 0, 0)
+//	for handle, props := range list.ByHandle() {
+//		fmt.Println(handle, props[mtp.ObjectPropCodeObjectFileName])
+//	}
+package mtp
+
+import (
+	"errors"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// VendorExtensionID is the VendorExtensionID reported by MTP devices in DeviceInfo.
+const VendorExtensionID uint32 = 0x00000006
+
+// Operation Code
+const (
+	OperationCodeGetObjectPropsSupported uint16 = 0x9801
+	OperationCodeGetObjectPropDesc       uint16 = 0x9802
+	OperationCodeGetObjectPropValue      uint16 = 0x9803
+	OperationCodeSetObjectPropValue      uint16 = 0x9804
+	OperationCodeGetObjectPropList       uint16 = 0x9805
+	OperationCodeSetObjectPropList       uint16 = 0x9806
+)
+
+// Response Code
+const (
+	ResponseCodeInvalidObjectPropCode           uint16 = 0xA801
+	ResponseCodeInvalidObjectPropFormat         uint16 = 0xA802
+	ResponseCodeInvalidObjectPropValue          uint16 = 0xA803
+	ResponseCodeInvalidObjectReference          uint16 = 0xA804
+	ResponseCodeGroupNotSupported               uint16 = 0xA805
+	ResponseCodeInvalidDataset                  uint16 = 0xA806
+	ResponseCodeSpecificationByGroupUnsupported uint16 = 0xA807
+	ResponseCodeSpecificationByDepthUnsupported uint16 = 0xA808
+	ResponseCodeObjectTooLarge                  uint16 = 0xA809
+	ResponseCodeObjectPropNotSupported          uint16 = 0xA80A
+)
+
+// Object Property Code
+const (
+	ObjectPropCodeStorageID                        uint16 = 0xDC01
+	ObjectPropCodeObjectFormat                     uint16 = 0xDC02
+	ObjectPropCodeProtectionStatus                 uint16 = 0xDC03
+	ObjectPropCodeObjectSize                       uint16 = 0xDC04
+	ObjectPropCodeAssociationType                  uint16 = 0xDC05
+	ObjectPropCodeAssociationDesc                  uint16 = 0xDC06
+	ObjectPropCodeObjectFileName                   uint16 = 0xDC07
+	ObjectPropCodeDateCreated                      uint16 = 0xDC08
+	ObjectPropCodeDateModified                     uint16 = 0xDC09
+	ObjectPropCodeKeywords                         uint16 = 0xDC0A
+	ObjectPropCodeParentObject                     uint16 = 0xDC0B
+	ObjectPropCodeAllowedFolderContents            uint16 = 0xDC0C
+	ObjectPropCodeHidden                           uint16 = 0xDC0D
+	ObjectPropCodeSystemObject                     uint16 = 0xDC0E
+	ObjectPropCodePersistentUniqueObjectIdentifier uint16 = 0xDC41
+	ObjectPropCodeSyncID                           uint16 = 0xDC42
+	ObjectPropCodePropertyBag                      uint16 = 0xDC43
+	ObjectPropCodeName                             uint16 = 0xDC44
+	ObjectPropCodeArtist                           uint16 = 0xDC46
+	ObjectPropCodeDateAuthored                     uint16 = 0xDC47
+	ObjectPropCodeDescription                      uint16 = 0xDC48
+	ObjectPropCodeDateAdded                        uint16 = 0xDC4E
+	ObjectPropCodeNonConsumable                    uint16 = 0xDC4F
+	ObjectPropCodeWidth                            uint16 = 0xDC87
+	ObjectPropCodeHeight                           uint16 = 0xDC88
+	ObjectPropCodeDuration                         uint16 = 0xDC89
+)
+
+// Parameters of GetObjectPropList
+const (
+	// ObjectHandleAll selects all objects, ObjectPropCodeAll all properties.
+	ObjectHandleAll   uint32 = 0xFFFFFFFF
+	ObjectPropCodeAll uint32 = 0xFFFFFFFF
+	// DepthAll selects the object and all objects below it.
+	DepthAll uint32 = 0xFFFFFFFF
+)
+
+// Errors returned for the MTP response codes.
+var (
+	ErrInvalidObjectPropCode           = errors.New("mtp: invalid object prop code")
+	ErrInvalidObjectPropFormat         = errors.New("mtp: invalid object prop format")
+	ErrInvalidObjectPropValue          = errors.New("mtp: invalid object prop value")
+	ErrInvalidObjectReference          = errors.New("mtp: invalid object reference")
+	ErrGroupNotSupported               = errors.New("mtp: group not supported")
+	ErrInvalidDataset                  = errors.New("mtp: invalid dataset")
+	ErrSpecificationByGroupUnsupported = errors.New("mtp: specification by group unsupported")
+	ErrSpecificationByDepthUnsupported = errors.New("mtp: specification by depth unsupported")
+	ErrObjectTooLarge                  = errors.New("mtp: object too large")
+	ErrObjectPropNotSupported          = errors.New("mtp: object prop not supported")
+)
+
+var responseErrors = map[uint16]error{
+	ResponseCodeInvalidObjectPropCode:           ErrInvalidObjectPropCode,
+	ResponseCodeInvalidObjectPropFormat:         ErrInvalidObjectPropFormat,
+	ResponseCodeInvalidObjectPropValue:          ErrInvalidObjectPropValue,
+	ResponseCodeInvalidObjectReference:          ErrInvalidObjectReference,
+	ResponseCodeGroupNotSupported:               ErrGroupNotSupported,
+	ResponseCodeInvalidDataset:                  ErrInvalidDataset,
+	ResponseCodeSpecificationByGroupUnsupported: ErrSpecificationByGroupUnsupported,
+	ResponseCodeSpecificationByDepthUnsupported: ErrSpecificationByDepthUnsupported,
+	ResponseCodeObjectTooLarge:                  ErrObjectTooLarge,
+	ResponseCodeObjectPropNotSupported:          ErrObjectPropNotSupported,
+}
+
+// mapError converts an MTP response code into its error.
+func mapError(err error) error {
+	if re, ok := err.(*packet.ResponseError); ok {
+		if e, ok := responseErrors[re.Code]; ok {
+			return e
+		}
+	}
+	return err
+}
+
+// Vendor is the MTP extension registered with ptpip.
+var Vendor = &ptpip.Vendor{
+	Name:              "mtp",
+	VendorExtensionID: VendorExtensionID,
+	Operations: map[uint16]string{
+		OperationCodeGetObjectPropsSupported: "GetObjectPropsSupported",
+		OperationCodeGetObjectPropDesc:       "GetObjectPropDesc",
+		OperationCodeGetObjectPropValue:      "GetObjectPropValue",
+		OperationCodeSetObjectPropValue:      "SetObjectPropValue",
+		OperationCodeGetObjectPropList:       "GetObjectPropList",
+		OperationCodeSetObjectPropList:       "SetObjectPropList",
+	},
+	Responses: map[uint16]string{
+		ResponseCodeInvalidObjectPropCode:           "InvalidObjectPropCode",
+		ResponseCodeInvalidObjectPropFormat:         "InvalidObjectPropFormat",
+		ResponseCodeInvalidObjectPropValue:          "InvalidObjectPropValue",
+		ResponseCodeInvalidObjectReference:          "InvalidObjectReference",
+		ResponseCodeGroupNotSupported:               "GroupNotSupported",
+		ResponseCodeInvalidDataset:                  "InvalidDataset",
+		ResponseCodeSpecificationByGroupUnsupported: "SpecificationByGroupUnsupported",
+		ResponseCodeSpecificationByDepthUnsupported: "SpecificationByDepthUnsupported",
+		ResponseCodeObjectTooLarge:                  "ObjectTooLarge",
+		ResponseCodeObjectPropNotSupported:          "ObjectPropNotSupported",
+	},
+	Decoders: map[uint16]ptpip.DatasetDecoder{
+		OperationCodeGetObjectPropDesc: func(data []byte) (interface{}, error) {
+			return ParseObjectPropDesc(data)
+		},
+		OperationCodeGetObjectPropList: func(data []byte) (interface{}, error) {
+			return ParseObjectPropList(data)
+		},
+	},
+}
+
+func init() {
+	ptpip.RegisterVendor(Vendor)
+}
+
+// Device wraps a ptpip.Client connected to an MTP device.
+type Device struct {
+	c *ptpip.Client
+}
+
+// New ...
+func New(c *ptpip.Client) *Device {
+	return &Device{c: c}
+}
+
+// Client returns the underlying ptpip.Client.
+func (d *Device) Client() *ptpip.Client {
+	return d.c
+}
+
+// GetObjectPropsSupported returns the object property codes supported for
+// objects of an object format.
+func (d *Device) GetObjectPropsSupported(objectFormat uint16) (propCodes []uint16, err error) {
+	data, err := d.c.Transaction(OperationCodeGetObjectPropsSupported, packet.DataPhaseInfoNoDataOrDataIn, uint32(objectFormat), 0, 0, 0, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	v, _, err := packet.DecodeValue(data, packet.DataTypeUInt16|packet.DataTypeArray)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]uint16), nil
+}
+
+// GetObjectPropDesc ...
+func (d *Device) GetObjectPropDesc(propCode uint16, objectFormat uint16) (desc *ObjectPropDesc, err error) {
+	data, err := d.c.Transaction(OperationCodeGetObjectPropDesc, packet.DataPhaseInfoNoDataOrDataIn, uint32(propCode), uint32(objectFormat), 0, 0, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return ParseObjectPropDesc(data)
+}
+
+// GetObjectPropValue returns the encoded value of an object property.
+// Decode it with packet.DecodeValue and the property's data type.
+func (d *Device) GetObjectPropValue(objectHandle uint32, propCode uint16) (value []byte, err error) {
+	value, err = d.c.Transaction(OperationCodeGetObjectPropValue, packet.DataPhaseInfoNoDataOrDataIn, objectHandle, uint32(propCode), 0, 0, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return value, nil
+}
+
+// SetObjectPropValue sets an object property to an encoded value.
+// Encode it with packet.EncodeValue and the property's data type.
+func (d *Device) SetObjectPropValue(objectHandle uint32, propCode uint16, value []byte) (err error) {
+	_, err = d.c.Transaction(OperationCodeSetObjectPropValue, packet.DataPhaseInfoDataOut, objectHandle, uint32(propCode), 0, 0, value)
+	return mapError(err)
+}
+
+// GetObjectPropList returns object properties of many objects in one
+// transaction. objectHandle and depth select the objects: depth 0 is the
+// object itself, DepthAll the object and all objects below it, and
+// ObjectHandleAll with depth 0 all objects of the device. objectFormat 0
+// selects objects of any format. propCode is a property code,
+// ObjectPropCodeAll, or 0 to select the properties of propGroup.
+func (d *Device) GetObjectPropList(objectHandle uint32, objectFormat uint16, propCode uint32, propGroup uint32, depth uint32) (list ObjectPropList, err error) {
+	params := []uint32{objectHandle, uint32(objectFormat), propCode, propGroup, depth}
+	data, _, err := d.c.TransactionWithParams(OperationCodeGetObjectPropList, packet.DataPhaseInfoNoDataOrDataIn, params, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return ParseObjectPropList(data)
+}
+
+// SetObjectPropList sets the properties in list. The device stops at the
+// first property it rejects, the properties before it are set.
+func (d *Device) SetObjectPropList(list ObjectPropList) (err error) {
+	data, err := MarshalObjectPropList(list)
+	if err != nil {
+		return err
+	}
+	_, err = d.c.Transaction(OperationCodeSetObjectPropList, packet.DataPhaseInfoDataOut, 0, 0, 0, 0, data)
+	return mapError(err)
+}
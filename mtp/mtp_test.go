@@ -0,0 +1,202 @@
+package mtp_test
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/mtp"
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/ptpiptest"
+)
+
+// connTransport runs transactions on the command connection of a
+// ptpiptest.Peer. It has no event connection.
+type connTransport struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (t *connTransport) Connect() error { return nil }
+
+func (t *connTransport) Close() error {
+	t.once.Do(func() {
+		t.conn.Close()
+		close(t.closed)
+	})
+	return nil
+}
+
+func (t *connTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	return packet.OperationRequestWithResponse(t.conn, req, sendData)
+}
+
+func (t *connTransport) RecvEvent() (*packet.EventPacket, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *connTransport) Cancel(transactionID uint32) error { return nil }
+
+func newDevice(t *testing.T) (d *mtp.Device, peer *ptpiptest.Peer) {
+	peer, conn := ptpiptest.NewPeer()
+	c := ptpip.NewClientTransport(&connTransport{conn: conn, closed: make(chan struct{})})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return mtp.New(c), peer
+}
+
+func TestObjectPropList(t *testing.T) {
+	list := mtp.ObjectPropList{
+		{ObjectHandle: 1, PropCode: mtp.ObjectPropCodeObjectFileName, DataType: packet.DataTypeString, Value: "A"},
+		{ObjectHandle: 1, PropCode: mtp.ObjectPropCodeParentObject, DataType: packet.DataTypeUInt32, Value: uint32(0)},
+		{ObjectHandle: 2, PropCode: mtp.ObjectPropCodeObjectSize, DataType: packet.DataTypeUInt64, Value: uint64(123456)},
+	}
+	golden := []byte{
+		0x03, 0x00, 0x00, 0x00, // Count
+		0x01, 0x00, 0x00, 0x00, 0x07, 0xDC, 0xFF, 0xFF, // 1 ObjectFileName STR
+		0x02, 0x41, 0x00, 0x00, 0x00, // "A"
+		0x01, 0x00, 0x00, 0x00, 0x0B, 0xDC, 0x06, 0x00, // 1 ParentObject UINT32
+		0x00, 0x00, 0x00, 0x00,
+		0x02, 0x00, 0x00, 0x00, 0x04, 0xDC, 0x08, 0x00, // 2 ObjectSize UINT64
+		0x40, 0xE2, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	data, err := mtp.MarshalObjectPropList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, golden) {
+		t.Fatalf("got\n% x\nexpected\n% x", data, golden)
+	}
+
+	got, err := mtp.ParseObjectPropList(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, list) {
+		t.Errorf("got %v expected %v", got, list)
+	}
+
+	objects := got.ByHandle()
+	if len(objects) != 2 || objects[1][mtp.ObjectPropCodeObjectFileName] != "A" || objects[2][mtp.ObjectPropCodeObjectSize] != uint64(123456) {
+		t.Errorf("ByHandle got %v", objects)
+	}
+
+	// a count larger than the data
+	if _, err := mtp.ParseObjectPropList([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x01}); err == nil {
+		t.Error("expected error for invalid count")
+	}
+	if _, err := mtp.ParseObjectPropList(golden[:len(golden)-1]); err == nil {
+		t.Error("expected error for short value")
+	}
+}
+
+func TestParseObjectPropDesc(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		expect *mtp.ObjectPropDesc
+	}{
+		{
+			"regular expression",
+			[]byte{
+				0x07, 0xDC, 0xFF, 0xFF, 0x01, // ObjectFileName STR get/set
+				0x00,                   // FactoryDefault ""
+				0x00, 0x00, 0x00, 0x00, // GroupCode
+				0x05,                                     // RegularExpression
+				0x03, 0x2E, 0x00, 0x2A, 0x00, 0x00, 0x00, // ".*"
+			},
+			&mtp.ObjectPropDesc{PropCode: 0xDC07, DataType: packet.DataTypeString, GetSet: 1, FactoryDefault: "", FormFlag: mtp.FormFlagRegularExpression, RegEx: ".*"},
+		},
+		{
+			"range",
+			[]byte{
+				0x87, 0xDC, 0x06, 0x00, 0x00, // Width UINT32 get
+				0x00, 0x00, 0x00, 0x00, // FactoryDefault
+				0x01, 0x00, 0x00, 0x00, // GroupCode
+				0x01,                   // Range
+				0x01, 0x00, 0x00, 0x00, // Min
+				0x00, 0x10, 0x00, 0x00, // Max
+				0x01, 0x00, 0x00, 0x00, // Step
+			},
+			&mtp.ObjectPropDesc{PropCode: 0xDC87, DataType: packet.DataTypeUInt32, FactoryDefault: uint32(0), GroupCode: 1, FormFlag: packet.FormFlagRange, Min: uint32(1), Max: uint32(0x1000), Step: uint32(1)},
+		},
+		{
+			"long string",
+			[]byte{
+				0x48, 0xDC, 0x04, 0x40, 0x01, // Description AUINT16 get/set
+				0x00, 0x00, 0x00, 0x00, // FactoryDefault empty array
+				0x00, 0x00, 0x00, 0x00, // GroupCode
+				0xFF,                   // LongString
+				0x00, 0x10, 0x00, 0x00, // MaxLength
+			},
+			&mtp.ObjectPropDesc{PropCode: 0xDC48, DataType: packet.DataTypeUInt16 | packet.DataTypeArray, GetSet: 1, FactoryDefault: []uint16{}, FormFlag: mtp.FormFlagLongString, MaxLength: 0x1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mtp.ParseObjectPropDesc(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("got %+v expected %+v", got, tt.expect)
+			}
+			if _, err := mtp.ParseObjectPropDesc(tt.data[:len(tt.data)-1]); err == nil {
+				t.Error("expected error for short data")
+			}
+		})
+	}
+}
+
+func TestGetObjectPropList(t *testing.T) {
+	list := mtp.ObjectPropList{
+		{ObjectHandle: 0x10, PropCode: mtp.ObjectPropCodeObjectFileName, DataType: packet.DataTypeString, Value: "DSC00001.JPG"},
+	}
+	data, err := mtp.MarshalObjectPropList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, peer := newDevice(t)
+	// Depth is sent as the fifth parameter
+	peer.Expect(ptpiptest.OperationRequest(packet.DataPhaseInfoNoDataOrDataIn, mtp.OperationCodeGetObjectPropList, 1, 0x10, 0, mtp.ObjectPropCodeAll, 0, 1)).
+		Send(ptpiptest.DataIn(1, data)...).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeOK, 1))
+	peer.Start()
+
+	got, err := d.GetObjectPropList(0x10, 0, mtp.ObjectPropCodeAll, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, list) {
+		t.Errorf("got %v expected %v", got, list)
+	}
+	d.Client().Disconnect()
+}
+
+func TestGetObjectPropValueError(t *testing.T) {
+	d, peer := newDevice(t)
+	peer.Expect(ptpiptest.OperationRequest(packet.DataPhaseInfoNoDataOrDataIn, mtp.OperationCodeGetObjectPropValue, 1, 0x10, uint32(mtp.ObjectPropCodeArtist))).
+		Send(ptpiptest.OperationResponse(mtp.ResponseCodeObjectPropNotSupported, 1))
+	peer.Start()
+
+	if _, err := d.GetObjectPropValue(0x10, mtp.ObjectPropCodeArtist); err != mtp.ErrObjectPropNotSupported {
+		t.Errorf("got %v expected ErrObjectPropNotSupported", err)
+	}
+	if err := peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	d.Client().Disconnect()
+}
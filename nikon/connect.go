@@ -0,0 +1,50 @@
+package nikon
+
+import (
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	connectRetries       = 5
+	connectRetryInterval = time.Second
+	openReadyTimeout     = 10 * time.Second
+)
+
+// Connect establishes the PTP-IP connection, opens a session and waits until
+// the camera is ready. It covers the quirks of Nikon's wireless connection:
+//
+//   - the camera answers InitFail busy while it still holds a previous
+//     connection, so the handshake is retried;
+//   - the camera pairs with the initiator GUID, so the ptpip.Initiator must
+//     keep the same GUID across connections;
+//   - a session survives a dropped Wi-Fi connection, so
+//     ResponseCodeSessionAlreadyOpen is resolved by closing and reopening it;
+//   - operations are refused until DeviceReady succeeds.
+func (cam *Camera) Connect(sessionID uint32) (err error) {
+	for i := 0; ; i++ {
+		err = cam.c.Connect()
+		if fe, ok := err.(*packet.InitFailError); ok && fe.Reason == packet.InitFailReasonBusy && i < connectRetries {
+			time.Sleep(connectRetryInterval)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		break
+	}
+
+	err = cam.c.OpenSession(sessionID)
+	if re, ok := err.(*packet.ResponseError); ok && re.Code == packet.ResponseCodeSessionAlreadyOpen {
+		if err = cam.c.CloseSession(); err != nil {
+			return err
+		}
+		err = cam.c.OpenSession(sessionID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return cam.WaitReady(openReadyTimeout)
+}
@@ -0,0 +1,64 @@
+package nikon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// ParseEvents decodes the event list returned by GetEvent:
+// Count(2) followed by Count entries of EventCode(2) Parameter(4).
+func ParseEvents(data []byte) (events []*packet.EventPacket, err error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("nikon: invalid event list len %d", len(data))
+	}
+
+	n := int(binary.LittleEndian.Uint16(data))
+	if (len(data)-2)/6 < n {
+		return nil, fmt.Errorf("nikon: invalid event count %d", n)
+	}
+
+	for i := 0; i < n; i++ {
+		off := 2 + i*6
+		events = append(events, &packet.EventPacket{
+			EventCode: binary.LittleEndian.Uint16(data[off:]),
+			P1:        binary.LittleEndian.Uint32(data[off+2:]),
+		})
+	}
+
+	return events, nil
+}
+
+// GetEvent polls the camera for pending events.
+func (cam *Camera) GetEvent() (events []*packet.EventPacket, err error) {
+	data, err := cam.transaction(OperationCodeGetEvent, 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEvents(data)
+}
+
+// PollEvents calls GetEvent every interval and passes each event to fn
+// until stop is closed or GetEvent fails.
+func (cam *Camera) PollEvents(stop <-chan struct{}, interval time.Duration, fn func(*packet.EventPacket)) (err error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := cam.GetEvent()
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			fn(e)
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
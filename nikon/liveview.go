@@ -0,0 +1,137 @@
+package nikon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/takurooo/ptpip/liveview"
+)
+
+const (
+	// liveViewHeaderMinSize is the size of the fields decoded into LiveViewHeader
+	liveViewHeaderMinSize = 0x18
+
+	liveViewReadyTimeout = 5 * time.Second
+)
+
+// liveViewHeaderSizes are the header sizes of the models: 384 bytes for the
+// D4, D800 and later models including the Z series, 128 for the D90 and
+// D5000 generation and 64 for the D3, D300 and D700.
+var liveViewHeaderSizes = []int{0x180, 0x80, 0x40}
+
+// ErrNoLiveViewImage is returned when GetLiveViewImg data holds no JPEG.
+var ErrNoLiveViewImage error = liveview.NoFrameError("nikon: no live view image")
+
+// LiveViewHeader is the start of the header preceding the JPEG in the
+// GetLiveViewImg data. Unlike the rest of PTP it is big endian. The header
+// size differs between models, only the fields common to all are decoded.
+type LiveViewHeader struct {
+	JPEGSize      uint32
+	Width         uint16
+	Height        uint16
+	DisplayWidth  uint16
+	DisplayHeight uint16
+	DisplayX      uint16
+	DisplayY      uint16
+	AFWidth       uint16
+	AFHeight      uint16
+	AFX           uint16
+	AFY           uint16
+}
+
+// LiveViewImage ...
+type LiveViewImage struct {
+	Header LiveViewHeader
+	// RawHeader holds the whole model specific header.
+	RawHeader []byte
+	JPEG      []byte
+}
+
+// ParseLiveViewImage splits the GetLiveViewImg data into header and JPEG.
+// The JPEG starts after the header of one of the known sizes, or else is
+// taken as the last JPEGSize bytes of the data. The JPEG is not searched
+// for, as its markers may occur in the binary header fields.
+func ParseLiveViewImage(data []byte) (img *LiveViewImage, err error) {
+	if len(data) < liveViewHeaderMinSize {
+		return nil, fmt.Errorf("nikon: invalid live view data len %d", len(data))
+	}
+	size := int(binary.BigEndian.Uint32(data))
+
+	soi := -1
+	for _, n := range liveViewHeaderSizes {
+		if n < len(data) && isSOI(data[n:]) && size <= len(data)-n {
+			soi = n
+			break
+		}
+	}
+	if soi < 0 && 0 < size && size <= len(data)-liveViewHeaderMinSize && isSOI(data[len(data)-size:]) {
+		soi = len(data) - size
+	}
+	if soi < 0 {
+		return nil, ErrNoLiveViewImage
+	}
+
+	be := binary.BigEndian
+	h := data[:soi]
+	img = &LiveViewImage{
+		Header: LiveViewHeader{
+			JPEGSize:      be.Uint32(h[0x00:]),
+			Width:         be.Uint16(h[0x04:]),
+			Height:        be.Uint16(h[0x06:]),
+			DisplayWidth:  be.Uint16(h[0x08:]),
+			DisplayHeight: be.Uint16(h[0x0A:]),
+			DisplayX:      be.Uint16(h[0x0C:]),
+			DisplayY:      be.Uint16(h[0x0E:]),
+			AFWidth:       be.Uint16(h[0x10:]),
+			AFHeight:      be.Uint16(h[0x12:]),
+			AFX:           be.Uint16(h[0x14:]),
+			AFY:           be.Uint16(h[0x16:]),
+		},
+		RawHeader: h,
+		JPEG:      data[soi:],
+	}
+
+	if size := int(img.Header.JPEGSize); 0 < size && size <= len(img.JPEG) {
+		img.JPEG = img.JPEG[:size]
+	}
+
+	return img, nil
+}
+
+// isSOI reports whether b starts with the JPEG start of image marker.
+func isSOI(b []byte) bool {
+	return 2 <= len(b) && b[0] == 0xFF && b[1] == 0xD8
+}
+
+// StartLiveView starts live view and waits until the camera is ready to deliver frames.
+func (cam *Camera) StartLiveView() (err error) {
+	if _, err = cam.transaction(OperationCodeStartLiveView, 0, 0, 0); err != nil {
+		return err
+	}
+	return cam.WaitReady(liveViewReadyTimeout)
+}
+
+// EndLiveView ...
+func (cam *Camera) EndLiveView() (err error) {
+	_, err = cam.transaction(OperationCodeEndLiveView, 0, 0, 0)
+	return err
+}
+
+// GetLiveViewImg returns the current live view frame.
+func (cam *Camera) GetLiveViewImg() (img *LiveViewImage, err error) {
+	data, err := cam.transaction(OperationCodeGetLiveViewImg, 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLiveViewImage(data)
+}
+
+// LiveViewImage returns the JPEG of the current live view frame.
+func (cam *Camera) LiveViewImage() (jpeg []byte, err error) {
+	img, err := cam.GetLiveViewImg()
+	if err != nil {
+		return nil, err
+	}
+	return img.JPEG, nil
+}
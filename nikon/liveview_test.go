@@ -0,0 +1,105 @@
+package nikon_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/takurooo/ptpip/nikon"
+)
+
+// jpeg is a minimal JPEG, SOI and EOI around some scan data.
+var jpeg = []byte{0xFF, 0xD8, 0xFF, 0xDA, 0x01, 0x02, 0x03, 0xFF, 0xD9}
+
+// liveViewData returns GetLiveViewImg data with a header of size bytes
+// holding h, the bytes of fill at their offsets, followed by img.
+func liveViewData(size int, h nikon.LiveViewHeader, fill map[int][]byte, img []byte) []byte {
+	data := make([]byte, size, size+len(img))
+	be := binary.BigEndian
+	be.PutUint32(data[0x00:], h.JPEGSize)
+	for i, v := range []uint16{h.Width, h.Height, h.DisplayWidth, h.DisplayHeight, h.DisplayX, h.DisplayY, h.AFWidth, h.AFHeight, h.AFX, h.AFY} {
+		be.PutUint16(data[0x04+2*i:], v)
+	}
+	for off, b := range fill {
+		copy(data[off:], b)
+	}
+	return append(data, img...)
+}
+
+func TestParseLiveViewImage(t *testing.T) {
+	h := nikon.LiveViewHeader{
+		JPEGSize: uint32(len(jpeg)),
+		Width:    640, Height: 424,
+		DisplayWidth: 6048, DisplayHeight: 4024,
+		AFWidth: 620, AFHeight: 412, AFX: 3024, AFY: 2012,
+	}
+	// header fields holding the JPEG start of image marker
+	soiFields := h
+	soiFields.Width, soiFields.DisplayX = 0xFFD8, 0xFFD8
+	soi := []byte{0xFF, 0xD8}
+	// JPEGSize 0 as reported by some models
+	noSize := h
+	noSize.JPEGSize = 0
+
+	tests := []struct {
+		name   string
+		size   int
+		header nikon.LiveViewHeader
+		fill   map[int][]byte
+		img    []byte
+		expect []byte
+	}{
+		{"D800 header", 0x180, h, nil, jpeg, jpeg},
+		{"D90 header", 0x80, h, nil, jpeg, jpeg},
+		{"D3 header", 0x40, h, nil, jpeg, jpeg},
+		{"SOI in decoded fields", 0x180, soiFields, nil, jpeg, jpeg},
+		{"SOI at smaller header sizes", 0x180, h, map[int][]byte{0x40: soi, 0x80: soi}, jpeg, jpeg},
+		{"SOI at D3 header size", 0x80, h, map[int][]byte{0x40: soi}, jpeg, jpeg},
+		{"unknown header size", 0x100, h, nil, jpeg, jpeg},
+		{"trailing bytes", 0x80, h, nil, append(append([]byte{}, jpeg...), 0, 0, 0), jpeg},
+		{"no JPEGSize", 0x40, noSize, nil, jpeg, jpeg},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := nikon.ParseLiveViewImage(liveViewData(tt.size, tt.header, tt.fill, tt.img))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(img.JPEG, tt.expect) {
+				t.Errorf("got JPEG % x expected % x", img.JPEG, tt.expect)
+			}
+			if len(img.RawHeader) != tt.size {
+				t.Errorf("got header len 0x%x expected 0x%x", len(img.RawHeader), tt.size)
+			}
+			if img.Header != tt.header {
+				t.Errorf("got %+v expected %+v", img.Header, tt.header)
+			}
+		})
+	}
+}
+
+func TestParseLiveViewImageNoJPEG(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"short", make([]byte, 0x17), nil},
+		{"header only", liveViewData(0x180, nikon.LiveViewHeader{}, nil, nil), nikon.ErrNoLiveViewImage},
+		{"no SOI", liveViewData(0x80, nikon.LiveViewHeader{JPEGSize: 4}, nil, []byte{1, 2, 3, 4}), nikon.ErrNoLiveViewImage},
+		{"JPEGSize too large", liveViewData(0x80, nikon.LiveViewHeader{JPEGSize: 0x1000}, nil, jpeg), nikon.ErrNoLiveViewImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := nikon.ParseLiveViewImage(tt.data)
+			if err == nil {
+				t.Fatalf("got JPEG % x expected error", img.JPEG)
+			}
+			if tt.err != nil && err != tt.err {
+				t.Errorf("got %v expected %v", err, tt.err)
+			}
+		})
+	}
+}
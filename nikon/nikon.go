@@ -0,0 +1,314 @@
+// Package nikon implements the Nikon extension to PTP on top of ptpip.Client.
+package nikon
+
+import (
+	"errors"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// VendorExtensionID is the VendorExtensionID reported by Nikon in DeviceInfo.
+	VendorExtensionID uint32 = 0x0000000A
+
+	deviceReadyInterval = 50 * time.Millisecond
+)
+
+// Operation Code
+const (
+	OperationCodeInitiateCaptureRecInSdram uint16 = 0x90C0
+	OperationCodeAfDrive                   uint16 = 0x90C1
+	OperationCodeChangeCameraMode          uint16 = 0x90C2
+	OperationCodeDeleteImagesInSdram       uint16 = 0x90C3
+	OperationCodeGetLargeThumb             uint16 = 0x90C4
+	OperationCodeGetEvent                  uint16 = 0x90C7
+	OperationCodeDeviceReady               uint16 = 0x90C8
+	OperationCodeSetPreWBData              uint16 = 0x90C9
+	OperationCodeGetVendorPropCodes        uint16 = 0x90CA
+	OperationCodeAfAndCaptureRecInSdram    uint16 = 0x90CB
+	OperationCodeGetPicCtrlData            uint16 = 0x90CC
+	OperationCodeStartLiveView             uint16 = 0x9201
+	OperationCodeEndLiveView               uint16 = 0x9202
+	OperationCodeGetLiveViewImg            uint16 = 0x9203
+	OperationCodeMfDrive                   uint16 = 0x9204
+	OperationCodeChangeAfArea              uint16 = 0x9205
+	OperationCodeAfDriveCancel             uint16 = 0x9206
+	OperationCodeInitiateCaptureRecInMedia uint16 = 0x9207
+)
+
+// Event Code
+const (
+	EventCodeObjectAddedInSdram        uint16 = 0xC101
+	EventCodeCaptureCompleteRecInSdram uint16 = 0xC102
+	EventCodeAdvancedTransfer          uint16 = 0xC103
+	EventCodePreviewImageAdded         uint16 = 0xC104
+)
+
+// Device Property Code
+const (
+	DevicePropCodeExposureTime              uint16 = 0xD100
+	DevicePropCodeACPower                   uint16 = 0xD101
+	DevicePropCodeWarningStatus             uint16 = 0xD102
+	DevicePropCodeMaximumShots              uint16 = 0xD103
+	DevicePropCodeAFLockStatus              uint16 = 0xD104
+	DevicePropCodeAELockStatus              uint16 = 0xD105
+	DevicePropCodeFVLockStatus              uint16 = 0xD106
+	DevicePropCodeAutofocusArea             uint16 = 0xD108
+	DevicePropCodeFlexibleProgram           uint16 = 0xD109
+	DevicePropCodeLightMeter                uint16 = 0xD10A
+	DevicePropCodeRecordingMedia            uint16 = 0xD10B
+	DevicePropCodeUSBSpeed                  uint16 = 0xD10C
+	DevicePropCodeCCDNumber                 uint16 = 0xD10D
+	DevicePropCodeCameraOrientation         uint16 = 0xD10E
+	DevicePropCodeExternalFlashAttached     uint16 = 0xD120
+	DevicePropCodeExternalFlashStatus       uint16 = 0xD121
+	DevicePropCodeExternalFlashSort         uint16 = 0xD122
+	DevicePropCodeExternalFlashMode         uint16 = 0xD123
+	DevicePropCodeExternalFlashCompensation uint16 = 0xD124
+	DevicePropCodeNewExternalFlashMode      uint16 = 0xD125
+	DevicePropCodeFlashExposureCompensation uint16 = 0xD126
+	DevicePropCodeLiveViewStatus            uint16 = 0xD1A2
+	DevicePropCodeLiveViewImageZoomRatio    uint16 = 0xD1A3
+	DevicePropCodeLiveViewProhibitCondition uint16 = 0xD1A4
+	DevicePropCodeExposureDisplayStatus     uint16 = 0xD1B0
+	DevicePropCodeExposureIndicateStatus    uint16 = 0xD1B1
+	DevicePropCodeInfoDispErrStatus         uint16 = 0xD1B2
+	DevicePropCodeExposureIndicateLightup   uint16 = 0xD1B3
+	DevicePropCodeFlashOpen                 uint16 = 0xD1C0
+	DevicePropCodeFlashCharged              uint16 = 0xD1C1
+	DevicePropCodeApplicationMode           uint16 = 0xD1F0
+)
+
+// Response Code
+const (
+	ResponseCodeHardwareError              uint16 = 0xA001
+	ResponseCodeOutOfFocus                 uint16 = 0xA002
+	ResponseCodeChangeCameraModeFailed     uint16 = 0xA003
+	ResponseCodeInvalidStatus              uint16 = 0xA004
+	ResponseCodeSetPropertyNotSupported    uint16 = 0xA005
+	ResponseCodeWbResetError               uint16 = 0xA006
+	ResponseCodeDustReferenceError         uint16 = 0xA007
+	ResponseCodeShutterSpeedBulb           uint16 = 0xA008
+	ResponseCodeMirrorUpSequence           uint16 = 0xA009
+	ResponseCodeCameraModeNotAdjustFNumber uint16 = 0xA00A
+	ResponseCodeNotLiveView                uint16 = 0xA00B
+	ResponseCodeMfDriveStepEnd             uint16 = 0xA00C
+	ResponseCodeMfDriveStepInsufficiency   uint16 = 0xA00E
+)
+
+// Errors returned for the Nikon response codes.
+var (
+	ErrHardwareError              = errors.New("nikon: hardware error")
+	ErrOutOfFocus                 = errors.New("nikon: out of focus")
+	ErrChangeCameraModeFailed     = errors.New("nikon: change camera mode failed")
+	ErrInvalidStatus              = errors.New("nikon: invalid status")
+	ErrSetPropertyNotSupported    = errors.New("nikon: set property not supported")
+	ErrWbResetError               = errors.New("nikon: white balance reset error")
+	ErrDustReferenceError         = errors.New("nikon: dust reference error")
+	ErrShutterSpeedBulb           = errors.New("nikon: shutter speed bulb")
+	ErrMirrorUpSequence           = errors.New("nikon: mirror up sequence")
+	ErrCameraModeNotAdjustFNumber = errors.New("nikon: camera mode cannot adjust f-number")
+	ErrNotLiveView                = errors.New("nikon: not in live view")
+	ErrMfDriveStepEnd             = errors.New("nikon: manual focus drive step end")
+	ErrMfDriveStepInsufficiency   = errors.New("nikon: manual focus drive step insufficiency")
+)
+
+var responseErrors = map[uint16]error{
+	ResponseCodeHardwareError:              ErrHardwareError,
+	ResponseCodeOutOfFocus:                 ErrOutOfFocus,
+	ResponseCodeChangeCameraModeFailed:     ErrChangeCameraModeFailed,
+	ResponseCodeInvalidStatus:              ErrInvalidStatus,
+	ResponseCodeSetPropertyNotSupported:    ErrSetPropertyNotSupported,
+	ResponseCodeWbResetError:               ErrWbResetError,
+	ResponseCodeDustReferenceError:         ErrDustReferenceError,
+	ResponseCodeShutterSpeedBulb:           ErrShutterSpeedBulb,
+	ResponseCodeMirrorUpSequence:           ErrMirrorUpSequence,
+	ResponseCodeCameraModeNotAdjustFNumber: ErrCameraModeNotAdjustFNumber,
+	ResponseCodeNotLiveView:                ErrNotLiveView,
+	ResponseCodeMfDriveStepEnd:             ErrMfDriveStepEnd,
+	ResponseCodeMfDriveStepInsufficiency:   ErrMfDriveStepInsufficiency,
+}
+
+// mapError converts a Nikon response code into its error.
+func mapError(err error) error {
+	if re, ok := err.(*packet.ResponseError); ok {
+		if e, ok := responseErrors[re.Code]; ok {
+			return e
+		}
+	}
+	return err
+}
+
+// Vendor is the Nikon vendor extension registered with ptpip.
+var Vendor = &ptpip.Vendor{
+	Name:              "nikon",
+	VendorExtensionID: VendorExtensionID,
+	Manufacturer:      "Nikon",
+	Operations: map[uint16]string{
+		OperationCodeInitiateCaptureRecInSdram: "InitiateCaptureRecInSdram",
+		OperationCodeAfDrive:                   "AfDrive",
+		OperationCodeChangeCameraMode:          "ChangeCameraMode",
+		OperationCodeDeleteImagesInSdram:       "DeleteImagesInSdram",
+		OperationCodeGetLargeThumb:             "GetLargeThumb",
+		OperationCodeGetEvent:                  "GetEvent",
+		OperationCodeDeviceReady:               "DeviceReady",
+		OperationCodeSetPreWBData:              "SetPreWBData",
+		OperationCodeGetVendorPropCodes:        "GetVendorPropCodes",
+		OperationCodeAfAndCaptureRecInSdram:    "AfAndCaptureRecInSdram",
+		OperationCodeGetPicCtrlData:            "GetPicCtrlData",
+		OperationCodeStartLiveView:             "StartLiveView",
+		OperationCodeEndLiveView:               "EndLiveView",
+		OperationCodeGetLiveViewImg:            "GetLiveViewImg",
+		OperationCodeMfDrive:                   "MfDrive",
+		OperationCodeChangeAfArea:              "ChangeAfArea",
+		OperationCodeAfDriveCancel:             "AfDriveCancel",
+		OperationCodeInitiateCaptureRecInMedia: "InitiateCaptureRecInMedia",
+	},
+	Events: map[uint16]string{
+		EventCodeObjectAddedInSdram:        "ObjectAddedInSdram",
+		EventCodeCaptureCompleteRecInSdram: "CaptureCompleteRecInSdram",
+		EventCodeAdvancedTransfer:          "AdvancedTransfer",
+		EventCodePreviewImageAdded:         "PreviewImageAdded",
+	},
+	DeviceProps: map[uint16]string{
+		DevicePropCodeExposureTime:              "ExposureTime",
+		DevicePropCodeACPower:                   "ACPower",
+		DevicePropCodeWarningStatus:             "WarningStatus",
+		DevicePropCodeMaximumShots:              "MaximumShots",
+		DevicePropCodeAFLockStatus:              "AFLockStatus",
+		DevicePropCodeAELockStatus:              "AELockStatus",
+		DevicePropCodeFVLockStatus:              "FVLockStatus",
+		DevicePropCodeAutofocusArea:             "AutofocusArea",
+		DevicePropCodeFlexibleProgram:           "FlexibleProgram",
+		DevicePropCodeLightMeter:                "LightMeter",
+		DevicePropCodeRecordingMedia:            "RecordingMedia",
+		DevicePropCodeUSBSpeed:                  "USBSpeed",
+		DevicePropCodeCCDNumber:                 "CCDNumber",
+		DevicePropCodeCameraOrientation:         "CameraOrientation",
+		DevicePropCodeExternalFlashAttached:     "ExternalFlashAttached",
+		DevicePropCodeExternalFlashStatus:       "ExternalFlashStatus",
+		DevicePropCodeExternalFlashSort:         "ExternalFlashSort",
+		DevicePropCodeExternalFlashMode:         "ExternalFlashMode",
+		DevicePropCodeExternalFlashCompensation: "ExternalFlashCompensation",
+		DevicePropCodeNewExternalFlashMode:      "NewExternalFlashMode",
+		DevicePropCodeFlashExposureCompensation: "FlashExposureCompensation",
+		DevicePropCodeLiveViewStatus:            "LiveViewStatus",
+		DevicePropCodeLiveViewImageZoomRatio:    "LiveViewImageZoomRatio",
+		DevicePropCodeLiveViewProhibitCondition: "LiveViewProhibitCondition",
+		DevicePropCodeExposureDisplayStatus:     "ExposureDisplayStatus",
+		DevicePropCodeExposureIndicateStatus:    "ExposureIndicateStatus",
+		DevicePropCodeInfoDispErrStatus:         "InfoDispErrStatus",
+		DevicePropCodeExposureIndicateLightup:   "ExposureIndicateLightup",
+		DevicePropCodeFlashOpen:                 "FlashOpen",
+		DevicePropCodeFlashCharged:              "FlashCharged",
+		DevicePropCodeApplicationMode:           "ApplicationMode",
+	},
+	Responses: map[uint16]string{
+		ResponseCodeHardwareError:              "HardwareError",
+		ResponseCodeOutOfFocus:                 "OutOfFocus",
+		ResponseCodeChangeCameraModeFailed:     "ChangeCameraModeFailed",
+		ResponseCodeInvalidStatus:              "InvalidStatus",
+		ResponseCodeSetPropertyNotSupported:    "SetPropertyNotSupported",
+		ResponseCodeWbResetError:               "WbResetError",
+		ResponseCodeDustReferenceError:         "DustReferenceError",
+		ResponseCodeShutterSpeedBulb:           "ShutterSpeedBulb",
+		ResponseCodeMirrorUpSequence:           "MirrorUpSequence",
+		ResponseCodeCameraModeNotAdjustFNumber: "CameraModeNotAdjustFNumber",
+		ResponseCodeNotLiveView:                "NotLiveView",
+		ResponseCodeMfDriveStepEnd:             "MfDriveStepEnd",
+		ResponseCodeMfDriveStepInsufficiency:   "MfDriveStepInsufficiency",
+	},
+	Decoders: map[uint16]ptpip.DatasetDecoder{
+		OperationCodeGetEvent: func(data []byte) (interface{}, error) {
+			return ParseEvents(data)
+		},
+		OperationCodeGetLiveViewImg: func(data []byte) (interface{}, error) {
+			return ParseLiveViewImage(data)
+		},
+	},
+}
+
+func init() {
+	ptpip.RegisterVendor(Vendor)
+}
+
+// CameraMode value for ChangeCameraMode
+const (
+	CameraModeCamera uint32 = 0x00000000 // controlled from the camera body
+	CameraModeHost   uint32 = 0x00000001 // controlled from the host
+)
+
+// Camera wraps a ptpip.Client connected to a Nikon body.
+type Camera struct {
+	c *ptpip.Client
+}
+
+// New ...
+func New(c *ptpip.Client) *Camera {
+	return &Camera{c: c}
+}
+
+// Client returns the underlying ptpip.Client.
+func (cam *Camera) Client() *ptpip.Client {
+	return cam.c
+}
+
+func (cam *Camera) transaction(opCode uint16, p1, p2, p3 uint32) (data []byte, err error) {
+	data, err = cam.c.Transaction(opCode, packet.DataPhaseInfoNoDataOrDataIn, p1, p2, p3, 0, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return data, nil
+}
+
+// InitiateCaptureRecInSdram takes a picture into the camera buffer without
+// writing it to the card. The new object is reported by EventCodeObjectAddedInSdram.
+func (cam *Camera) InitiateCaptureRecInSdram() (err error) {
+	_, err = cam.transaction(OperationCodeInitiateCaptureRecInSdram, 0xFFFFFFFF, 0, 0)
+	return err
+}
+
+// InitiateCaptureRecInMedia takes a picture and writes it to the card.
+func (cam *Camera) InitiateCaptureRecInMedia() (err error) {
+	_, err = cam.transaction(OperationCodeInitiateCaptureRecInMedia, 0xFFFFFFFF, 0, 0)
+	return err
+}
+
+// AfDrive starts autofocus. Poll DeviceReady for the result; ErrOutOfFocus
+// means the camera could not focus.
+func (cam *Camera) AfDrive() (err error) {
+	_, err = cam.transaction(OperationCodeAfDrive, 0, 0, 0)
+	return err
+}
+
+// ChangeCameraMode switches between CameraModeCamera and CameraModeHost.
+func (cam *Camera) ChangeCameraMode(mode uint32) (err error) {
+	_, err = cam.transaction(OperationCodeChangeCameraMode, mode, 0, 0)
+	return err
+}
+
+// DeviceReady returns nil when the camera has finished the previous
+// operation, packet.ResponseError with ResponseCodeDeviceBusy while it is
+// busy, or the Nikon error of the previous operation.
+func (cam *Camera) DeviceReady() (err error) {
+	_, err = cam.transaction(OperationCodeDeviceReady, 0, 0, 0)
+	return err
+}
+
+// WaitReady polls DeviceReady until the camera is ready or timeout expires.
+func (cam *Camera) WaitReady(timeout time.Duration) (err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		err = cam.DeviceReady()
+		re, busy := err.(*packet.ResponseError)
+		if !busy || re.Code != packet.ResponseCodeDeviceBusy {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(deviceReadyInterval)
+	}
+}
@@ -0,0 +1,164 @@
+package ptpip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// partialObjectChunkSize is the number of bytes requested per GetPartialObject
+	partialObjectChunkSize uint32 = 1024 * 1024
+)
+
+// ErrNoThumbnailPresent is returned by GetThumb when the object has no thumbnail.
+var ErrNoThumbnailPresent = errors.New("no thumbnail present")
+
+// GetStorageIDs ...
+func (c *Client) GetStorageIDs() (storageIDs []uint32, err error) {
+	data, err := c.Transaction(uint16(packet.OperationCodeGetStorageIDs), packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseU32Array(data)
+}
+
+// GetStorageInfo ...
+func (c *Client) GetStorageInfo(storageID uint32) (info *packet.StorageInfo, err error) {
+	data, err := c.Transaction(uint16(packet.OperationCodeGetStorageInfo), packet.DataPhaseInfoNoDataOrDataIn, storageID, 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return packet.ParseStorageInfo(data)
+}
+
+// GetObjectHandles returns the handles of the objects in storageID
+// (0xFFFFFFFF for all storages), optionally filtered by object format and
+// parent association (0xFFFFFFFF for the root).
+func (c *Client) GetObjectHandles(storageID uint32, objectFormat uint16, parent uint32) (handles []uint32, err error) {
+	data, err := c.Transaction(uint16(packet.OperationCodeGetObjectHandles), packet.DataPhaseInfoNoDataOrDataIn, storageID, uint32(objectFormat), parent, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseU32Array(data)
+}
+
+// InitiateCapture takes a picture into storageID (0 lets the device choose).
+// The new objects are reported by ObjectAdded and CaptureComplete events.
+func (c *Client) InitiateCapture(storageID uint32, objectFormat uint16) (err error) {
+	_, err = c.Transaction(uint16(packet.OperationCodeInitiateCapture), packet.DataPhaseInfoNoDataOrDataIn, storageID, uint32(objectFormat), 0, 0, nil)
+	return err
+}
+
+// GetObjectInfo ...
+func (c *Client) GetObjectInfo(handle uint32) (info *packet.ObjectInfo, err error) {
+	data, err := c.Transaction(uint16(packet.OperationCodeGetObjectInfo), packet.DataPhaseInfoNoDataOrDataIn, handle, 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return packet.ParseObjectInfo(data)
+}
+
+// SendObjectInfo announces an object to be sent with SendObject. storageID
+// and parent may be 0 to let the responder choose. It returns where the
+// responder will store the object.
+func (c *Client) SendObjectInfo(storageID uint32, parent uint32, info *packet.ObjectInfo) (respStorageID, respParent, handle uint32, err error) {
+	data, err := packet.MarshalObjectInfo(info)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	_, resp, err := c.TransactionWithResponse(uint16(packet.OperationCodeSendObjectInfo), packet.DataPhaseInfoDataOut, storageID, parent, 0, 0, data)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return resp.P1, resp.P2, resp.P3, nil
+}
+
+// SendObject sends the data of the object announced by the preceding SendObjectInfo.
+func (c *Client) SendObject(data []byte) (err error) {
+	_, err = c.Transaction(uint16(packet.OperationCodeSendObject), packet.DataPhaseInfoDataOut, 0, 0, 0, 0, data)
+	return err
+}
+
+// DeleteObject ...
+func (c *Client) DeleteObject(handle uint32) (err error) {
+	_, err = c.Transaction(uint16(packet.OperationCodeDeleteObject), packet.DataPhaseInfoNoDataOrDataIn, handle, 0, 0, 0, nil)
+	return err
+}
+
+// GetObject ...
+func (c *Client) GetObject(handle uint32) (data []byte, err error) {
+	data, err = c.Transaction(uint16(packet.OperationCodeGetObject), packet.DataPhaseInfoNoDataOrDataIn, handle, 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetThumb ...
+func (c *Client) GetThumb(handle uint32) (data []byte, err error) {
+	data, err = c.Transaction(uint16(packet.OperationCodeGetThumb), packet.DataPhaseInfoNoDataOrDataIn, handle, 0, 0, 0, nil)
+	if err != nil {
+		if re, ok := err.(*packet.ResponseError); ok && re.Code == packet.ResponseCodeNoThumbnailPresent {
+			return nil, ErrNoThumbnailPresent
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetPartialObject returns up to maxBytes of the object starting at offset.
+func (c *Client) GetPartialObject(handle uint32, offset uint32, maxBytes uint32) (data []byte, err error) {
+	data, err = c.Transaction(uint16(packet.OperationCodeGetPartialObject), packet.DataPhaseInfoNoDataOrDataIn, handle, offset, maxBytes, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DownloadObject writes the object to w with GetPartialObject, starting at offset.
+// The returned n is the offset reached, so a download broken off by a connection
+// drop can be resumed by calling DownloadObject again with n as offset.
+func (c *Client) DownloadObject(handle uint32, w io.WriterAt, offset int64) (n int64, err error) {
+	info, err := c.GetObjectInfo(handle)
+	if err != nil {
+		return offset, err
+	}
+	size := info.ObjectCompressedSize
+
+	if offset < 0 {
+		return offset, errors.New("ptpip: DownloadObject: negative offset")
+	}
+
+	n = offset
+	for n < int64(size) {
+		data, err := c.GetPartialObject(handle, uint32(n), partialObjectChunkSize)
+		if err != nil {
+			return n, err
+		}
+		if len(data) == 0 {
+			break
+		}
+		if _, err = w.WriteAt(data, n); err != nil {
+			return n, err
+		}
+		n += int64(len(data))
+	}
+
+	if n != int64(size) {
+		return n, fmt.Errorf("invalid object size 0x%x expected 0x%x", n, size)
+	}
+
+	return n, nil
+}
+
+func parseU32Array(data []byte) (a []uint32, err error) {
+	v, _, err := packet.DecodeValue(data, packet.DataTypeArray|packet.DataTypeUInt32)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]uint32), nil
+}
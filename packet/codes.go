@@ -0,0 +1,406 @@
+package packet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The code tables of PTP 1.1 (ISO 15740:2013). Vendor extensions define
+// their codes in the vendor packages.
+
+// OperationCode is a PTP operation code.
+type OperationCode uint16
+
+// Operation Code
+const (
+	OperationCodeUndefined              OperationCode = 0x1000
+	OperationCodeGetDeviceInfo          OperationCode = 0x1001
+	OperationCodeOpenSession            OperationCode = 0x1002
+	OperationCodeCloseSession           OperationCode = 0x1003
+	OperationCodeGetStorageIDs          OperationCode = 0x1004
+	OperationCodeGetStorageInfo         OperationCode = 0x1005
+	OperationCodeGetNumObjects          OperationCode = 0x1006
+	OperationCodeGetObjectHandles       OperationCode = 0x1007
+	OperationCodeGetObjectInfo          OperationCode = 0x1008
+	OperationCodeGetObject              OperationCode = 0x1009
+	OperationCodeGetThumb               OperationCode = 0x100A
+	OperationCodeDeleteObject           OperationCode = 0x100B
+	OperationCodeSendObjectInfo         OperationCode = 0x100C
+	OperationCodeSendObject             OperationCode = 0x100D
+	OperationCodeInitiateCapture        OperationCode = 0x100E
+	OperationCodeFormatStore            OperationCode = 0x100F
+	OperationCodeResetDevice            OperationCode = 0x1010
+	OperationCodeSelfTest               OperationCode = 0x1011
+	OperationCodeSetObjectProtection    OperationCode = 0x1012
+	OperationCodePowerDown              OperationCode = 0x1013
+	OperationCodeGetDevicePropDesc      OperationCode = 0x1014
+	OperationCodeGetDevicePropValue     OperationCode = 0x1015
+	OperationCodeSetDevicePropValue     OperationCode = 0x1016
+	OperationCodeResetDevicePropValue   OperationCode = 0x1017
+	OperationCodeTerminateOpenCapture   OperationCode = 0x1018
+	OperationCodeMoveObject             OperationCode = 0x1019
+	OperationCodeCopyObject             OperationCode = 0x101A
+	OperationCodeGetPartialObject       OperationCode = 0x101B
+	OperationCodeInitiateOpenCapture    OperationCode = 0x101C
+	OperationCodeStartEnumHandles       OperationCode = 0x101D
+	OperationCodeEnumHandles            OperationCode = 0x101E
+	OperationCodeStopEnumHandles        OperationCode = 0x101F
+	OperationCodeGetVendorExtensionMaps OperationCode = 0x1020
+	OperationCodeGetVendorDeviceInfo    OperationCode = 0x1021
+	OperationCodeGetResizedImageObject  OperationCode = 0x1022
+	OperationCodeGetFilesystemManifest  OperationCode = 0x1023
+	OperationCodeGetStreamInfo          OperationCode = 0x1024
+	OperationCodeGetStream              OperationCode = 0x1025
+)
+
+var operationCodeNames = map[OperationCode]string{
+	OperationCodeUndefined:              "Undefined",
+	OperationCodeGetDeviceInfo:          "GetDeviceInfo",
+	OperationCodeOpenSession:            "OpenSession",
+	OperationCodeCloseSession:           "CloseSession",
+	OperationCodeGetStorageIDs:          "GetStorageIDs",
+	OperationCodeGetStorageInfo:         "GetStorageInfo",
+	OperationCodeGetNumObjects:          "GetNumObjects",
+	OperationCodeGetObjectHandles:       "GetObjectHandles",
+	OperationCodeGetObjectInfo:          "GetObjectInfo",
+	OperationCodeGetObject:              "GetObject",
+	OperationCodeGetThumb:               "GetThumb",
+	OperationCodeDeleteObject:           "DeleteObject",
+	OperationCodeSendObjectInfo:         "SendObjectInfo",
+	OperationCodeSendObject:             "SendObject",
+	OperationCodeInitiateCapture:        "InitiateCapture",
+	OperationCodeFormatStore:            "FormatStore",
+	OperationCodeResetDevice:            "ResetDevice",
+	OperationCodeSelfTest:               "SelfTest",
+	OperationCodeSetObjectProtection:    "SetObjectProtection",
+	OperationCodePowerDown:              "PowerDown",
+	OperationCodeGetDevicePropDesc:      "GetDevicePropDesc",
+	OperationCodeGetDevicePropValue:     "GetDevicePropValue",
+	OperationCodeSetDevicePropValue:     "SetDevicePropValue",
+	OperationCodeResetDevicePropValue:   "ResetDevicePropValue",
+	OperationCodeTerminateOpenCapture:   "TerminateOpenCapture",
+	OperationCodeMoveObject:             "MoveObject",
+	OperationCodeCopyObject:             "CopyObject",
+	OperationCodeGetPartialObject:       "GetPartialObject",
+	OperationCodeInitiateOpenCapture:    "InitiateOpenCapture",
+	OperationCodeStartEnumHandles:       "StartEnumHandles",
+	OperationCodeEnumHandles:            "EnumHandles",
+	OperationCodeStopEnumHandles:        "StopEnumHandles",
+	OperationCodeGetVendorExtensionMaps: "GetVendorExtensionMaps",
+	OperationCodeGetVendorDeviceInfo:    "GetVendorDeviceInfo",
+	OperationCodeGetResizedImageObject:  "GetResizedImageObject",
+	OperationCodeGetFilesystemManifest:  "GetFilesystemManifest",
+	OperationCodeGetStreamInfo:          "GetStreamInfo",
+	OperationCodeGetStream:              "GetStream",
+}
+
+// String returns the name of the code, e.g. "GetDeviceInfo", or its hex value if unknown.
+func (o OperationCode) String() string {
+	if name, ok := operationCodeNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", uint16(o))
+}
+
+// ParseOperationCode returns the code of a name returned by String, ignoring case.
+func ParseOperationCode(s string) (o OperationCode, err error) {
+	for code, name := range operationCodeNames {
+		if strings.EqualFold(name, s) {
+			return code, nil
+		}
+	}
+	v, err := parseCode(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid operation code %q", s)
+	}
+	return OperationCode(v), nil
+}
+
+// EventCode is a PTP event code.
+type EventCode uint16
+
+// Event Code
+const (
+	EventCodeUndefined             EventCode = 0x4000
+	EventCodeCancelTransaction     EventCode = 0x4001
+	EventCodeObjectAdded           EventCode = 0x4002
+	EventCodeObjectRemoved         EventCode = 0x4003
+	EventCodeStoreAdded            EventCode = 0x4004
+	EventCodeStoreRemoved          EventCode = 0x4005
+	EventCodeDevicePropChanged     EventCode = 0x4006
+	EventCodeObjectInfoChanged     EventCode = 0x4007
+	EventCodeDeviceInfoChanged     EventCode = 0x4008
+	EventCodeRequestObjectTransfer EventCode = 0x4009
+	EventCodeStoreFull             EventCode = 0x400A
+	EventCodeDeviceReset           EventCode = 0x400B
+	EventCodeStorageInfoChanged    EventCode = 0x400C
+	EventCodeCaptureComplete       EventCode = 0x400D
+	EventCodeUnreportedStatus      EventCode = 0x400E
+)
+
+var eventCodeNames = map[EventCode]string{
+	EventCodeUndefined:             "Undefined",
+	EventCodeCancelTransaction:     "CancelTransaction",
+	EventCodeObjectAdded:           "ObjectAdded",
+	EventCodeObjectRemoved:         "ObjectRemoved",
+	EventCodeStoreAdded:            "StoreAdded",
+	EventCodeStoreRemoved:          "StoreRemoved",
+	EventCodeDevicePropChanged:     "DevicePropChanged",
+	EventCodeObjectInfoChanged:     "ObjectInfoChanged",
+	EventCodeDeviceInfoChanged:     "DeviceInfoChanged",
+	EventCodeRequestObjectTransfer: "RequestObjectTransfer",
+	EventCodeStoreFull:             "StoreFull",
+	EventCodeDeviceReset:           "DeviceReset",
+	EventCodeStorageInfoChanged:    "StorageInfoChanged",
+	EventCodeCaptureComplete:       "CaptureComplete",
+	EventCodeUnreportedStatus:      "UnreportedStatus",
+}
+
+// String returns the name of the code, e.g. "CancelTransaction", or its hex value if unknown.
+func (e EventCode) String() string {
+	if name, ok := eventCodeNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", uint16(e))
+}
+
+// ParseEventCode returns the code of a name returned by String, ignoring case.
+func ParseEventCode(s string) (e EventCode, err error) {
+	for code, name := range eventCodeNames {
+		if strings.EqualFold(name, s) {
+			return code, nil
+		}
+	}
+	v, err := parseCode(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid event code %q", s)
+	}
+	return EventCode(v), nil
+}
+
+// DevicePropCode is a PTP device property code.
+type DevicePropCode uint16
+
+// Device Property Code
+const (
+	DevicePropCodeUndefined                DevicePropCode = 0x5000
+	DevicePropCodeBatteryLevel             DevicePropCode = 0x5001
+	DevicePropCodeFunctionalMode           DevicePropCode = 0x5002
+	DevicePropCodeImageSize                DevicePropCode = 0x5003
+	DevicePropCodeCompressionSetting       DevicePropCode = 0x5004
+	DevicePropCodeWhiteBalance             DevicePropCode = 0x5005
+	DevicePropCodeRGBGain                  DevicePropCode = 0x5006
+	DevicePropCodeFNumber                  DevicePropCode = 0x5007
+	DevicePropCodeFocalLength              DevicePropCode = 0x5008
+	DevicePropCodeFocusDistance            DevicePropCode = 0x5009
+	DevicePropCodeFocusMode                DevicePropCode = 0x500A
+	DevicePropCodeExposureMeteringMode     DevicePropCode = 0x500B
+	DevicePropCodeFlashMode                DevicePropCode = 0x500C
+	DevicePropCodeExposureTime             DevicePropCode = 0x500D
+	DevicePropCodeExposureProgramMode      DevicePropCode = 0x500E
+	DevicePropCodeExposureIndex            DevicePropCode = 0x500F
+	DevicePropCodeExposureBiasCompensation DevicePropCode = 0x5010
+	DevicePropCodeDateTime                 DevicePropCode = 0x5011
+	DevicePropCodeCaptureDelay             DevicePropCode = 0x5012
+	DevicePropCodeStillCaptureMode         DevicePropCode = 0x5013
+	DevicePropCodeContrast                 DevicePropCode = 0x5014
+	DevicePropCodeSharpness                DevicePropCode = 0x5015
+	DevicePropCodeDigitalZoom              DevicePropCode = 0x5016
+	DevicePropCodeEffectMode               DevicePropCode = 0x5017
+	DevicePropCodeBurstNumber              DevicePropCode = 0x5018
+	DevicePropCodeBurstInterval            DevicePropCode = 0x5019
+	DevicePropCodeTimelapseNumber          DevicePropCode = 0x501A
+	DevicePropCodeTimelapseInterval        DevicePropCode = 0x501B
+	DevicePropCodeFocusMeteringMode        DevicePropCode = 0x501C
+	DevicePropCodeUploadURL                DevicePropCode = 0x501D
+	DevicePropCodeArtist                   DevicePropCode = 0x501E
+	DevicePropCodeCopyrightInfo            DevicePropCode = 0x501F
+	DevicePropCodeSupportedStreams         DevicePropCode = 0x5020
+	DevicePropCodeEnabledStreams           DevicePropCode = 0x5021
+	DevicePropCodeVideoFormat              DevicePropCode = 0x5022
+	DevicePropCodeVideoResolution          DevicePropCode = 0x5023
+	DevicePropCodeVideoQuality             DevicePropCode = 0x5024
+	DevicePropCodeVideoFrameRate           DevicePropCode = 0x5025
+	DevicePropCodeVideoContrast            DevicePropCode = 0x5026
+	DevicePropCodeVideoBrightness          DevicePropCode = 0x5027
+	DevicePropCodeAudioFormat              DevicePropCode = 0x5028
+	DevicePropCodeAudioBitrate             DevicePropCode = 0x5029
+	DevicePropCodeAudioSamplingRate        DevicePropCode = 0x502A
+	DevicePropCodeAudioBitPerSample        DevicePropCode = 0x502B
+	DevicePropCodeAudioVolume              DevicePropCode = 0x502C
+)
+
+var devicePropCodeNames = map[DevicePropCode]string{
+	DevicePropCodeUndefined:                "Undefined",
+	DevicePropCodeBatteryLevel:             "BatteryLevel",
+	DevicePropCodeFunctionalMode:           "FunctionalMode",
+	DevicePropCodeImageSize:                "ImageSize",
+	DevicePropCodeCompressionSetting:       "CompressionSetting",
+	DevicePropCodeWhiteBalance:             "WhiteBalance",
+	DevicePropCodeRGBGain:                  "RGBGain",
+	DevicePropCodeFNumber:                  "FNumber",
+	DevicePropCodeFocalLength:              "FocalLength",
+	DevicePropCodeFocusDistance:            "FocusDistance",
+	DevicePropCodeFocusMode:                "FocusMode",
+	DevicePropCodeExposureMeteringMode:     "ExposureMeteringMode",
+	DevicePropCodeFlashMode:                "FlashMode",
+	DevicePropCodeExposureTime:             "ExposureTime",
+	DevicePropCodeExposureProgramMode:      "ExposureProgramMode",
+	DevicePropCodeExposureIndex:            "ExposureIndex",
+	DevicePropCodeExposureBiasCompensation: "ExposureBiasCompensation",
+	DevicePropCodeDateTime:                 "DateTime",
+	DevicePropCodeCaptureDelay:             "CaptureDelay",
+	DevicePropCodeStillCaptureMode:         "StillCaptureMode",
+	DevicePropCodeContrast:                 "Contrast",
+	DevicePropCodeSharpness:                "Sharpness",
+	DevicePropCodeDigitalZoom:              "DigitalZoom",
+	DevicePropCodeEffectMode:               "EffectMode",
+	DevicePropCodeBurstNumber:              "BurstNumber",
+	DevicePropCodeBurstInterval:            "BurstInterval",
+	DevicePropCodeTimelapseNumber:          "TimelapseNumber",
+	DevicePropCodeTimelapseInterval:        "TimelapseInterval",
+	DevicePropCodeFocusMeteringMode:        "FocusMeteringMode",
+	DevicePropCodeUploadURL:                "UploadURL",
+	DevicePropCodeArtist:                   "Artist",
+	DevicePropCodeCopyrightInfo:            "CopyrightInfo",
+	DevicePropCodeSupportedStreams:         "SupportedStreams",
+	DevicePropCodeEnabledStreams:           "EnabledStreams",
+	DevicePropCodeVideoFormat:              "VideoFormat",
+	DevicePropCodeVideoResolution:          "VideoResolution",
+	DevicePropCodeVideoQuality:             "VideoQuality",
+	DevicePropCodeVideoFrameRate:           "VideoFrameRate",
+	DevicePropCodeVideoContrast:            "VideoContrast",
+	DevicePropCodeVideoBrightness:          "VideoBrightness",
+	DevicePropCodeAudioFormat:              "AudioFormat",
+	DevicePropCodeAudioBitrate:             "AudioBitrate",
+	DevicePropCodeAudioSamplingRate:        "AudioSamplingRate",
+	DevicePropCodeAudioBitPerSample:        "AudioBitPerSample",
+	DevicePropCodeAudioVolume:              "AudioVolume",
+}
+
+// String returns the name of the code, e.g. "BatteryLevel", or its hex value if unknown.
+func (d DevicePropCode) String() string {
+	if name, ok := devicePropCodeNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", uint16(d))
+}
+
+// ParseDevicePropCode returns the code of a name returned by String, ignoring case.
+func ParseDevicePropCode(s string) (d DevicePropCode, err error) {
+	for code, name := range devicePropCodeNames {
+		if strings.EqualFold(name, s) {
+			return code, nil
+		}
+	}
+	v, err := parseCode(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid device property code %q", s)
+	}
+	return DevicePropCode(v), nil
+}
+
+// ObjectFormatCode is a PTP object format code.
+type ObjectFormatCode uint16
+
+// Object Format Code
+const (
+	ObjectFormatCodeUndefined      ObjectFormatCode = 0x3000
+	ObjectFormatCodeAssociation    ObjectFormatCode = 0x3001
+	ObjectFormatCodeScript         ObjectFormatCode = 0x3002
+	ObjectFormatCodeExecutable     ObjectFormatCode = 0x3003
+	ObjectFormatCodeText           ObjectFormatCode = 0x3004
+	ObjectFormatCodeHTML           ObjectFormatCode = 0x3005
+	ObjectFormatCodeDPOF           ObjectFormatCode = 0x3006
+	ObjectFormatCodeAIFF           ObjectFormatCode = 0x3007
+	ObjectFormatCodeWAV            ObjectFormatCode = 0x3008
+	ObjectFormatCodeMP3            ObjectFormatCode = 0x3009
+	ObjectFormatCodeAVI            ObjectFormatCode = 0x300A
+	ObjectFormatCodeMPEG           ObjectFormatCode = 0x300B
+	ObjectFormatCodeASF            ObjectFormatCode = 0x300C
+	ObjectFormatCodeQT             ObjectFormatCode = 0x300D
+	ObjectFormatCodeUndefinedImage ObjectFormatCode = 0x3800
+	ObjectFormatCodeEXIFJPEG       ObjectFormatCode = 0x3801
+	ObjectFormatCodeTIFFEP         ObjectFormatCode = 0x3802
+	ObjectFormatCodeFlashPix       ObjectFormatCode = 0x3803
+	ObjectFormatCodeBMP            ObjectFormatCode = 0x3804
+	ObjectFormatCodeCIFF           ObjectFormatCode = 0x3805
+	ObjectFormatCodeGIF            ObjectFormatCode = 0x3807
+	ObjectFormatCodeJFIF           ObjectFormatCode = 0x3808
+	ObjectFormatCodePCD            ObjectFormatCode = 0x3809
+	ObjectFormatCodePICT           ObjectFormatCode = 0x380A
+	ObjectFormatCodePNG            ObjectFormatCode = 0x380B
+	ObjectFormatCodeTIFF           ObjectFormatCode = 0x380D
+	ObjectFormatCodeTIFFIT         ObjectFormatCode = 0x380E
+	ObjectFormatCodeJP2            ObjectFormatCode = 0x380F
+	ObjectFormatCodeJPX            ObjectFormatCode = 0x3810
+	ObjectFormatCodeDNG            ObjectFormatCode = 0x3811
+)
+
+var objectFormatCodeNames = map[ObjectFormatCode]string{
+	ObjectFormatCodeUndefined:      "Undefined",
+	ObjectFormatCodeAssociation:    "Association",
+	ObjectFormatCodeScript:         "Script",
+	ObjectFormatCodeExecutable:     "Executable",
+	ObjectFormatCodeText:           "Text",
+	ObjectFormatCodeHTML:           "HTML",
+	ObjectFormatCodeDPOF:           "DPOF",
+	ObjectFormatCodeAIFF:           "AIFF",
+	ObjectFormatCodeWAV:            "WAV",
+	ObjectFormatCodeMP3:            "MP3",
+	ObjectFormatCodeAVI:            "AVI",
+	ObjectFormatCodeMPEG:           "MPEG",
+	ObjectFormatCodeASF:            "ASF",
+	ObjectFormatCodeQT:             "QT",
+	ObjectFormatCodeUndefinedImage: "UndefinedImage",
+	ObjectFormatCodeEXIFJPEG:       "EXIFJPEG",
+	ObjectFormatCodeTIFFEP:         "TIFFEP",
+	ObjectFormatCodeFlashPix:       "FlashPix",
+	ObjectFormatCodeBMP:            "BMP",
+	ObjectFormatCodeCIFF:           "CIFF",
+	ObjectFormatCodeGIF:            "GIF",
+	ObjectFormatCodeJFIF:           "JFIF",
+	ObjectFormatCodePCD:            "PCD",
+	ObjectFormatCodePICT:           "PICT",
+	ObjectFormatCodePNG:            "PNG",
+	ObjectFormatCodeTIFF:           "TIFF",
+	ObjectFormatCodeTIFFIT:         "TIFFIT",
+	ObjectFormatCodeJP2:            "JP2",
+	ObjectFormatCodeJPX:            "JPX",
+	ObjectFormatCodeDNG:            "DNG",
+}
+
+// String returns the name of the code, e.g. "Association", or its hex value if unknown.
+func (o ObjectFormatCode) String() string {
+	if name, ok := objectFormatCodeNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", uint16(o))
+}
+
+// ParseObjectFormatCode returns the code of a name returned by String, ignoring case.
+func ParseObjectFormatCode(s string) (o ObjectFormatCode, err error) {
+	for code, name := range objectFormatCodeNames {
+		if strings.EqualFold(name, s) {
+			return code, nil
+		}
+	}
+	v, err := parseCode(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid object format code %q", s)
+	}
+	return ObjectFormatCode(v), nil
+}
+
+// parseCode parses a code as a hex value, e.g. "0x1001".
+func parseCode(s string) (v uint16, err error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return 0, fmt.Errorf("invalid code %q", s)
+	}
+	u, err := strconv.ParseUint(s[2:], 16, 16)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(u), nil
+}
@@ -0,0 +1,123 @@
+package packet_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// code is one of the code types with its String and Parse function.
+type code struct {
+	kind  string
+	str   func(v uint16) string
+	parse func(s string) (uint16, error)
+}
+
+var codes = []code{
+	{
+		"OperationCode",
+		func(v uint16) string { return packet.OperationCode(v).String() },
+		func(s string) (uint16, error) { c, err := packet.ParseOperationCode(s); return uint16(c), err },
+	},
+	{
+		"EventCode",
+		func(v uint16) string { return packet.EventCode(v).String() },
+		func(s string) (uint16, error) { c, err := packet.ParseEventCode(s); return uint16(c), err },
+	},
+	{
+		"DevicePropCode",
+		func(v uint16) string { return packet.DevicePropCode(v).String() },
+		func(s string) (uint16, error) { c, err := packet.ParseDevicePropCode(s); return uint16(c), err },
+	},
+	{
+		"ObjectFormatCode",
+		func(v uint16) string { return packet.ObjectFormatCode(v).String() },
+		func(s string) (uint16, error) { c, err := packet.ParseObjectFormatCode(s); return uint16(c), err },
+	},
+}
+
+func TestCodeString(t *testing.T) {
+	tests := []struct {
+		kind   int
+		v      uint16
+		expect string
+	}{
+		{0, uint16(packet.OperationCodeGetDeviceInfo), "GetDeviceInfo"},
+		{0, 0x9201, "0x9201"},
+		{1, uint16(packet.EventCodeObjectAdded), "ObjectAdded"},
+		{1, uint16(packet.EventCodeCaptureComplete), "CaptureComplete"},
+		{1, 0xC101, "0xC101"},
+		{2, uint16(packet.DevicePropCodeExposureTime), "ExposureTime"},
+		{2, 0xD1A2, "0xD1A2"},
+		{3, uint16(packet.ObjectFormatCodeEXIFJPEG), "EXIFJPEG"},
+		{3, 0x0000, "0x0000"},
+	}
+
+	for _, tt := range tests {
+		c := codes[tt.kind]
+		t.Run(fmt.Sprintf("%s 0x%04X", c.kind, tt.v), func(t *testing.T) {
+			if got := c.str(tt.v); got != tt.expect {
+				t.Errorf("got %q expected %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		kind   int
+		s      string
+		expect uint16
+	}{
+		{0, "GetDeviceInfo", uint16(packet.OperationCodeGetDeviceInfo)},
+		{0, "getdeviceinfo", uint16(packet.OperationCodeGetDeviceInfo)},
+		{0, "0x9201", 0x9201},
+		{0, "0X1001", 0x1001},
+		{1, "CAPTURECOMPLETE", uint16(packet.EventCodeCaptureComplete)},
+		{1, "0xc101", 0xC101},
+		{2, "BatteryLevel", uint16(packet.DevicePropCodeBatteryLevel)},
+		{2, "0xFFFF", 0xFFFF},
+		{3, "exifjpeg", uint16(packet.ObjectFormatCodeEXIFJPEG)},
+		{3, "0x0", 0},
+	}
+
+	for _, tt := range tests {
+		c := codes[tt.kind]
+		t.Run(c.kind+" "+tt.s, func(t *testing.T) {
+			got, err := c.parse(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect {
+				t.Errorf("got 0x%04X expected 0x%04X", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestParseCodeInvalid(t *testing.T) {
+	for _, c := range codes {
+		for _, s := range []string{"", "Foo", "1001", "0x", "0x10000", "0x-1", "0xG001", " 0x1001"} {
+			if v, err := c.parse(s); err == nil {
+				t.Errorf("%s %q: got 0x%04X expected error", c.kind, s, v)
+			}
+		}
+	}
+}
+
+func TestCodeRoundTrip(t *testing.T) {
+	// every code, named or not, parses from its String
+	for _, c := range codes {
+		for v := 0; v <= 0xFFFF; v++ {
+			s := c.str(uint16(v))
+			got, err := c.parse(s)
+			if err != nil {
+				t.Fatalf("%s %q: %v", c.kind, s, err)
+			}
+			if got != uint16(v) {
+				t.Fatalf("%s %q: got 0x%04X expected 0x%04X", c.kind, s, got, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package packet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+	"unicode/utf16"
+
+	"github.com/takurooo/binaryio"
+	"github.com/takurooo/swriter"
+)
+
+// DeviceInfo ...
+type DeviceInfo struct {
+	StandardVersion           uint16
+	VendorExtensionID         uint32
+	VendorExtensionVersion    uint16
+	VendorExtensionDesc       string
+	FunctionalMode            uint16
+	OperationsSupported       []uint16
+	EventsSupported           []uint16
+	DevicePropertiesSupported []uint16
+	CaptureFormats            []uint16
+	ImageFormats              []uint16
+	Manufacturer              string
+	Model                     string
+	DeviceVersion             string
+	SerialNumber              string
+}
+
+func (d DeviceInfo) String() string {
+	var s string
+	s += fmt.Sprintf("----------------\n")
+	s += fmt.Sprintf("DeviceInfo\n")
+	s += fmt.Sprintf("----------------\n")
+	s += fmt.Sprintf("StandardVersion  : 0x%04x\n", d.StandardVersion)
+	s += fmt.Sprintf("VendorExtID      : 0x%08x\n", d.VendorExtensionID)
+	s += fmt.Sprintf("VendorExtVersion : 0x%04x\n", d.VendorExtensionVersion)
+	s += fmt.Sprintf("VendorExtDesc    : %v\n", d.VendorExtensionDesc)
+	s += fmt.Sprintf("FunctionalMode   : 0x%04x\n", d.FunctionalMode)
+	s += fmt.Sprintf("Operations       : %d\n", len(d.OperationsSupported))
+	s += fmt.Sprintf("Events           : %d\n", len(d.EventsSupported))
+	s += fmt.Sprintf("DeviceProperties : %d\n", len(d.DevicePropertiesSupported))
+	s += fmt.Sprintf("CaptureFormats   : %d\n", len(d.CaptureFormats))
+	s += fmt.Sprintf("ImageFormats     : %d\n", len(d.ImageFormats))
+	s += fmt.Sprintf("Manufacturer     : %v\n", d.Manufacturer)
+	s += fmt.Sprintf("Model            : %v\n", d.Model)
+	s += fmt.Sprintf("DeviceVersion    : %v\n", d.DeviceVersion)
+	s += fmt.Sprintf("SerialNumber     : %v", d.SerialNumber)
+	return s
+}
+
+// ParseDeviceInfo decodes the DeviceInfo dataset returned by GetDeviceInfo.
+func ParseDeviceInfo(data []byte) (d *DeviceInfo, err error) {
+	br := newDatasetReader(data)
+
+	d = &DeviceInfo{}
+	d.StandardVersion = br.ReadU16(endian)
+	d.VendorExtensionID = br.ReadU32(endian)
+	d.VendorExtensionVersion = br.ReadU16(endian)
+	d.VendorExtensionDesc = br.readString()
+	d.FunctionalMode = br.ReadU16(endian)
+	d.OperationsSupported = br.readU16Array()
+	d.EventsSupported = br.readU16Array()
+	d.DevicePropertiesSupported = br.readU16Array()
+	d.CaptureFormats = br.readU16Array()
+	d.ImageFormats = br.readU16Array()
+	d.Manufacturer = br.readString()
+	d.Model = br.readString()
+	d.DeviceVersion = br.readString()
+	d.SerialNumber = br.readString()
+
+	if br.Err() != nil {
+		return nil, fmt.Errorf("invalid DeviceInfo: %v", br.Err())
+	}
+
+	return d, nil
+}
+
+// Storage Type
+const (
+	StorageTypeUndefined    uint16 = 0x0000
+	StorageTypeFixedROM     uint16 = 0x0001
+	StorageTypeRemovableROM uint16 = 0x0002
+	StorageTypeFixedRAM     uint16 = 0x0003
+	StorageTypeRemovableRAM uint16 = 0x0004
+)
+
+// Filesystem Type
+const (
+	FilesystemTypeUndefined           uint16 = 0x0000
+	FilesystemTypeGenericFlat         uint16 = 0x0001
+	FilesystemTypeGenericHierarchical uint16 = 0x0002
+	FilesystemTypeDCF                 uint16 = 0x0003
+)
+
+// Access Capability
+const (
+	AccessCapabilityReadWrite               uint16 = 0x0000
+	AccessCapabilityReadOnlyWithoutDeletion uint16 = 0x0001
+	AccessCapabilityReadOnlyWithDeletion    uint16 = 0x0002
+)
+
+// FreeSpaceInImagesUnused is the FreeSpaceInImages of storages that do not report it.
+const FreeSpaceInImagesUnused uint32 = 0xFFFFFFFF
+
+// StorageInfo ...
+type StorageInfo struct {
+	StorageType        uint16
+	FilesystemType     uint16
+	AccessCapability   uint16
+	MaxCapacity        uint64
+	FreeSpaceInBytes   uint64
+	FreeSpaceInImages  uint32
+	StorageDescription string
+	VolumeLabel        string
+}
+
+func (si StorageInfo) String() string {
+	var s string
+	s += fmt.Sprintf("----------------\n")
+	s += fmt.Sprintf("StorageInfo\n")
+	s += fmt.Sprintf("----------------\n")
+	s += fmt.Sprintf("StorageType        : 0x%04x\n", si.StorageType)
+	s += fmt.Sprintf("FilesystemType     : 0x%04x\n", si.FilesystemType)
+	s += fmt.Sprintf("AccessCapability   : 0x%04x\n", si.AccessCapability)
+	s += fmt.Sprintf("MaxCapacity        : %v\n", si.MaxCapacity)
+	s += fmt.Sprintf("FreeSpaceInBytes   : %v\n", si.FreeSpaceInBytes)
+	s += fmt.Sprintf("FreeSpaceInImages  : %v\n", si.FreeSpaceInImages)
+	s += fmt.Sprintf("StorageDescription : %v\n", si.StorageDescription)
+	s += fmt.Sprintf("VolumeLabel        : %v", si.VolumeLabel)
+	return s
+}
+
+// ParseStorageInfo decodes the StorageInfo dataset returned by GetStorageInfo.
+func ParseStorageInfo(data []byte) (si *StorageInfo, err error) {
+	br := newDatasetReader(data)
+
+	si = &StorageInfo{}
+	si.StorageType = br.ReadU16(endian)
+	si.FilesystemType = br.ReadU16(endian)
+	si.AccessCapability = br.ReadU16(endian)
+	si.MaxCapacity = br.ReadU64(endian)
+	si.FreeSpaceInBytes = br.ReadU64(endian)
+	si.FreeSpaceInImages = br.ReadU32(endian)
+	si.StorageDescription = br.readString()
+	si.VolumeLabel = br.readString()
+
+	if br.Err() != nil {
+		return nil, fmt.Errorf("invalid StorageInfo: %v", br.Err())
+	}
+
+	return si, nil
+}
+
+// datasetReader reads the PTP datatypes used in datasets on top of binaryio.Reader.
+type datasetReader struct {
+	*binaryio.Reader
+	size int
+	err  error
+}
+
+func newDatasetReader(data []byte) *datasetReader {
+	return &datasetReader{Reader: binaryio.NewReader(bytes.NewReader(data)), size: len(data)}
+}
+
+// Err ...
+func (dr *datasetReader) Err() error {
+	if dr.err != nil {
+		return dr.err
+	}
+	return dr.Reader.Err()
+}
+
+// readString reads a PTP string: a uint8 character count (including the
+// null terminator) followed by UTF-16LE code units.
+func (dr *datasetReader) readString() string {
+	numChars := dr.ReadU8()
+	if numChars == 0 {
+		return ""
+	}
+
+	chars := make([]uint16, 0, numChars)
+	for i := 0; i < int(numChars); i++ {
+		v := dr.ReadU16(endian)
+		if v == 0x0000 { // null terminated
+			continue
+		}
+		chars = append(chars, v)
+	}
+
+	return string(utf16.Decode(chars))
+}
+
+// readArrayLen reads the element count of a PTP array and checks it
+// against the dataset size, so that a corrupt count cannot force a huge allocation.
+func (dr *datasetReader) readArrayLen(elemSize int) int {
+	n := dr.ReadU32(endian)
+	if dr.Err() != nil {
+		return 0
+	}
+	if uint64(dr.size) < uint64(n)*uint64(elemSize) {
+		dr.err = fmt.Errorf("invalid array len %d", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (dr *datasetReader) readU16Array() []uint16 {
+	n := dr.readArrayLen(2)
+	if dr.Err() != nil {
+		return nil
+	}
+	a := make([]uint16, n)
+	for i := range a {
+		a[i] = dr.ReadU16(endian)
+	}
+	return a
+}
+
+// ObjectInfo ...
+type ObjectInfo struct {
+	StorageID            uint32
+	ObjectFormat         uint16
+	ProtectionStatus     uint16
+	ObjectCompressedSize uint32
+	ThumbFormat          uint16
+	ThumbCompressedSize  uint32
+	ThumbPixWidth        uint32
+	ThumbPixHeight       uint32
+	ImagePixWidth        uint32
+	ImagePixHeight       uint32
+	ImageBitDepth        uint32
+	ParentObject         uint32
+	AssociationType      uint16
+	AssociationDesc      uint32
+	SequenceNumber       uint32
+	Filename             string
+	// CaptureDate and ModificationDate are zero if not set by the device.
+	CaptureDate      time.Time
+	ModificationDate time.Time
+	Keywords         string
+}
+
+func (o ObjectInfo) String() string {
+	var s string
+	s += fmt.Sprintf("----------------\n")
+	s += fmt.Sprintf("ObjectInfo\n")
+	s += fmt.Sprintf("----------------\n")
+	s += fmt.Sprintf("StorageID        : 0x%08x\n", o.StorageID)
+	s += fmt.Sprintf("ObjectFormat     : 0x%04x\n", o.ObjectFormat)
+	s += fmt.Sprintf("ProtectionStatus : 0x%04x\n", o.ProtectionStatus)
+	s += fmt.Sprintf("CompressedSize   : %v\n", o.ObjectCompressedSize)
+	s += fmt.Sprintf("ThumbFormat      : 0x%04x\n", o.ThumbFormat)
+	s += fmt.Sprintf("ThumbSize        : %v\n", o.ThumbCompressedSize)
+	s += fmt.Sprintf("ThumbPix         : %vx%v\n", o.ThumbPixWidth, o.ThumbPixHeight)
+	s += fmt.Sprintf("ImagePix         : %vx%v\n", o.ImagePixWidth, o.ImagePixHeight)
+	s += fmt.Sprintf("ImageBitDepth    : %v\n", o.ImageBitDepth)
+	s += fmt.Sprintf("ParentObject     : 0x%08x\n", o.ParentObject)
+	s += fmt.Sprintf("AssociationType  : 0x%04x\n", o.AssociationType)
+	s += fmt.Sprintf("AssociationDesc  : 0x%08x\n", o.AssociationDesc)
+	s += fmt.Sprintf("SequenceNumber   : %v\n", o.SequenceNumber)
+	s += fmt.Sprintf("Filename         : %v\n", o.Filename)
+	s += fmt.Sprintf("CaptureDate      : %v\n", FormatDateTime(o.CaptureDate))
+	s += fmt.Sprintf("ModificationDate : %v\n", FormatDateTime(o.ModificationDate))
+	s += fmt.Sprintf("Keywords         : %v", o.Keywords)
+	return s
+}
+
+// ParseObjectInfo decodes the ObjectInfo dataset returned by GetObjectInfo.
+func ParseObjectInfo(data []byte) (o *ObjectInfo, err error) {
+	br := newDatasetReader(data)
+
+	o = &ObjectInfo{}
+	o.StorageID = br.ReadU32(endian)
+	o.ObjectFormat = br.ReadU16(endian)
+	o.ProtectionStatus = br.ReadU16(endian)
+	o.ObjectCompressedSize = br.ReadU32(endian)
+	o.ThumbFormat = br.ReadU16(endian)
+	o.ThumbCompressedSize = br.ReadU32(endian)
+	o.ThumbPixWidth = br.ReadU32(endian)
+	o.ThumbPixHeight = br.ReadU32(endian)
+	o.ImagePixWidth = br.ReadU32(endian)
+	o.ImagePixHeight = br.ReadU32(endian)
+	o.ImageBitDepth = br.ReadU32(endian)
+	o.ParentObject = br.ReadU32(endian)
+	o.AssociationType = br.ReadU16(endian)
+	o.AssociationDesc = br.ReadU32(endian)
+	o.SequenceNumber = br.ReadU32(endian)
+	o.Filename = br.readString()
+	captureDate := br.readString()
+	modificationDate := br.readString()
+	o.Keywords = br.readString()
+
+	if br.Err() != nil {
+		return nil, fmt.Errorf("invalid ObjectInfo: %v", br.Err())
+	}
+
+	// devices fill dates they do not track with garbage, leave them zero
+	o.CaptureDate, _ = ParseDateTime(captureDate)
+	o.ModificationDate, _ = ParseDateTime(modificationDate)
+
+	return o, nil
+}
+
+// MarshalObjectInfo encodes the ObjectInfo dataset sent with SendObjectInfo.
+func MarshalObjectInfo(o *ObjectInfo) (data []byte, err error) {
+	var strs [][]byte
+	for _, s := range []string{o.Filename, FormatDateTime(o.CaptureDate), FormatDateTime(o.ModificationDate), o.Keywords} {
+		b, err := encodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, b)
+	}
+
+	sw := swriter.New(64)
+	bw := binaryio.NewWriter(sw)
+
+	bw.WriteU32(o.StorageID, endian)
+	bw.WriteU16(o.ObjectFormat, endian)
+	bw.WriteU16(o.ProtectionStatus, endian)
+	bw.WriteU32(o.ObjectCompressedSize, endian)
+	bw.WriteU16(o.ThumbFormat, endian)
+	bw.WriteU32(o.ThumbCompressedSize, endian)
+	bw.WriteU32(o.ThumbPixWidth, endian)
+	bw.WriteU32(o.ThumbPixHeight, endian)
+	bw.WriteU32(o.ImagePixWidth, endian)
+	bw.WriteU32(o.ImagePixHeight, endian)
+	bw.WriteU32(o.ImageBitDepth, endian)
+	bw.WriteU32(o.ParentObject, endian)
+	bw.WriteU16(o.AssociationType, endian)
+	bw.WriteU32(o.AssociationDesc, endian)
+	bw.WriteU32(o.SequenceNumber, endian)
+	for _, b := range strs {
+		bw.WriteRaw(b)
+	}
+
+	if bw.Err() != nil {
+		return nil, bw.Err()
+	}
+
+	return sw.Bytes(), nil
+}
+
+// Form Flag
+const (
+	FormFlagNone        uint8 = 0x00
+	FormFlagRange       uint8 = 0x01
+	FormFlagEnumeration uint8 = 0x02
+)
+
+// DevicePropDesc ...
+type DevicePropDesc struct {
+	DevicePropCode uint16
+	DataType       uint16
+	GetSet         uint8
+	FactoryDefault interface{}
+	Current        interface{}
+	FormFlag       uint8
+
+	// FormFlagRange
+	Min  interface{}
+	Max  interface{}
+	Step interface{}
+
+	// FormFlagEnumeration
+	Values []interface{}
+}
+
+// ParseDevicePropDesc decodes the DevicePropDesc dataset returned by
+// GetDevicePropDesc. Values are decoded with DecodeValue.
+func ParseDevicePropDesc(data []byte) (d *DevicePropDesc, err error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("invalid DevicePropDesc len %d", len(data))
+	}
+
+	d = &DevicePropDesc{
+		DevicePropCode: binary.LittleEndian.Uint16(data[0:]),
+		DataType:       binary.LittleEndian.Uint16(data[2:]),
+		GetSet:         data[4],
+	}
+	off := 5
+
+	value := func() (v interface{}) {
+		if err != nil {
+			return nil
+		}
+		v, n, e := DecodeValue(data[off:], d.DataType)
+		err = e
+		off += n
+		return v
+	}
+
+	d.FactoryDefault = value()
+	d.Current = value()
+	if err != nil {
+		return nil, fmt.Errorf("invalid DevicePropDesc: %v", err)
+	}
+
+	// FormFlag is missing from some devices when there is no form
+	if off < len(data) {
+		d.FormFlag = data[off]
+		off++
+	}
+
+	switch d.FormFlag {
+	case FormFlagRange:
+		d.Min = value()
+		d.Max = value()
+		d.Step = value()
+	case FormFlagEnumeration:
+		if len(data) < off+2 {
+			return nil, fmt.Errorf("invalid DevicePropDesc enumeration len %d", len(data)-off)
+		}
+		n := int(binary.LittleEndian.Uint16(data[off:]))
+		off += 2
+		for i := 0; i < n && err == nil; i++ {
+			d.Values = append(d.Values, value())
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid DevicePropDesc: %v", err)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,365 @@
+package packet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf16"
+)
+
+// Data Type
+const (
+	DataTypeUndefined uint16 = 0x0000
+	DataTypeInt8      uint16 = 0x0001
+	DataTypeUInt8     uint16 = 0x0002
+	DataTypeInt16     uint16 = 0x0003
+	DataTypeUInt16    uint16 = 0x0004
+	DataTypeInt32     uint16 = 0x0005
+	DataTypeUInt32    uint16 = 0x0006
+	DataTypeInt64     uint16 = 0x0007
+	DataTypeUInt64    uint16 = 0x0008
+	DataTypeInt128    uint16 = 0x0009
+	DataTypeUInt128   uint16 = 0x000A
+	DataTypeArray     uint16 = 0x4000 // DataTypeArray | element type
+	DataTypeString    uint16 = 0xFFFF
+)
+
+var dataTypeNames = map[uint16]string{
+	DataTypeUndefined: "UNDEF",
+	DataTypeInt8:      "INT8",
+	DataTypeUInt8:     "UINT8",
+	DataTypeInt16:     "INT16",
+	DataTypeUInt16:    "UINT16",
+	DataTypeInt32:     "INT32",
+	DataTypeUInt32:    "UINT32",
+	DataTypeInt64:     "INT64",
+	DataTypeUInt64:    "UINT64",
+	DataTypeInt128:    "INT128",
+	DataTypeUInt128:   "UINT128",
+	DataTypeString:    "STR",
+}
+
+// DataTypeName returns the name of a datatype as in the PTP specification,
+// e.g. "UINT16", "AUINT16" for an array of it and "STR", or its hex value
+// if unknown.
+func DataTypeName(dataType uint16) string {
+	if name, ok := dataTypeNames[dataType]; ok {
+		return name
+	}
+	if dataType&DataTypeArray != 0 {
+		if name, ok := dataTypeNames[dataType&^DataTypeArray]; ok && dataType != DataTypeString {
+			return "A" + name
+		}
+	}
+	return fmt.Sprintf("0x%04X", dataType)
+}
+
+// ParseDataType returns the datatype of a name returned by DataTypeName, ignoring case.
+func ParseDataType(s string) (dataType uint16, err error) {
+	upper := strings.ToUpper(s)
+	for code, name := range dataTypeNames {
+		if upper == name {
+			return code, nil
+		}
+		if upper == "A"+name && code != DataTypeString && code != DataTypeUndefined {
+			return DataTypeArray | code, nil
+		}
+	}
+	v, err := parseCode(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid data type %q", s)
+	}
+	return v, nil
+}
+
+// DataTypeSize returns the size of a scalar datatype, or 0 for arrays, strings and unknown types.
+func DataTypeSize(dataType uint16) int {
+	switch dataType {
+	case DataTypeInt8, DataTypeUInt8:
+		return 1
+	case DataTypeInt16, DataTypeUInt16:
+		return 2
+	case DataTypeInt32, DataTypeUInt32:
+		return 4
+	case DataTypeInt64, DataTypeUInt64:
+		return 8
+	case DataTypeInt128, DataTypeUInt128:
+		return 16
+	}
+	return 0
+}
+
+// DecodeValue decodes a value of dataType at the start of data and returns
+// it with the number of bytes consumed. Scalars are returned as the Go
+// integer type of the same size (128 bit values as a 16 byte []byte),
+// strings as string and arrays as a slice of the element type.
+func DecodeValue(data []byte, dataType uint16) (v interface{}, n int, err error) {
+	le := binary.LittleEndian
+
+	if dataType == DataTypeString {
+		return decodeString(data)
+	}
+
+	if dataType&DataTypeArray != 0 {
+		return decodeArray(data, dataType&^DataTypeArray)
+	}
+
+	size := DataTypeSize(dataType)
+	if size == 0 {
+		return nil, 0, fmt.Errorf("unknown data type 0x%04x", dataType)
+	}
+	if len(data) < size {
+		return nil, 0, fmt.Errorf("invalid value len %d for data type 0x%04x", len(data), dataType)
+	}
+
+	switch dataType {
+	case DataTypeInt8:
+		v = int8(data[0])
+	case DataTypeUInt8:
+		v = data[0]
+	case DataTypeInt16:
+		v = int16(le.Uint16(data))
+	case DataTypeUInt16:
+		v = le.Uint16(data)
+	case DataTypeInt32:
+		v = int32(le.Uint32(data))
+	case DataTypeUInt32:
+		v = le.Uint32(data)
+	case DataTypeInt64:
+		v = int64(le.Uint64(data))
+	case DataTypeUInt64:
+		v = le.Uint64(data)
+	default: // 128 bit
+		b := make([]byte, size)
+		copy(b, data)
+		v = b
+	}
+
+	return v, size, nil
+}
+
+func decodeString(data []byte) (v interface{}, n int, err error) {
+	if len(data) < 1 {
+		return nil, 0, fmt.Errorf("invalid string len %d", len(data))
+	}
+
+	numChars := int(data[0])
+	n = 1 + numChars*2
+	if len(data) < n {
+		return nil, 0, fmt.Errorf("invalid string len %d expected %d", len(data), n)
+	}
+
+	chars := make([]uint16, 0, numChars)
+	for i := 0; i < numChars; i++ {
+		c := binary.LittleEndian.Uint16(data[1+i*2:])
+		if c == 0x0000 { // null terminated
+			continue
+		}
+		chars = append(chars, c)
+	}
+
+	return string(utf16.Decode(chars)), n, nil
+}
+
+func decodeArray(data []byte, elemType uint16) (v interface{}, n int, err error) {
+	size := DataTypeSize(elemType)
+	if size == 0 {
+		return nil, 0, fmt.Errorf("unknown array element type 0x%04x", elemType)
+	}
+	if len(data) < 4 {
+		return nil, 0, fmt.Errorf("invalid array len %d", len(data))
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(count)*uint64(size) {
+		return nil, 0, fmt.Errorf("invalid array count %d", count)
+	}
+
+	le := binary.LittleEndian
+	b := data[4:]
+	switch elemType {
+	case DataTypeInt8:
+		a := make([]int8, count)
+		for i := range a {
+			a[i] = int8(b[i])
+		}
+		v = a
+	case DataTypeUInt8:
+		a := make([]uint8, count)
+		copy(a, b)
+		v = a
+	case DataTypeInt16:
+		a := make([]int16, count)
+		for i := range a {
+			a[i] = int16(le.Uint16(b[i*2:]))
+		}
+		v = a
+	case DataTypeUInt16:
+		a := make([]uint16, count)
+		for i := range a {
+			a[i] = le.Uint16(b[i*2:])
+		}
+		v = a
+	case DataTypeInt32:
+		a := make([]int32, count)
+		for i := range a {
+			a[i] = int32(le.Uint32(b[i*4:]))
+		}
+		v = a
+	case DataTypeUInt32:
+		a := make([]uint32, count)
+		for i := range a {
+			a[i] = le.Uint32(b[i*4:])
+		}
+		v = a
+	case DataTypeInt64:
+		a := make([]int64, count)
+		for i := range a {
+			a[i] = int64(le.Uint64(b[i*8:]))
+		}
+		v = a
+	case DataTypeUInt64:
+		a := make([]uint64, count)
+		for i := range a {
+			a[i] = le.Uint64(b[i*8:])
+		}
+		v = a
+	default: // 128 bit
+		a := make([][]byte, count)
+		for i := range a {
+			a[i] = append([]byte(nil), b[i*16:i*16+16]...)
+		}
+		v = a
+	}
+
+	n = 4 + int(count)*size
+	return v, n, nil
+}
+
+// EncodeValue encodes v as dataType, the inverse of DecodeValue. Integer
+// values of any Go integer type are accepted for scalar types and must be in
+// the range of the type; 128 bit values are a 16 byte []byte. Arrays are a
+// slice of such values, strings a string.
+func EncodeValue(v interface{}, dataType uint16) (data []byte, err error) {
+	if dataType == DataTypeString {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %T for data type 0x%04x", v, dataType)
+		}
+		return encodeString(s)
+	}
+
+	if dataType&DataTypeArray != 0 {
+		return encodeArray(v, dataType&^DataTypeArray)
+	}
+	return encodeScalar(v, dataType)
+}
+
+func encodeScalar(v interface{}, dataType uint16) (data []byte, err error) {
+	size := DataTypeSize(dataType)
+	if size == 0 {
+		return nil, fmt.Errorf("unsupported data type 0x%04x", dataType)
+	}
+
+	if size == 16 {
+		b, ok := v.([]byte)
+		if !ok || len(b) != size {
+			return nil, fmt.Errorf("invalid value %T for data type 0x%04x", v, dataType)
+		}
+		return append([]byte(nil), b...), nil
+	}
+
+	// u is the two's complement of negative values
+	var u uint64
+	var neg bool
+	switch x := v.(type) {
+	case int:
+		u, neg = uint64(x), x < 0
+	case int8:
+		u, neg = uint64(x), x < 0
+	case int16:
+		u, neg = uint64(x), x < 0
+	case int32:
+		u, neg = uint64(x), x < 0
+	case int64:
+		u, neg = uint64(x), x < 0
+	case uint:
+		u = uint64(x)
+	case uint8:
+		u = uint64(x)
+	case uint16:
+		u = uint64(x)
+	case uint32:
+		u = uint64(x)
+	case uint64:
+		u = x
+	default:
+		return nil, fmt.Errorf("invalid value %T for data type 0x%04x", v, dataType)
+	}
+	if !inRange(u, neg, dataType) {
+		return nil, fmt.Errorf("value %v out of range for data type %s", v, DataTypeName(dataType))
+	}
+
+	data = make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, u)
+	return data[:size], nil
+}
+
+// inRange reports whether the integer u, negative if neg, fits dataType.
+func inRange(u uint64, neg bool, dataType uint16) bool {
+	bits := uint(DataTypeSize(dataType) * 8)
+	switch dataType {
+	case DataTypeInt8, DataTypeInt16, DataTypeInt32, DataTypeInt64:
+		if neg {
+			return bits == 64 || -(int64(1)<<(bits-1)) <= int64(u)
+		}
+		return u < uint64(1)<<(bits-1)
+	}
+	if neg {
+		return false
+	}
+	return bits == 64 || u < uint64(1)<<bits
+}
+
+// encodeArray encodes a slice of values as an array of elemType.
+func encodeArray(v interface{}, elemType uint16) (data []byte, err error) {
+	size := DataTypeSize(elemType)
+	if size == 0 {
+		return nil, fmt.Errorf("unknown array element type 0x%04x", elemType)
+	}
+
+	a := reflect.ValueOf(v)
+	if a.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("invalid value %T for data type 0x%04x", v, DataTypeArray|elemType)
+	}
+
+	data = make([]byte, 4, 4+a.Len()*size)
+	binary.LittleEndian.PutUint32(data, uint32(a.Len()))
+	for i := 0; i < a.Len(); i++ {
+		b, err := encodeScalar(a.Index(i).Interface(), elemType)
+		if err != nil {
+			return nil, fmt.Errorf("array element %d: %v", i, err)
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func encodeString(s string) (data []byte, err error) {
+	if s == "" {
+		return []byte{0}, nil
+	}
+
+	chars := append(utf16.Encode([]rune(s)), 0x0000)
+	if 255 < len(chars) {
+		return nil, fmt.Errorf("invalid string len %d", len(chars))
+	}
+
+	data = make([]byte, 1+len(chars)*2)
+	data[0] = byte(len(chars))
+	for i, c := range chars {
+		binary.LittleEndian.PutUint16(data[1+i*2:], c)
+	}
+	return data, nil
+}
@@ -0,0 +1,92 @@
+package packet_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        interface{}
+		dataType uint16
+		expect   []byte
+	}{
+		{"UINT8 max", 255, packet.DataTypeUInt8, []byte{0xFF}},
+		{"INT8 min", -128, packet.DataTypeInt8, []byte{0x80}},
+		{"INT16 from uint8", uint8(200), packet.DataTypeInt16, []byte{0xC8, 0x00}},
+		{"UINT64 max", ^uint64(0), packet.DataTypeUInt64, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"INT64 min", int64(-1 << 63), packet.DataTypeInt64, []byte{0, 0, 0, 0, 0, 0, 0, 0x80}},
+		{"UINT128", make([]byte, 16), packet.DataTypeUInt128, make([]byte, 16)},
+		{"AUINT16", []uint16{1, 2}, packet.DataTypeArray | packet.DataTypeUInt16, []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00}},
+		{"AINT8 from int", []int{-1}, packet.DataTypeArray | packet.DataTypeInt8, []byte{0x01, 0x00, 0x00, 0x00, 0xFF}},
+		{"empty AUINT32", []uint32{}, packet.DataTypeArray | packet.DataTypeUInt32, []byte{0x00, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := packet.EncodeValue(tt.v, tt.dataType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, tt.expect) {
+				t.Errorf("got % x expected % x", data, tt.expect)
+			}
+		})
+	}
+}
+
+func TestEncodeValueInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        interface{}
+		dataType uint16
+	}{
+		{"UINT8 overflow", 300, packet.DataTypeUInt8},
+		{"UINT16 negative", -1, packet.DataTypeUInt16},
+		{"UINT64 negative", int64(-1), packet.DataTypeUInt64},
+		{"INT8 overflow", 128, packet.DataTypeInt8},
+		{"INT8 underflow", -129, packet.DataTypeInt8},
+		{"INT64 overflow", uint64(1 << 63), packet.DataTypeInt64},
+		{"UINT128 short", make([]byte, 8), packet.DataTypeUInt128},
+		{"string for UINT8", "1", packet.DataTypeUInt8},
+		{"array element overflow", []int{1, 256}, packet.DataTypeArray | packet.DataTypeUInt8},
+		{"scalar for array", 1, packet.DataTypeArray | packet.DataTypeUInt8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if data, err := packet.EncodeValue(tt.v, tt.dataType); err == nil {
+				t.Errorf("got % x expected error", data)
+			}
+		})
+	}
+}
+
+func TestEncodeValueArrayRoundTrip(t *testing.T) {
+	values := []interface{}{
+		[]int8{-1, 0, 1},
+		[]uint16{0x5001, 0x5003},
+		[]int64{-1 << 63, 1<<63 - 1},
+		[][]byte{make([]byte, 16), bytes.Repeat([]byte{0xFF}, 16)},
+	}
+	dataTypes := []uint16{packet.DataTypeInt8, packet.DataTypeUInt16, packet.DataTypeInt64, packet.DataTypeUInt128}
+
+	for i, v := range values {
+		dataType := packet.DataTypeArray | dataTypes[i]
+		data, err := packet.EncodeValue(v, dataType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, n, err := packet.DecodeValue(data, dataType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(data) || !reflect.DeepEqual(got, v) {
+			t.Errorf("%s: got %v (%d bytes) expected %v", packet.DataTypeName(dataType), got, n, v)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package packet
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateTimeLayout is the PTP DateTime string without tenths and time zone
+const dateTimeLayout = "20060102T150405"
+
+// ParseDateTime parses a PTP DateTime string "YYYYMMDDThhmmss.s" where the
+// tenths of a second are optional and may be followed by "Z" for UTC or a
+// "+hhmm"/"-hhmm" offset. Times without time zone are the local time of the
+// device and returned in time.Local. The empty string is the zero Time.
+func ParseDateTime(s string) (t time.Time, err error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if len(s) < len(dateTimeLayout) {
+		return time.Time{}, fmt.Errorf("invalid DateTime %q", s)
+	}
+
+	rest := s[len(dateTimeLayout):]
+	var tenths int
+	if len(rest) >= 2 && rest[0] == '.' && '0' <= rest[1] && rest[1] <= '9' {
+		tenths = int(rest[1] - '0')
+		rest = rest[2:]
+	}
+
+	loc := time.Local
+	switch {
+	case rest == "":
+	case rest == "Z":
+		loc = time.UTC
+	case len(rest) == 5 && (rest[0] == '+' || rest[0] == '-') && isDigits(rest[1:]):
+		hh := int(rest[1]-'0')*10 + int(rest[2]-'0')
+		mm := int(rest[3]-'0')*10 + int(rest[4]-'0')
+		offset := (hh*60 + mm) * 60
+		if rest[0] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	default:
+		return time.Time{}, fmt.Errorf("invalid DateTime %q", s)
+	}
+
+	t, err = time.ParseInLocation(dateTimeLayout, s[:len(dateTimeLayout)], loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid DateTime %q", s)
+	}
+	return t.Add(time.Duration(tenths) * 100 * time.Millisecond), nil
+}
+
+// isDigits reports whether s consists of ASCII digits only.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || '9' < s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatDateTime formats t as PTP DateTime string. Tenths of a second are
+// included if not zero. Times in time.Local are written without time zone,
+// times in UTC with "Z" and others with their offset. The zero Time is the
+// empty string.
+func FormatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	s := t.Format(dateTimeLayout)
+	if tenths := t.Nanosecond() / int(100*time.Millisecond); tenths != 0 {
+		s += fmt.Sprintf(".%d", tenths)
+	}
+
+	switch t.Location() {
+	case time.Local:
+	case time.UTC:
+		s += "Z"
+	default:
+		s += t.Format("-0700")
+	}
+	return s
+}
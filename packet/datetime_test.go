@@ -0,0 +1,97 @@
+package packet_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+func TestParseDateTime(t *testing.T) {
+	jst := time.FixedZone("", 9*3600)
+	west := time.FixedZone("", -(1*3600 + 30*60))
+
+	tests := []struct {
+		s      string
+		expect time.Time
+	}{
+		{"", time.Time{}},
+		{"20200906T093630", time.Date(2020, 9, 6, 9, 36, 30, 0, time.Local)},
+		{"20200906T093630Z", time.Date(2020, 9, 6, 9, 36, 30, 0, time.UTC)},
+		{"20200906T093630.5", time.Date(2020, 9, 6, 9, 36, 30, 5e8, time.Local)},
+		{"20200906T093630.5Z", time.Date(2020, 9, 6, 9, 36, 30, 5e8, time.UTC)},
+		{"20200906T093630+0900", time.Date(2020, 9, 6, 9, 36, 30, 0, jst)},
+		{"20200906T093630.9-0130", time.Date(2020, 9, 6, 9, 36, 30, 9e8, west)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := packet.ParseDateTime(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.expect) {
+				t.Errorf("got %v expected %v", got, tt.expect)
+			}
+			_, gotOffset := got.Zone()
+			_, expectOffset := tt.expect.Zone()
+			if gotOffset != expectOffset || (got.Location() == time.Local) != (tt.expect.Location() == time.Local) {
+				t.Errorf("got location %v expected %v", got.Location(), tt.expect.Location())
+			}
+		})
+	}
+}
+
+func TestParseDateTimeInvalid(t *testing.T) {
+	for _, s := range []string{
+		"2020",
+		"20200906 093630",
+		"20201306T093630",
+		"20200906T093630X",
+		"20200906T093630.",
+		"20200906T093630.Z",
+		"20200906T093630.55",
+		"20200906T093630+09",
+		"20200906T093630+09000",
+		"20200906T093630++100",
+		"20200906T093630+-100",
+		"20200906T093630+0-10",
+		"20200906T093630+ 900",
+	} {
+		if got, err := packet.ParseDateTime(s); err == nil {
+			t.Errorf("%q: got %v expected error", s, got)
+		}
+	}
+}
+
+func TestFormatDateTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		t      time.Time
+		expect string
+	}{
+		{"zero", time.Time{}, ""},
+		{"local", time.Date(2020, 9, 6, 9, 36, 30, 0, time.Local), "20200906T093630"},
+		{"UTC", time.Date(2020, 9, 6, 9, 36, 30, 0, time.UTC), "20200906T093630Z"},
+		{"tenths", time.Date(2020, 9, 6, 9, 36, 30, 5e8, time.UTC), "20200906T093630.5Z"},
+		{"below tenths", time.Date(2020, 9, 6, 9, 36, 30, 99e6, time.UTC), "20200906T093630Z"},
+		{"east", time.Date(2020, 9, 6, 9, 36, 30, 0, time.FixedZone("JST", 9*3600)), "20200906T093630+0900"},
+		{"west", time.Date(2020, 9, 6, 9, 36, 30, 1e8, time.FixedZone("", -(1*3600+30*60))), "20200906T093630.1-0130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := packet.FormatDateTime(tt.t)
+			if s != tt.expect {
+				t.Fatalf("got %q expected %q", s, tt.expect)
+			}
+			got, err := packet.ParseDateTime(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expect := tt.t.Truncate(100 * time.Millisecond); !got.Equal(expect) {
+				t.Errorf("parsed %v expected %v", got, expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,696 @@
+package packet
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// Packet is a PTP-IP packet. MarshalBinary encodes the whole packet,
+// header included, and UnmarshalBinary decodes one, copying what it keeps.
+// Packet is implemented by the packet types of this package.
+type Packet interface {
+	PacketType() uint32
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+
+	// appendBody appends the encoded body to b.
+	appendBody(b []byte) ([]byte, error)
+	// parseBody decodes body, which may be retained.
+	parseBody(body []byte) error
+}
+
+// newPacket returns an empty packet of packetType, or nil if it is unknown.
+func newPacket(packetType uint32) Packet {
+	switch packetType {
+	case PacketTypeInitCommandRequest:
+		return &InitCommandRequestPacket{}
+	case PacketTypeInitCommandAck:
+		return &InitCommandAckPacket{}
+	case PacketTypeInitEventRequest:
+		return &InitEventRequestPacket{}
+	case PacketTypeInitEventAck:
+		return &InitEventAckPacket{}
+	case PacketTypeInitFail:
+		return &InitFailPacket{}
+	case PacketTypeOperationRequest:
+		return &OperationRequestPacket{}
+	case PacketTypeOperationResponse:
+		return &OperationResponsePacket{}
+	case PacketTypeEvent:
+		return &EventPacket{}
+	case PacketTypeStartData:
+		return &StartDataPacket{}
+	case PacketTypeData:
+		return &DataPacket{}
+	case PacketTypeCancel:
+		return &CancelPacket{}
+	case PacketTypeEndData:
+		return &EndDataPacket{}
+	case PacketTypeProbeRequest:
+		return &ProbeRequestPacket{}
+	case PacketTypeProbeResponse:
+		return &ProbeResponsePacket{}
+	}
+	return nil
+}
+
+// appendPacket appends the header and body of p to b.
+func appendPacket(b []byte, p Packet) ([]byte, error) {
+	start := len(b)
+	b = append(b, make([]byte, packetHeaderSize)...)
+	b, err := p.appendBody(b)
+	if err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(b[start:], uint32(len(b)-start))
+	binary.LittleEndian.PutUint32(b[start+4:], p.PacketType())
+	return b, nil
+}
+
+func marshalPacket(p Packet) ([]byte, error) {
+	return appendPacket(nil, p)
+}
+
+func unmarshalPacket(p Packet, data []byte) error {
+	if len(data) < int(packetHeaderSize) {
+		return &ProtocolError{PacketType: p.PacketType(), Reason: fmt.Sprintf("invalid packet len 0x%x", len(data))}
+	}
+	packetLen := binary.LittleEndian.Uint32(data[0:])
+	packetType := binary.LittleEndian.Uint32(data[4:])
+	if packetType != p.PacketType() {
+		return &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("expected 0x%08x", p.PacketType())}
+	}
+	if packetLen != uint32(len(data)) {
+		return &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("invalid packet len 0x%x expected 0x%x", packetLen, len(data))}
+	}
+
+	body := make([]byte, len(data)-int(packetHeaderSize))
+	copy(body, data[packetHeaderSize:])
+	return p.parseBody(body)
+}
+
+func invalidBodyLen(packetType uint32, body []byte) error {
+	return &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("invalid body len 0x%x", len(body))}
+}
+
+func appendU16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendU32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendU64(b []byte, v uint64) []byte {
+	return appendU32(appendU32(b, uint32(v)), uint32(v>>32))
+}
+
+// encodeFriendlyName encodes s as null terminated UTF-16LE.
+func encodeFriendlyName(s string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = appendU16(b, c)
+	}
+	return appendU16(b, 0)
+}
+
+// decodeFriendlyName decodes a null terminated UTF-16LE name at the start of
+// b and returns the number of bytes consumed. A name without terminator ends
+// at the end of b.
+func decodeFriendlyName(b []byte) (name string, n int) {
+	var chars []uint16
+	for ; n+2 <= len(b); n += 2 {
+		c := binary.LittleEndian.Uint16(b[n:])
+		if c == 0x0000 { // null terminated
+			n += 2
+			break
+		}
+		chars = append(chars, c)
+	}
+	return string(utf16.Decode(chars)), n
+}
+
+// PacketType ...
+func (p *InitCommandRequestPacket) PacketType() uint32 {
+	return PacketTypeInitCommandRequest
+}
+
+// MarshalBinary ...
+func (p *InitCommandRequestPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *InitCommandRequestPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *InitCommandRequestPacket) appendBody(b []byte) ([]byte, error) {
+	if 16 < len(p.GUID) {
+		return nil, fmt.Errorf("invalid initiator GUID len 16 < %d", len(p.GUID))
+	}
+	name := encodeFriendlyName(p.FriendlyName)
+	if 40 < len(name) {
+		return nil, fmt.Errorf("invalid initiator FriendlyName len 40 < %d", len(name))
+	}
+
+	b = append(b, p.GUID...)
+	b = append(b, name...)
+	b = appendU32(b, p.ProtocolVersion)
+	return b, nil
+}
+
+func (p *InitCommandRequestPacket) parseBody(body []byte) error {
+	// GUID(16) FriendlyName(2*n) ProtocolVersion(4)
+	if len(body) < 16 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.GUID = append([]byte(nil), body[:16]...)
+	name, n := decodeFriendlyName(body[16:])
+	p.FriendlyName = name
+	rest := body[16+n:]
+	if len(rest) < 4 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.ProtocolVersion = binary.LittleEndian.Uint32(rest)
+	return nil
+}
+
+// PacketType ...
+func (p *InitCommandAckPacket) PacketType() uint32 {
+	return PacketTypeInitCommandAck
+}
+
+// MarshalBinary ...
+func (p *InitCommandAckPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *InitCommandAckPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *InitCommandAckPacket) appendBody(b []byte) ([]byte, error) {
+	if 16 < len(p.GUID) {
+		return nil, fmt.Errorf("invalid responder GUID len 16 < %d", len(p.GUID))
+	}
+	name := encodeFriendlyName(p.FriendlyName)
+	if 40 < len(name) {
+		return nil, fmt.Errorf("invalid responder FriendlyName len 40 < %d", len(name))
+	}
+
+	b = appendU32(b, p.ConnectionNumber)
+	b = append(b, p.GUID...)
+	b = append(b, name...)
+	b = appendU32(b, p.ProtocolVersion)
+	return b, nil
+}
+
+func (p *InitCommandAckPacket) parseBody(body []byte) error {
+	// ConnectionNumber(4) GUID(16) FriendlyName(2*n) ProtocolVersion(4)
+	if len(body) < 20 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.ConnectionNumber = binary.LittleEndian.Uint32(body)
+	p.GUID = append([]byte(nil), body[4:20]...)
+	name, n := decodeFriendlyName(body[20:])
+	p.FriendlyName = name
+	rest := body[20+n:]
+	if len(rest) < 4 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.ProtocolVersion = binary.LittleEndian.Uint32(rest)
+	return nil
+}
+
+// PacketType ...
+func (p *InitEventRequestPacket) PacketType() uint32 {
+	return PacketTypeInitEventRequest
+}
+
+// MarshalBinary ...
+func (p *InitEventRequestPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *InitEventRequestPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *InitEventRequestPacket) appendBody(b []byte) ([]byte, error) {
+	return appendU32(b, p.ConnectionNumber), nil
+}
+
+func (p *InitEventRequestPacket) parseBody(body []byte) error {
+	if len(body) < 4 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.ConnectionNumber = binary.LittleEndian.Uint32(body)
+	return nil
+}
+
+// PacketType ...
+func (p *InitEventAckPacket) PacketType() uint32 {
+	return PacketTypeInitEventAck
+}
+
+// MarshalBinary ...
+func (p *InitEventAckPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *InitEventAckPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *InitEventAckPacket) appendBody(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (p *InitEventAckPacket) parseBody(body []byte) error {
+	return nil
+}
+
+// PacketType ...
+func (p *InitFailPacket) PacketType() uint32 {
+	return PacketTypeInitFail
+}
+
+// MarshalBinary ...
+func (p *InitFailPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *InitFailPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *InitFailPacket) appendBody(b []byte) ([]byte, error) {
+	return appendU32(b, p.Reason), nil
+}
+
+func (p *InitFailPacket) parseBody(body []byte) error {
+	if len(body) < 4 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.Reason = binary.LittleEndian.Uint32(body)
+	return nil
+}
+
+// PacketType ...
+func (p *OperationRequestPacket) PacketType() uint32 {
+	return PacketTypeOperationRequest
+}
+
+// MarshalBinary ...
+func (p *OperationRequestPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *OperationRequestPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *OperationRequestPacket) appendBody(b []byte) ([]byte, error) {
+	b = appendU32(b, p.DataPhaseInfo)
+	b = appendU16(b, p.OperationCode)
+	b = appendU32(b, p.TransactionID)
+	b = appendU32(b, p.P1)
+	b = appendU32(b, p.P2)
+	b = appendU32(b, p.P3)
+	b = appendU32(b, p.P4)
+	if p.P5 != 0 {
+		b = appendU32(b, p.P5)
+	}
+	return b, nil
+}
+
+func (p *OperationRequestPacket) parseBody(body []byte) error {
+	// DataPhaseInfo(4) OperationCode(2) TransactionID(4) Parameter(4)*n
+	if len(body) < 10 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+
+	// missing parameters are zero
+	b := make([]byte, 30)
+	copy(b, body)
+
+	le := binary.LittleEndian
+	p.DataPhaseInfo = le.Uint32(b[0:])
+	p.OperationCode = le.Uint16(b[4:])
+	p.TransactionID = le.Uint32(b[6:])
+	p.P1 = le.Uint32(b[10:])
+	p.P2 = le.Uint32(b[14:])
+	p.P3 = le.Uint32(b[18:])
+	p.P4 = le.Uint32(b[22:])
+	p.P5 = le.Uint32(b[26:])
+	return nil
+}
+
+// PacketType ...
+func (p *OperationResponsePacket) PacketType() uint32 {
+	return PacketTypeOperationResponse
+}
+
+// MarshalBinary ...
+func (p *OperationResponsePacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *OperationResponsePacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *OperationResponsePacket) appendBody(b []byte) ([]byte, error) {
+	b = appendU16(b, p.ResponseCode)
+	b = appendU32(b, p.TransactionID)
+	b = appendU32(b, p.P1)
+	b = appendU32(b, p.P2)
+	b = appendU32(b, p.P3)
+	b = appendU32(b, p.P4)
+	return b, nil
+}
+
+func (p *OperationResponsePacket) parseBody(body []byte) error {
+	// ResponseCode(2) TransactionID(4) Parameter(4)*n
+	if len(body) < 6 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+
+	// missing parameters are zero
+	b := make([]byte, 22)
+	copy(b, body)
+
+	le := binary.LittleEndian
+	p.ResponseCode = le.Uint16(b[0:])
+	p.TransactionID = le.Uint32(b[2:])
+	p.P1 = le.Uint32(b[6:])
+	p.P2 = le.Uint32(b[10:])
+	p.P3 = le.Uint32(b[14:])
+	p.P4 = le.Uint32(b[18:])
+	return nil
+}
+
+// PacketType ...
+func (p *EventPacket) PacketType() uint32 {
+	return PacketTypeEvent
+}
+
+// MarshalBinary ...
+func (p *EventPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *EventPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *EventPacket) appendBody(b []byte) ([]byte, error) {
+	b = appendU16(b, p.EventCode)
+	b = appendU32(b, p.TransactionID)
+	b = appendU32(b, p.P1)
+	b = appendU32(b, p.P2)
+	b = appendU32(b, p.P3)
+	return b, nil
+}
+
+func (p *EventPacket) parseBody(body []byte) error {
+	// EventCode(2) TransactionID(4) Parameter(4)*n
+	if len(body) < 6 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+
+	// missing parameters are zero
+	b := make([]byte, 18)
+	copy(b, body)
+
+	le := binary.LittleEndian
+	p.EventCode = le.Uint16(b[0:])
+	p.TransactionID = le.Uint32(b[2:])
+	p.P1 = le.Uint32(b[6:])
+	p.P2 = le.Uint32(b[10:])
+	p.P3 = le.Uint32(b[14:])
+	return nil
+}
+
+// PacketType ...
+func (p *StartDataPacket) PacketType() uint32 {
+	return PacketTypeStartData
+}
+
+// MarshalBinary ...
+func (p *StartDataPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *StartDataPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *StartDataPacket) appendBody(b []byte) ([]byte, error) {
+	b = appendU32(b, p.TransactionID)
+	b = appendU64(b, p.TotalDataLength)
+	return b, nil
+}
+
+func (p *StartDataPacket) parseBody(body []byte) error {
+	if len(body) != 12 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.TransactionID = binary.LittleEndian.Uint32(body[0:])
+	p.TotalDataLength = binary.LittleEndian.Uint64(body[4:])
+	return nil
+}
+
+// PacketType ...
+func (p *DataPacket) PacketType() uint32 {
+	return PacketTypeData
+}
+
+// MarshalBinary ...
+func (p *DataPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *DataPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *DataPacket) appendBody(b []byte) ([]byte, error) {
+	b = appendU32(b, p.TransactionID)
+	b = append(b, p.Payload...)
+	return b, nil
+}
+
+func (p *DataPacket) parseBody(body []byte) error {
+	if len(body) < 4 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.TransactionID = binary.LittleEndian.Uint32(body)
+	p.Payload = body[4:]
+	return nil
+}
+
+// PacketType ...
+func (p *CancelPacket) PacketType() uint32 {
+	return PacketTypeCancel
+}
+
+// MarshalBinary ...
+func (p *CancelPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *CancelPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *CancelPacket) appendBody(b []byte) ([]byte, error) {
+	return appendU32(b, p.TransactionID), nil
+}
+
+func (p *CancelPacket) parseBody(body []byte) error {
+	if len(body) < 4 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.TransactionID = binary.LittleEndian.Uint32(body)
+	return nil
+}
+
+// PacketType ...
+func (p *EndDataPacket) PacketType() uint32 {
+	return PacketTypeEndData
+}
+
+// MarshalBinary ...
+func (p *EndDataPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *EndDataPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *EndDataPacket) appendBody(b []byte) ([]byte, error) {
+	b = appendU32(b, p.TransactionID)
+	b = append(b, p.Payload...)
+	return b, nil
+}
+
+func (p *EndDataPacket) parseBody(body []byte) error {
+	if len(body) < 4 {
+		return invalidBodyLen(p.PacketType(), body)
+	}
+	p.TransactionID = binary.LittleEndian.Uint32(body)
+	p.Payload = body[4:]
+	return nil
+}
+
+// PacketType ...
+func (p *ProbeRequestPacket) PacketType() uint32 {
+	return PacketTypeProbeRequest
+}
+
+// MarshalBinary ...
+func (p *ProbeRequestPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *ProbeRequestPacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *ProbeRequestPacket) appendBody(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (p *ProbeRequestPacket) parseBody(body []byte) error {
+	return nil
+}
+
+// PacketType ...
+func (p *ProbeResponsePacket) PacketType() uint32 {
+	return PacketTypeProbeResponse
+}
+
+// MarshalBinary ...
+func (p *ProbeResponsePacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary ...
+func (p *ProbeResponsePacket) UnmarshalBinary(data []byte) error {
+	return unmarshalPacket(p, data)
+}
+
+func (p *ProbeResponsePacket) appendBody(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (p *ProbeResponsePacket) parseBody(body []byte) error {
+	return nil
+}
+
+// readFrame reads the header of a packet into header and its body into
+// body, growing body as the data arrives so that a corrupt length cannot
+// force a huge allocation.
+func readFrame(r io.Reader, header []byte, body *bytes.Buffer) (packetLen uint32, packetType uint32, err error) {
+	if _, err = io.ReadFull(r, header[:packetHeaderSize]); err != nil {
+		return 0, 0, err
+	}
+	packetLen = binary.LittleEndian.Uint32(header[0:])
+	packetType = binary.LittleEndian.Uint32(header[4:])
+	if packetLen < packetHeaderSize {
+		return 0, 0, &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("invalid packet len 0x%x", packetLen)}
+	}
+
+	bodyLen := packetLen - packetHeaderSize
+	initialSize := bodyLen
+	if maxInitialBodySize < initialSize {
+		initialSize = maxInitialBodySize
+	}
+	body.Reset()
+	body.Grow(int(initialSize))
+	_, err = io.CopyN(body, r, int64(bodyLen))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return packetLen, packetType, nil
+}
+
+// Reader reads packets from a connection, e.g. to build a responder or a
+// proxy. It reuses its buffer across packets: the Payload of a DataPacket or
+// EndDataPacket returned by ReadPacket is only valid until the next call.
+type Reader struct {
+	r      io.Reader
+	header [packetHeaderSize]byte
+	body   bytes.Buffer
+}
+
+// NewReader ...
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// ReadPacket reads the next packet. A packet of unknown type or invalid
+// length is returned as ProtocolError.
+func (r *Reader) ReadPacket() (p Packet, err error) {
+	_, packetType, err := readFrame(r.r, r.header[:], &r.body)
+	if err != nil {
+		return nil, err
+	}
+
+	p = newPacket(packetType)
+	if p == nil {
+		return nil, &ProtocolError{PacketType: packetType, Reason: "unknown packet type"}
+	}
+	if err = p.parseBody(r.body.Bytes()); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Writer writes packets to a connection, each with a single Write. It
+// reuses its buffer across packets and is not safe for concurrent use.
+type Writer struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriter ...
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WritePacket ...
+func (w *Writer) WritePacket(p Packet) (err error) {
+	b, err := appendPacket(w.buf[:0], p)
+	if err != nil {
+		return err
+	}
+	w.buf = b
+
+	_, err = w.w.Write(b)
+	return err
+}
@@ -0,0 +1,127 @@
+package packet_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/ptpiptest"
+)
+
+// frames holds a frame of every packet type with its decoded packet.
+// Operation responses and events are sent with all of their parameters.
+var frames = []struct {
+	name  string
+	frame []byte
+	p     packet.Packet
+}{
+	{"InitCommandRequest", ptpiptest.Golden["InitCommandRequest"], &packet.InitCommandRequestPacket{GUID: initiatorGUID, FriendlyName: "ptpip", ProtocolVersion: 0x00010000}},
+	{"InitCommandAck", ptpiptest.Golden["InitCommandAck"], &packet.InitCommandAckPacket{ConnectionNumber: 1, GUID: []byte{0xf0, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8, 0xf9, 0xfa, 0xfb, 0xfc, 0xfd, 0xfe, 0xff}, FriendlyName: "cam", ProtocolVersion: 0x00010000}},
+	{"InitEventRequest", ptpiptest.Golden["InitEventRequest"], &packet.InitEventRequestPacket{ConnectionNumber: 1}},
+	{"InitEventAck", ptpiptest.Golden["InitEventAck"], &packet.InitEventAckPacket{}},
+	{"InitFail", ptpiptest.Golden["InitFail"], &packet.InitFailPacket{Reason: packet.InitFailReasonBusy}},
+	{"OperationRequest", ptpiptest.Golden["OperationRequest"], &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn, OperationCode: 0x1002, P1: 1}},
+	{"OperationRequest P5", ptpiptest.OperationRequest(packet.DataPhaseInfoNoDataOrDataIn, 0x9805, 2, 1, 0, 0, 0, 5), &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn, OperationCode: 0x9805, TransactionID: 2, P1: 1, P5: 5}},
+	{"OperationResponse", ptpiptest.OperationResponse(packet.ResponseCodeOK, 1, 0x10, 0, 0, 0), &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: 1, P1: 0x10}},
+	{"Event", ptpiptest.Event(uint16(packet.EventCodeObjectAdded), 0, 0x10, 0, 0), &packet.EventPacket{EventCode: uint16(packet.EventCodeObjectAdded), P1: 0x10}},
+	{"StartData", ptpiptest.Golden["StartData"], &packet.StartDataPacket{TransactionID: 1, TotalDataLength: 4}},
+	{"Data", ptpiptest.Golden["Data"], &packet.DataPacket{TransactionID: 1, Payload: []byte{1, 2, 3, 4}}},
+	{"Cancel", ptpiptest.Golden["Cancel"], &packet.CancelPacket{TransactionID: 1}},
+	{"EndData", ptpiptest.Golden["EndData"], &packet.EndDataPacket{TransactionID: 1, Payload: []byte{}}},
+	{"ProbeRequest", ptpiptest.Golden["ProbeRequest"], &packet.ProbeRequestPacket{}},
+	{"ProbeResponse", ptpiptest.Golden["ProbeResponse"], &packet.ProbeResponsePacket{}},
+}
+
+func TestReaderWriter(t *testing.T) {
+	var stream bytes.Buffer
+	w := packet.NewWriter(&stream)
+	for _, f := range frames {
+		b, err := f.p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		if !bytes.Equal(b, f.frame) {
+			t.Errorf("%s: MarshalBinary got\n% x\nexpected\n% x", f.name, b, f.frame)
+		}
+		if err = w.WritePacket(f.p); err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+	}
+
+	r := packet.NewReader(&stream)
+	for _, f := range frames {
+		p, err := r.ReadPacket()
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		if !reflect.DeepEqual(p, f.p) {
+			t.Errorf("%s: ReadPacket got %+v expected %+v", f.name, p, f.p)
+		}
+	}
+	if _, err := r.ReadPacket(); err != io.EOF {
+		t.Errorf("got %v expected EOF", err)
+	}
+}
+
+func TestUnmarshalBinary(t *testing.T) {
+	for _, f := range frames {
+		p := reflect.New(reflect.TypeOf(f.p).Elem()).Interface().(packet.Packet)
+		if err := p.UnmarshalBinary(f.frame); err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		if !reflect.DeepEqual(p, f.p) {
+			t.Errorf("%s: got %+v expected %+v", f.name, p, f.p)
+		}
+	}
+
+	// parameters left out by the responder are zero
+	resp := &packet.OperationResponsePacket{}
+	if err := resp.UnmarshalBinary(ptpiptest.Golden["OperationResponse"]); err != nil {
+		t.Fatal(err)
+	}
+	if resp.P1 != 0x10 || resp.P2 != 0 {
+		t.Errorf("got %+v", resp)
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		p    packet.Packet
+		data []byte
+	}{
+		{"packet type", &packet.EventPacket{}, ptpiptest.Golden["Cancel"]},
+		{"packet len", &packet.CancelPacket{}, ptpiptest.Golden["Cancel"][:10]},
+		{"short body", &packet.CancelPacket{}, ptpiptest.Frame(packet.PacketTypeCancel, []byte{1})},
+		{"StartData len", &packet.StartDataPacket{}, ptpiptest.Frame(packet.PacketTypeStartData, make([]byte, 8))},
+		{"FriendlyName without ProtocolVersion", &packet.InitCommandAckPacket{}, ptpiptest.Frame(packet.PacketTypeInitCommandAck, make([]byte, 22))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.UnmarshalBinary(tt.data)
+			if _, ok := err.(*packet.ProtocolError); !ok {
+				t.Errorf("got %v expected ProtocolError", err)
+			}
+		})
+	}
+}
+
+func TestReaderInvalid(t *testing.T) {
+	r := packet.NewReader(bytes.NewReader(ptpiptest.Frame(0x0F, nil)))
+	if _, err := r.ReadPacket(); err == nil {
+		t.Error("expected error for unknown packet type")
+	}
+
+	r = packet.NewReader(bytes.NewReader([]byte{0x04, 0, 0, 0, 0x0B, 0, 0, 0}))
+	if _, err := r.ReadPacket(); err == nil {
+		t.Error("expected error for packet shorter than its header")
+	}
+
+	r = packet.NewReader(bytes.NewReader(ptpiptest.Golden["Data"][:12]))
+	if _, err := r.ReadPacket(); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v expected ErrUnexpectedEOF", err)
+	}
+}
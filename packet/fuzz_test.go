@@ -0,0 +1,298 @@
+//go:build go1.18
+// +build go1.18
+
+package packet_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/ptpiptest"
+)
+
+// The seeds are synthetic: they are built from the golden frames of
+// ptpiptest, which follow the specification, not recorded from devices.
+
+// fuzzConn reads the fuzz input and discards what is written.
+type fuzzConn struct {
+	io.Reader
+}
+
+func (fuzzConn) Write(b []byte) (int, error) { return len(b), nil }
+
+func addFrames(f *testing.F, frames ...[]byte) {
+	f.Add(bytes.Join(frames, nil))
+}
+
+func FuzzInitCommandRequest(f *testing.F) {
+	addFrames(f, ptpiptest.Golden["InitCommandAck"])
+	addFrames(f, ptpiptest.Golden["InitFail"])
+	addFrames(f, ptpiptest.InitCommandAck(1, make([]byte, 16), "a long friendly name of a camera over 40 chars", 0x00010000))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		req := &packet.InitCommandRequestPacket{GUID: initiatorGUID, FriendlyName: "ptpip", ProtocolVersion: 0x00010000}
+		packet.InitCommandRequest(fuzzConn{bytes.NewReader(data)}, req)
+	})
+}
+
+func FuzzInitEventRequest(f *testing.F) {
+	addFrames(f, ptpiptest.Golden["InitEventAck"])
+	addFrames(f, ptpiptest.Golden["InitFail"])
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		packet.InitEventRequest(fuzzConn{bytes.NewReader(data)}, 1)
+	})
+}
+
+func FuzzRecvEvent(f *testing.F) {
+	addFrames(f, ptpiptest.Golden["Event"])
+	addFrames(f, ptpiptest.Golden["ProbeRequest"], ptpiptest.Golden["ProbeResponse"], ptpiptest.Golden["Cancel"], ptpiptest.Golden["Event"])
+	addFrames(f, ptpiptest.Event(uint16(packet.EventCodeDevicePropChanged), 0xFFFFFFFF))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		conn := fuzzConn{bytes.NewReader(data)}
+		for {
+			if _, err := packet.RecvEventPacket(conn); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func FuzzDataPhase(f *testing.F) {
+	// the fuzzer also picks the data phase and the transaction ID
+	add := func(phase uint32, frames ...[]byte) {
+		f.Add(phase, uint32(1), bytes.Join(frames, nil))
+	}
+	in := packet.DataPhaseInfoNoDataOrDataIn
+	add(in, ptpiptest.Golden["OperationResponse"])
+	add(in, ptpiptest.Golden["StartData"], ptpiptest.Golden["Data"], ptpiptest.Golden["EndData"], ptpiptest.Golden["OperationResponse"])
+	add(in, ptpiptest.StartData(1, 8), ptpiptest.Data(1, []byte{1, 2}), ptpiptest.Golden["Cancel"], ptpiptest.Golden["EndData"], ptpiptest.OperationResponse(packet.ResponseCodeTransactionCancelled, 1))
+	add(in, ptpiptest.StartData(1, 0xFFFFFFFFFFFFFFFF), ptpiptest.EndData(1, []byte{1, 2, 3}), ptpiptest.Golden["OperationResponse"])
+	add(in, ptpiptest.StartData(1, 8), ptpiptest.EndData(1, []byte{1}), ptpiptest.OperationResponse(packet.ResponseCodeTransactionCancelled, 1))
+	add(packet.DataPhaseInfoDataOut, ptpiptest.Golden["Cancel"], ptpiptest.OperationResponse(packet.ResponseCodeTransactionCancelled, 1))
+
+	f.Fuzz(func(t *testing.T, phase uint32, transactionID uint32, data []byte) {
+		req := &packet.OperationRequestPacket{DataPhaseInfo: phase, TransactionID: transactionID}
+		recvData, resp, err := packet.OperationRequestWithResponse(fuzzConn{bytes.NewReader(data)}, req, []byte{1})
+		if err == nil && resp == nil {
+			t.Fatalf("no response and no error, data % x", recvData)
+		}
+	})
+}
+
+func FuzzReader(f *testing.F) {
+	var all [][]byte
+	for _, fr := range frames {
+		addFrames(f, fr.frame)
+		all = append(all, fr.frame)
+	}
+	addFrames(f, all...)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := packet.NewReader(bytes.NewReader(data))
+		for {
+			p, err := r.ReadPacket()
+			if err != nil {
+				return
+			}
+			// a decoded packet encodes to a packet that decodes to the same
+			b, err := p.MarshalBinary()
+			if err != nil {
+				continue
+			}
+			q := reflect.New(reflect.TypeOf(p).Elem()).Interface().(packet.Packet)
+			if err = q.UnmarshalBinary(b); err != nil {
+				t.Fatalf("unmarshal of marshaled %+v: %v", p, err)
+			}
+			if !reflect.DeepEqual(p, q) {
+				t.Fatalf("got %+v expected %+v", q, p)
+			}
+		}
+	})
+}
+
+func FuzzParseDeviceInfo(f *testing.F) {
+	f.Add(deviceInfoSeed())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		packet.ParseDeviceInfo(data)
+	})
+}
+
+func FuzzParseStorageInfo(f *testing.F) {
+	var b []byte
+	b = appendU16(b, packet.StorageTypeRemovableRAM)
+	b = appendU16(b, packet.FilesystemTypeDCF)
+	b = appendU16(b, packet.AccessCapabilityReadWrite)
+	b = appendU64(b, 64<<30)
+	b = appendU64(b, 32<<30)
+	b = appendU32(b, packet.FreeSpaceInImagesUnused)
+	b = appendString(b, "SD1")
+	b = appendString(b, "")
+	f.Add(b)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		packet.ParseStorageInfo(data)
+	})
+}
+
+func FuzzParseObjectInfo(f *testing.F) {
+	seed, err := packet.MarshalObjectInfo(&packet.ObjectInfo{
+		StorageID:            0x00010001,
+		ObjectFormat:         uint16(packet.ObjectFormatCodeEXIFJPEG),
+		ObjectCompressedSize: 123456,
+		ParentObject:         0x10,
+		Filename:             "DSC00001.JPG",
+		CaptureDate:          time.Date(2020, 9, 6, 9, 36, 30, 0, time.UTC),
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(seed)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		o, err := packet.ParseObjectInfo(data)
+		if err != nil {
+			return
+		}
+		// a decoded ObjectInfo encodes again
+		if _, err = packet.MarshalObjectInfo(o); err != nil {
+			t.Fatalf("marshal of parsed ObjectInfo: %v", err)
+		}
+	})
+}
+
+func FuzzParseDevicePropDesc(f *testing.F) {
+	// ExposureBiasCompensation INT16 range -3000..3000 step 333
+	var b []byte
+	b = appendU16(b, uint16(packet.DevicePropCodeExposureBiasCompensation))
+	b = appendU16(b, packet.DataTypeInt16)
+	b = append(b, 1)
+	b = appendU16(b, 0)
+	b = appendU16(b, 0)
+	b = append(b, packet.FormFlagRange)
+	b = appendU16(b, uint16(0xFFFF-3000+1))
+	b = appendU16(b, 3000)
+	b = appendU16(b, 333)
+	f.Add(b)
+
+	// WhiteBalance UINT16 enumeration
+	b = nil
+	b = appendU16(b, uint16(packet.DevicePropCodeWhiteBalance))
+	b = appendU16(b, packet.DataTypeUInt16)
+	b = append(b, 1)
+	b = appendU16(b, 2)
+	b = appendU16(b, 2)
+	b = append(b, packet.FormFlagEnumeration)
+	b = appendU16(b, 3)
+	b = appendU16(b, 2)
+	b = appendU16(b, 4)
+	b = appendU16(b, 6)
+	f.Add(b)
+
+	// ArtistName string without form
+	b = nil
+	b = appendU16(b, 0x501E)
+	b = appendU16(b, packet.DataTypeString)
+	b = append(b, 1)
+	b = appendString(b, "")
+	b = appendString(b, "ptpip")
+	f.Add(b)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		packet.ParseDevicePropDesc(data)
+	})
+}
+
+func FuzzDecodeValue(f *testing.F) {
+	f.Add([]byte{0x01, 0x02, 0x03, 0x04}, packet.DataTypeUInt32)
+	f.Add([]byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00}, packet.DataTypeUInt16|packet.DataTypeArray)
+	f.Add(appendString(nil, "ptpip"), packet.DataTypeString)
+	f.Add(make([]byte, 16), packet.DataTypeUInt128)
+
+	f.Fuzz(func(t *testing.T, data []byte, dataType uint16) {
+		v, n, err := packet.DecodeValue(data, dataType)
+		if err != nil {
+			return
+		}
+		if n < 0 || len(data) < n {
+			t.Fatalf("consumed %d of %d bytes", n, len(data))
+		}
+		// a decoded value encodes to the bytes it was decoded from, except
+		// strings as their terminator is optional
+		if dataType == packet.DataTypeString {
+			return
+		}
+		enc, err := packet.EncodeValue(v, dataType)
+		if err != nil {
+			t.Fatalf("encode of decoded %v: %v", v, err)
+		}
+		if !bytes.Equal(enc, data[:n]) {
+			t.Fatalf("encoded % x, decoded from % x", enc, data[:n])
+		}
+	})
+}
+
+func FuzzParseDateTime(f *testing.F) {
+	f.Add("20200906T093630")
+	f.Add("20200906T093630.5Z")
+	f.Add("20200906T093630+0900")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		packet.ParseDateTime(s)
+	})
+}
+
+func deviceInfoSeed() []byte {
+	var b []byte
+	b = appendU16(b, 100)
+	b = appendU32(b, 0x00000006)
+	b = appendU16(b, 100)
+	b = appendString(b, "microsoft.com: 1.0")
+	b = appendU16(b, 0)
+	b = appendU16Array(b, uint16(packet.OperationCodeGetDeviceInfo), uint16(packet.OperationCodeOpenSession), uint16(packet.OperationCodeGetObject))
+	b = appendU16Array(b, uint16(packet.EventCodeObjectAdded))
+	b = appendU16Array(b, uint16(packet.DevicePropCodeWhiteBalance))
+	b = appendU16Array(b, uint16(packet.ObjectFormatCodeEXIFJPEG))
+	b = appendU16Array(b, uint16(packet.ObjectFormatCodeEXIFJPEG))
+	b = appendString(b, "Maker")
+	b = appendString(b, "Model")
+	b = appendString(b, "1.00")
+	b = appendString(b, "0123456789")
+	return b
+}
+
+func appendU16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendU32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendU64(b []byte, v uint64) []byte {
+	return appendU32(appendU32(b, uint32(v)), uint32(v>>32))
+}
+
+func appendU16Array(b []byte, a ...uint16) []byte {
+	b = appendU32(b, uint32(len(a)))
+	for _, v := range a {
+		b = appendU16(b, v)
+	}
+	return b
+}
+
+// appendString appends a PTP string, see packet.EncodeValue.
+func appendString(b []byte, s string) []byte {
+	enc, err := packet.EncodeValue(s, packet.DataTypeString)
+	if err != nil {
+		panic(err)
+	}
+	return append(b, enc...)
+}
@@ -67,9 +67,50 @@ const (
 	ResponseCodeInvalidParameter                      uint16 = 0x201D
 	ResponseCodeSessionAlreadyOpen                    uint16 = 0x201E
 	ResponseCodeTransactionCancelled                  uint16 = 0x201F
-	ResponseCodeSpecificationOfDestinationUnsupported uint16 = 0x201F
+	ResponseCodeSpecificationOfDestinationUnsupported uint16 = 0x2020
+	ResponseCodeInvalidEnumHandle                     uint16 = 0x2021
+	ResponseCodeNoStreamEnabled                       uint16 = 0x2022
+	ResponseCodeInvalidDataSet                        uint16 = 0x2023
 )
 
+// InitFail Reason
+const (
+	InitFailReasonRejectedInitiator uint32 = 0x00000001
+	InitFailReasonBusy              uint32 = 0x00000002
+	InitFailReasonUnspecified       uint32 = 0x00000003
+)
+
+// ResponseError is returned when the responder completes an operation
+// with a response code other than ResponseCodeOK.
+type ResponseError struct {
+	Code uint16
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("operation response error 0x%04x", e.Code)
+}
+
+// InitFailError is returned when the responder refuses the connection with an InitFail packet.
+type InitFailError struct {
+	Reason uint32
+}
+
+func (e *InitFailError) Error() string {
+	return fmt.Sprintf("init fail reason 0x%08x", e.Reason)
+}
+
+// ProtocolError is returned when the responder violates the PTP-IP protocol,
+// e.g. a packet out of order, of another transaction or of invalid length.
+// The connection is out of sync after a ProtocolError and must be closed.
+type ProtocolError struct {
+	PacketType uint32
+	Reason     string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("protocol error packet type 0x%08x: %s", e.PacketType, e.Reason)
+}
+
 // InitCommandRequestPacket ...
 type InitCommandRequestPacket struct {
 	GUID            []byte
@@ -108,6 +149,19 @@ func (ca InitCommandAckPacket) String() string {
 	return s
 }
 
+// InitEventRequestPacket ...
+type InitEventRequestPacket struct {
+	ConnectionNumber uint32
+}
+
+// InitEventAckPacket ...
+type InitEventAckPacket struct{}
+
+// InitFailPacket ...
+type InitFailPacket struct {
+	Reason uint32
+}
+
 // OperationRequestPacket ...
 type OperationRequestPacket struct {
 	DataPhaseInfo uint32
@@ -117,6 +171,8 @@ type OperationRequestPacket struct {
 	P2            uint32
 	P3            uint32
 	P4            uint32
+	// P5 is sent only if not zero, e.g. the depth of MTP GetObjectPropList.
+	P5 uint32
 }
 
 func (o OperationRequestPacket) String() string {
@@ -131,6 +187,7 @@ func (o OperationRequestPacket) String() string {
 	s += fmt.Sprintf("P2               : 0x%08x\n", o.P2)
 	s += fmt.Sprintf("P3               : 0x%08x\n", o.P3)
 	s += fmt.Sprintf("P4               : 0x%08x\n", o.P4)
+	s += fmt.Sprintf("P5               : 0x%08x\n", o.P5)
 	return s
 }
 
@@ -166,3 +223,33 @@ type EventPacket struct {
 	P2            uint32
 	P3            uint32
 }
+
+// CancelPacket ...
+type CancelPacket struct {
+	TransactionID uint32
+}
+
+// StartDataPacket ...
+type StartDataPacket struct {
+	TransactionID uint32
+	// TotalDataLength is 0xFFFFFFFFFFFFFFFF if unknown in advance.
+	TotalDataLength uint64
+}
+
+// DataPacket ...
+type DataPacket struct {
+	TransactionID uint32
+	Payload       []byte
+}
+
+// EndDataPacket ...
+type EndDataPacket struct {
+	TransactionID uint32
+	Payload       []byte
+}
+
+// ProbeRequestPacket ...
+type ProbeRequestPacket struct{}
+
+// ProbeResponsePacket ...
+type ProbeResponsePacket struct{}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/takurooo/binaryio"
 	"github.com/takurooo/swriter"
@@ -16,6 +17,9 @@ const (
 
 const (
 	packetHeaderSize uint32 = 8
+
+	// maxInitialBodySize is the buffer allocated before a packet body is read
+	maxInitialBodySize uint32 = 1 << 20
 )
 
 func dump(b []byte, col int) {
@@ -32,32 +36,6 @@ func dump(b []byte, col int) {
 	fmt.Printf("\n")
 }
 
-func encodeFriendlyName(s string) []byte {
-	buf := make([]byte, len(s)*2+2)
-	for i, r := range s {
-		buf[i*2] = byte(r)
-		buf[i*2+1] = 0
-	}
-	buf[len(s)*2] = 0
-	buf[len(s)*2+1] = 0
-	return buf
-}
-
-func decodeFriendlyName(br *binaryio.Reader) string {
-	frinedlyName := make([]uint8, 40)
-	var i int
-	for {
-		v := br.ReadU16(endian)
-		if v == 0x00 { // null terminated
-			break
-		}
-		frinedlyName[i] = byte(v & 0xff)
-		i++
-	}
-
-	return string(frinedlyName)
-}
-
 func sendPacket(w io.Writer, packet []byte) (err error) {
 
 	// fmt.Println("----------------")
@@ -73,71 +51,54 @@ func sendPacket(w io.Writer, packet []byte) (err error) {
 }
 
 func recvPacket(r io.Reader) (packetLen uint32, packetType uint32, packetBody []byte, err error) {
-	// read packet header
-	var packetHeader = make([]byte, packetHeaderSize)
-	_, err = r.Read(packetHeader)
+	var (
+		header [packetHeaderSize]byte
+		body   bytes.Buffer
+	)
+	packetLen, packetType, err = readFrame(r, header[:], &body)
 	if err != nil {
 		return 0, 0, nil, err
 	}
-	brHeader := binaryio.NewReader(bytes.NewReader(packetHeader))
-	packetLen = brHeader.ReadU32(endian)
-	packetType = brHeader.ReadU32(endian)
-
-	// read packet body
-	packetBodyLen := packetLen - packetHeaderSize
-	if 0 < packetBodyLen {
-		packetBody = make([]byte, packetBodyLen)
-		_, err = r.Read(packetBody)
-		if err != nil {
-			return 0, 0, nil, err
-		}
+	if 0 < body.Len() {
+		packetBody = body.Bytes()
 	}
 
 	// fmt.Println("----------------")
 	// fmt.Println("recvPacket")
 	// fmt.Println("----------------")
-	// dump(append(packetHeader, packetBody...), 4)
+	// dump(append(header[:], packetBody...), 4)
 
 	return packetLen, packetType, packetBody, nil
 }
 
-func sendInitCommandRequestPacket(w io.Writer, p *InitCommandRequestPacket) (err error) {
-
-	// check value
-	if 16 < len(p.GUID) {
-		return fmt.Errorf("invalid initiator GUID len 16 < %d", len(p.GUID))
+// writePacket encodes p and sends it with a single Write.
+func writePacket(w io.Writer, p Packet) (err error) {
+	packet, err := p.MarshalBinary()
+	if err != nil {
+		return err
 	}
 
-	encodedFriendlyName := encodeFriendlyName(p.FriendlyName)
-	if 40 < len(encodedFriendlyName) {
-		return fmt.Errorf("invalid initiator FriendlyName len 40 < %d", len(encodedFriendlyName))
+	err = sendPacket(w, packet)
+	if err != nil {
+		return err
 	}
 
-	packetLen := uint32(12 + len(p.GUID) + len(encodedFriendlyName))
-
-	sw := swriter.New(int(packetLen))
-	bw := binaryio.NewWriter(sw)
+	return nil
+}
 
-	// write packet header to buffer
-	bw.WriteU32(packetLen, endian)
-	bw.WriteU32(PacketTypeInitCommandRequest, endian)
-	// write packet body to buffer
-	bw.WriteRaw(p.GUID)
-	bw.WriteRaw(encodedFriendlyName)
-	bw.WriteU32(p.ProtocolVersion, endian)
+func sendInitCommandRequestPacket(w io.Writer, p *InitCommandRequestPacket) (err error) {
 
-	if bw.Err() != nil {
-		return bw.Err()
+	packet, err := p.MarshalBinary()
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("----------------")
 	fmt.Println("sendInitCommandRequest")
 	fmt.Println("----------------")
-	fmt.Printf("packetLen        : 0x%08x\n", packetLen)
+	fmt.Printf("packetLen        : 0x%08x\n", len(packet))
 	fmt.Println(p)
 
-	packet := sw.Bytes()
-
 	err = sendPacket(w, packet)
 	if err != nil {
 		return err
@@ -154,18 +115,19 @@ func recvInitCommandAckPacket(r io.Reader) (ack *InitCommandAckPacket, err error
 		return nil, err
 	}
 
+	if packetType == PacketTypeInitFail {
+		return nil, parseInitFailPacket(packetBody)
+	}
 	if packetType != PacketTypeInitCommandAck {
 		return nil, fmt.Errorf("invalid packet type 0x%08x expected 0x%08x", packetType, PacketTypeInitCommandAck)
 	}
 
 	// parse InitCommandAckPacket
-	brBody := binaryio.NewReader(bytes.NewReader(packetBody))
-
 	ack = &InitCommandAckPacket{}
-	ack.ConnectionNumber = brBody.ReadU32(endian)
-	ack.GUID = brBody.ReadRaw(16)
-	ack.FriendlyName = decodeFriendlyName(brBody)
-	ack.ProtocolVersion = brBody.ReadU32(endian)
+	err = ack.parseBody(packetBody)
+	if err != nil {
+		return nil, err
+	}
 
 	fmt.Println("----------------")
 	fmt.Println("recvInitCommandAck")
@@ -177,31 +139,28 @@ func recvInitCommandAckPacket(r io.Reader) (ack *InitCommandAckPacket, err error
 	return ack, nil
 }
 
-func sendInitEventRequestPacket(w io.Writer, conndectionNumber uint32) (err error) {
-
-	packetLen := uint32(12)
-
-	sw := swriter.New(int(packetLen))
-	bw := binaryio.NewWriter(sw)
+func parseInitFailPacket(packetBody []byte) error {
+	p := &InitFailPacket{}
+	err := p.parseBody(packetBody)
+	if err != nil {
+		return err
+	}
+	return &InitFailError{Reason: p.Reason}
+}
 
-	// write packet header to buffer
-	bw.WriteU32(packetLen, endian)
-	bw.WriteU32(PacketTypeInitEventRequest, endian)
-	// write packet body to buffer
-	bw.WriteU32(conndectionNumber, endian)
+func sendInitEventRequestPacket(w io.Writer, conndectionNumber uint32) (err error) {
 
-	if bw.Err() != nil {
-		return bw.Err()
+	packet, err := (&InitEventRequestPacket{ConnectionNumber: conndectionNumber}).MarshalBinary()
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("----------------")
 	fmt.Println("sendInitEventRequest")
 	fmt.Println("----------------")
-	fmt.Printf("packetLen        : 0x%08x\n", packetLen)
+	fmt.Printf("packetLen        : 0x%08x\n", len(packet))
 	fmt.Printf("ConnectionNumber : 0x%08x\n", conndectionNumber)
 
-	packet := sw.Bytes()
-
 	err = sendPacket(w, packet)
 	if err != nil {
 		return err
@@ -213,11 +172,14 @@ func sendInitEventRequestPacket(w io.Writer, conndectionNumber uint32) (err erro
 func recvInitEventAckPacket(r io.Reader) error {
 
 	// read packet header
-	packetLen, packetType, _, err := recvPacket(r)
+	packetLen, packetType, packetBody, err := recvPacket(r)
 	if err != nil {
 		return err
 	}
 
+	if packetType == PacketTypeInitFail {
+		return parseInitFailPacket(packetBody)
+	}
 	if packetType != PacketTypeInitEventAck {
 		return fmt.Errorf("invalid packet type 0x%08x expected 0x%08x", packetType, PacketTypeInitEventAck)
 	}
@@ -233,33 +195,17 @@ func recvInitEventAckPacket(r io.Reader) error {
 
 func sendOperationRequestPacket(w io.Writer, req *OperationRequestPacket) (err error) {
 
-	packetLen := uint32(34)
-	sw := swriter.New(int(packetLen))
-	bw := binaryio.NewWriter(sw)
-
-	// write packet header to buffer
-	bw.WriteU32(packetLen, endian)
-	bw.WriteU32(PacketTypeOperationRequest, endian)
-	// write packet body to buffer
-	bw.WriteU32(req.DataPhaseInfo, endian)
-	bw.WriteU16(req.OperationCode, endian)
-	bw.WriteU32(req.TransactionID, endian)
-	bw.WriteU32(req.P1, endian)
-	bw.WriteU32(req.P2, endian)
-	bw.WriteU32(req.P3, endian)
-	bw.WriteU32(req.P4, endian)
-
-	if bw.Err() != nil {
-		return bw.Err()
+	packet, err := req.MarshalBinary()
+	if err != nil {
+		return err
 	}
+
 	fmt.Println("----------------")
 	fmt.Println("sendOperationRequestPacket")
 	fmt.Println("----------------")
-	fmt.Printf("packetLen        : 0x%08x\n", packetLen)
+	fmt.Printf("packetLen        : 0x%08x\n", len(packet))
 	fmt.Println(req)
 
-	packet := sw.Bytes()
-
 	err = sendPacket(w, packet)
 	if err != nil {
 		return err
@@ -268,148 +214,237 @@ func sendOperationRequestPacket(w io.Writer, req *OperationRequestPacket) (err e
 	return nil
 }
 
-func sendDataPacket(w io.Writer, sendData []byte) (err error) {
+// dataOutPayloadSize is the largest payload of the Data packets sent in a
+// data-out phase. A Cancel is observed between the packets.
+const dataOutPayloadSize = 1 << 20
 
-	if len(sendData) == 0 {
-		return errors.New("send data empty")
-	}
-
-	{
-		packetLen := uint32(20)
-		sw := swriter.New(int(packetLen))
-		bw := binaryio.NewWriter(sw)
-
-		// write packet header to buffer
-		bw.WriteU32(packetLen, endian)
-		bw.WriteU32(PacketTypeStartData, endian)
-		// write packet body to buffer
-		bw.WriteU32(1, endian)
-		bw.WriteU64(uint64(len(sendData)), endian)
+// dataOut is a data-out phase in progress on a connection, see Cancel.
+type dataOut struct {
+	transactionID uint32
+	cancelled     bool
+}
 
-		if bw.Err() != nil {
-			return bw.Err()
-		}
+// dataOuts is keyed by the connection the phase is sent on
+var (
+	dataOutsMu sync.Mutex
+	dataOuts   = make(map[io.Writer]*dataOut)
+)
 
-		packet := sw.Bytes()
+func cancelDataOut(w io.Writer, transactionID uint32) {
+	dataOutsMu.Lock()
+	defer dataOutsMu.Unlock()
 
-		err = sendPacket(w, packet)
-		if err != nil {
-			return err
-		}
+	if d, ok := dataOuts[w]; ok && d.transactionID == transactionID {
+		d.cancelled = true
 	}
-	{
-		packetLen := uint32(12 + len(sendData))
-		sw := swriter.New(int(packetLen))
-		bw := binaryio.NewWriter(sw)
-
-		// write packet header to buffer
-		bw.WriteU32(packetLen, endian)
-		bw.WriteU32(PacketTypeData, endian)
-		// write packet body to buffer
-		bw.WriteU32(1, endian)
-		bw.WriteRaw(sendData)
-
-		if bw.Err() != nil {
-			return bw.Err()
-		}
+}
 
-		packet := sw.Bytes()
+// sendDataPacket sends sendData in Data packets of up to dataOutPayloadSize.
+// If the transaction is cancelled in between, EndData is sent without the
+// rest of the data and the responder answers TransactionCancelled.
+func sendDataPacket(w io.Writer, transactionID uint32, sendData []byte) (err error) {
 
-		err = sendPacket(w, packet)
-		if err != nil {
-			return err
-		}
+	if len(sendData) == 0 {
+		return errors.New("send data empty")
 	}
-	{
-		packetLen := uint32(12)
-		sw := swriter.New(int(packetLen))
-		bw := binaryio.NewWriter(sw)
 
-		// write packet header to buffer
-		bw.WriteU32(packetLen, endian)
-		bw.WriteU32(PacketTypeEndData, endian)
-		// write packet body to buffer
-		bw.WriteU32(1, endian)
+	d := &dataOut{transactionID: transactionID}
+	dataOutsMu.Lock()
+	dataOuts[w] = d
+	dataOutsMu.Unlock()
+	defer func() {
+		dataOutsMu.Lock()
+		delete(dataOuts, w)
+		dataOutsMu.Unlock()
+	}()
 
-		if bw.Err() != nil {
-			return bw.Err()
+	err = writePacket(w, &StartDataPacket{TransactionID: transactionID, TotalDataLength: uint64(len(sendData))})
+	if err != nil {
+		return err
+	}
+	for 0 < len(sendData) {
+		dataOutsMu.Lock()
+		cancelled := d.cancelled
+		dataOutsMu.Unlock()
+		if cancelled {
+			break
 		}
 
-		packet := sw.Bytes()
-
-		err = sendPacket(w, packet)
+		n := len(sendData)
+		if dataOutPayloadSize < n {
+			n = dataOutPayloadSize
+		}
+		err = writePacket(w, &DataPacket{TransactionID: transactionID, Payload: sendData[:n]})
 		if err != nil {
 			return err
 		}
+		sendData = sendData[n:]
+	}
+	err = writePacket(w, &EndDataPacket{TransactionID: transactionID})
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func recvDataPacket(r io.Reader) (data []byte, err error) {
+// data phase states of recvDataPacket
+const (
+	dataPhaseStart     = iota // waiting for StartData or an OperationResponse without data
+	dataPhaseData             // receiving Data up to EndData
+	dataPhaseCancelled        // draining a cancelled data phase up to the OperationResponse
+)
+
+// unknownDataLength is the TotalDataLength of StartData when the responder
+// does not know the length in advance
+const unknownDataLength uint64 = 0xFFFFFFFFFFFFFFFF
+
+// recvDataPacket receives the data phase of transactionID. Packets are
+// validated against the state of the data phase; a violation is returned as
+// ProtocolError. If the responder ends the transaction without data, or
+// cancels it, the OperationResponse is returned instead of data.
+func recvDataPacket(r io.Reader, transactionID uint32) (data []byte, resp *OperationResponsePacket, err error) {
 	var (
-		packetLen       uint32
 		packetType      uint32
 		packetBody      []byte
 		totalDataLength uint64
 	)
 
+	state := dataPhaseStart
 	sw := swriter.New(64)
 
-L:
 	for {
-		packetLen, packetType, packetBody, err = recvPacket(r)
-		brBody := binaryio.NewReader(bytes.NewReader(packetBody))
-		var payload []byte
+		_, packetType, packetBody, err = recvPacket(r)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if packetType == PacketTypeOperationResponse {
+			resp, err = parseOperationResponsePacket(packetBody, transactionID)
+			if err != nil {
+				return nil, nil, err
+			}
+			// a response ends the data phase early only with an error or after a cancel
+			if state == dataPhaseData && resp.ResponseCode == ResponseCodeOK {
+				return nil, nil, &ProtocolError{PacketType: packetType, Reason: "response before EndData"}
+			}
+			return nil, resp, nil
+		}
 
 		switch packetType {
-		case PacketTypeStartData:
-			// fmt.Println("PacketTypeStartData")
-			_ = brBody.ReadU32(endian) // transactionID
-			totalDataLength = brBody.ReadU64(endian)
-		case PacketTypeData:
-			// fmt.Println("PacketTypeData")
-			_ = brBody.ReadU32(endian)                       // transactionID
-			payload = brBody.ReadRaw(uint64(packetLen - 12)) // packetLen - packetHeaderSize - 4
-			sw.Write(payload)
-		case PacketTypeEndData:
-			// fmt.Println("PacketTypeEndData")
-			_ = brBody.ReadU32(endian)                       // transactionID
-			payload = brBody.ReadRaw(uint64(packetLen - 12)) // packetLen - packetHeaderSize - 4
-			sw.Write(payload)
-			break L
+		case PacketTypeStartData, PacketTypeData, PacketTypeEndData, PacketTypeCancel:
+		default:
+			return nil, nil, &ProtocolError{PacketType: packetType, Reason: "unexpected packet in data phase"}
+		}
+
+		err = checkTransactionID(packetType, packetBody, transactionID)
+		if err != nil {
+			return nil, nil, err
 		}
+		payload := packetBody[4:]
+
+		switch state {
+		case dataPhaseStart:
+			switch packetType {
+			case PacketTypeStartData:
+				if len(payload) != 8 {
+					return nil, nil, &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("invalid body len 0x%x", len(packetBody))}
+				}
+				totalDataLength = binaryio.NewReader(bytes.NewReader(payload)).ReadU64(endian)
+				state = dataPhaseData
+			case PacketTypeCancel:
+				state = dataPhaseCancelled
+			default:
+				return nil, nil, &ProtocolError{PacketType: packetType, Reason: "data before StartData"}
+			}
+
+		case dataPhaseData:
+			switch packetType {
+			case PacketTypeData, PacketTypeEndData:
+				sw.Write(payload)
+				if totalDataLength != unknownDataLength && totalDataLength < uint64(sw.Len()) {
+					return nil, nil, &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("data len exceeds 0x%x", totalDataLength)}
+				}
+			case PacketTypeCancel:
+				// responder cancelled the transaction, the data is dropped and
+				// the OperationResponse follows
+				state = dataPhaseCancelled
+				continue
+			default:
+				return nil, nil, &ProtocolError{PacketType: packetType, Reason: "StartData in data phase"}
+			}
+			if packetType == PacketTypeEndData {
+				return endDataPhase(r, transactionID, sw.Bytes(), totalDataLength)
+			}
+
+		case dataPhaseCancelled:
+			if packetType == PacketTypeStartData {
+				return nil, nil, &ProtocolError{PacketType: packetType, Reason: "StartData after Cancel"}
+			}
+		}
+	}
+}
+
+// endDataPhase checks the length of the data received up to EndData.
+func endDataPhase(r io.Reader, transactionID uint32, data []byte, totalDataLength uint64) ([]byte, *OperationResponsePacket, error) {
+	if totalDataLength == unknownDataLength || uint64(len(data)) == totalDataLength {
+		return data, nil, nil
 	}
 
-	if uint64(sw.Len()) != totalDataLength {
-		return nil, fmt.Errorf("invalid data len 0x%x expected 0x%x", sw.Len(), totalDataLength)
+	// a transaction cancelled by the initiator ends the data phase early,
+	// read the response to keep the connection in sync
+	resp, err := recvOperationReponsePacket(r, transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.ResponseCode != ResponseCodeOK {
+		return nil, resp, nil
 	}
+	return nil, nil, &ProtocolError{PacketType: PacketTypeEndData, Reason: fmt.Sprintf("invalid data len 0x%x expected 0x%x", len(data), totalDataLength)}
+}
 
-	return sw.Bytes(), nil
+// checkTransactionID checks the TransactionID leading the body of a data phase packet.
+func checkTransactionID(packetType uint32, packetBody []byte, transactionID uint32) error {
+	if len(packetBody) < 4 {
+		return &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("invalid body len 0x%x", len(packetBody))}
+	}
+	if id := binaryio.NewReader(bytes.NewReader(packetBody)).ReadU32(endian); id != transactionID {
+		return &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("invalid transaction id 0x%08x expected 0x%08x", id, transactionID)}
+	}
+	return nil
 }
 
-func recvOperationReponsePacket(r io.Reader) (resp *OperationResponsePacket, err error) {
+func recvOperationReponsePacket(r io.Reader, transactionID uint32) (resp *OperationResponsePacket, err error) {
 
-	// read packet header
-	packetLen, packetType, packetBody, err := recvPacket(r)
-	if err != nil {
-		return nil, err
+	var (
+		packetLen  uint32
+		packetType uint32
+		packetBody []byte
+	)
+	for {
+		// read packet header
+		packetLen, packetType, packetBody, err = recvPacket(r)
+		if err != nil {
+			return nil, err
+		}
+		if packetType != PacketTypeCancel {
+			break
+		}
+		// a Cancel of the responder precedes the response of a cancelled transaction
+		err = checkTransactionID(packetType, packetBody, transactionID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if packetType != PacketTypeOperationResponse {
-		return nil, fmt.Errorf("invalid packet type 0x%08x expected 0x%08x", packetType, PacketTypeOperationResponse)
+		return nil, &ProtocolError{PacketType: packetType, Reason: fmt.Sprintf("expected 0x%08x", PacketTypeOperationResponse)}
 	}
 
-	// parse OperationResponsePacket
-	brBody := binaryio.NewReader(bytes.NewReader(packetBody))
-
-	resp = &OperationResponsePacket{}
-	resp.ResponseCode = brBody.ReadU16(endian)
-	resp.TransactionID = brBody.ReadU32(endian)
-	resp.P1 = brBody.ReadU32(endian)
-	resp.P2 = brBody.ReadU32(endian)
-	resp.P3 = brBody.ReadU32(endian)
-	resp.P4 = brBody.ReadU32(endian)
+	resp, err = parseOperationResponsePacket(packetBody, transactionID)
+	if err != nil {
+		return nil, err
+	}
 
 	fmt.Println("----------------")
 	fmt.Println("recvOperationReponsePacket")
@@ -421,43 +456,46 @@ func recvOperationReponsePacket(r io.Reader) (resp *OperationResponsePacket, err
 	return resp, nil
 }
 
-func parseEventPacket(packetBody []byte) (e *EventPacket, err error) {
+func parseOperationResponsePacket(packetBody []byte, transactionID uint32) (resp *OperationResponsePacket, err error) {
 
-	// parse EventPacket
-	brBody := binaryio.NewReader(bytes.NewReader(packetBody))
+	resp = &OperationResponsePacket{}
+	err = resp.parseBody(packetBody)
+	if err != nil {
+		return nil, err
+	}
 
-	e = &EventPacket{}
-	e.EventCode = brBody.ReadU16(endian)
-	e.TransactionID = brBody.ReadU32(endian)
-	e.P1 = brBody.ReadU32(endian)
-	e.P2 = brBody.ReadU32(endian)
-	e.P3 = brBody.ReadU32(endian)
+	if resp.TransactionID != transactionID {
+		return nil, &ProtocolError{PacketType: PacketTypeOperationResponse, Reason: fmt.Sprintf("invalid transaction id 0x%08x expected 0x%08x", resp.TransactionID, transactionID)}
+	}
 
-	return e, nil
+	return resp, nil
 }
 
-func sendProbeResponsePacket(w io.Writer) (err error) {
+func parseEventPacket(packetBody []byte) (e *EventPacket, err error) {
 
-	packetLen := uint32(8)
-	sw := swriter.New(int(packetLen))
-	bw := binaryio.NewWriter(sw)
+	e = &EventPacket{}
+	err = e.parseBody(packetBody)
+	if err != nil {
+		return nil, err
+	}
 
-	// write packet header to buffer
-	bw.WriteU32(packetLen, endian)
-	bw.WriteU32(PacketTypeProbeResponse, endian)
+	return e, nil
+}
 
-	if bw.Err() != nil {
-		return bw.Err()
-	}
+func sendProbeResponsePacket(w io.Writer) (err error) {
+	return writePacket(w, &ProbeResponsePacket{})
+}
 
-	packet := sw.Bytes()
+func sendProbeRequestPacket(w io.Writer) (err error) {
+	return writePacket(w, &ProbeRequestPacket{})
+}
 
-	err = sendPacket(w, packet)
-	if err != nil {
-		return err
-	}
+func sendCancelPacket(w io.Writer, transactionID uint32) (err error) {
+	return writePacket(w, &CancelPacket{TransactionID: transactionID})
+}
 
-	return nil
+func sendEventPacket(w io.Writer, e *EventPacket) (err error) {
+	return writePacket(w, e)
 }
 
 // InitCommandRequest ...
@@ -490,63 +528,122 @@ func InitEventRequest(conn PTPIPConn, conndectionNumber uint32) (err error) {
 
 // OperationRequest ...
 func OperationRequest(conn PTPIPConn, req *OperationRequestPacket, sendData []byte) (recvData []byte, err error) {
+	recvData, _, err = OperationRequestWithResponse(conn, req, sendData)
+	if err != nil {
+		return nil, err
+	}
+	return recvData, nil
+}
+
+// OperationRequestWithResponse is OperationRequest that also returns the
+// OperationResponse, whose parameters carry results of some operations.
+// The response is returned along with the ResponseError when the response code is not OK.
+// A violation of the protocol by the responder is returned as ProtocolError,
+// after which conn must be closed.
+func OperationRequestWithResponse(conn PTPIPConn, req *OperationRequestPacket, sendData []byte) (recvData []byte, resp *OperationResponsePacket, err error) {
 
 	err = sendOperationRequestPacket(conn, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch req.DataPhaseInfo {
 	case DataPhaseInfoNoDataOrDataIn:
-		recvData, err = recvDataPacket(conn)
+		recvData, resp, err = recvDataPacket(conn, req.TransactionID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	case DataPhaseInfoDataOut:
-		err = sendDataPacket(conn, sendData)
+		err = sendDataPacket(conn, req.TransactionID, sendData)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	resp, err := recvOperationReponsePacket(conn)
-	if err != nil {
-		return nil, err
+	if resp == nil {
+		resp, err = recvOperationReponsePacket(conn, req.TransactionID)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if resp.ResponseCode != ResponseCodeOK {
-		return nil, fmt.Errorf("operation response error 0x%08x", resp.ResponseCode)
+		return nil, resp, &ResponseError{Code: resp.ResponseCode}
 	}
 
-	return recvData, nil
+	return recvData, resp, nil
+}
+
+// Cancel cancels the transaction in progress on cConn. It sends a Cancel
+// packet on cConn and, if eConn is not nil, the CancelTransaction event on
+// eConn. It does not wait for the transaction: OperationRequestWithResponse
+// drains the rest of the data phase, or ends a data-out phase with EndData
+// after the Data packet being sent, and returns the ResponseError with
+// ResponseCodeTransactionCancelled, or the result if the transaction completed
+// before the responder saw the Cancel.
+// Cancel may be called while another goroutine is in OperationRequestWithResponse
+// as every packet is sent with a single Write.
+func Cancel(cConn PTPIPConn, eConn PTPIPConn, transactionID uint32) (err error) {
+	cancelDataOut(cConn, transactionID)
+
+	err = sendCancelPacket(cConn, transactionID)
+	if err != nil {
+		return err
+	}
+	if eConn != nil {
+		e := &EventPacket{EventCode: uint16(EventCodeCancelTransaction), TransactionID: transactionID}
+		err = sendEventPacket(eConn, e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Probe sends a ProbeRequest on the event connection. The ProbeResponse is
+// consumed by RecvEventPacket, a caller detects it as data read from conn.
+func Probe(conn PTPIPConn) (err error) {
+	return sendProbeRequestPacket(conn)
 }
 
 // RecvEvent ...
 func RecvEvent(conn PTPIPConn) (eventCode uint16, err error) {
+	e, err := RecvEventPacket(conn)
+	if err != nil {
+		return 0, err
+	}
+	return e.EventCode, nil
+}
+
+// RecvEventPacket is RecvEvent that returns the whole EventPacket.
+func RecvEventPacket(conn PTPIPConn) (e *EventPacket, err error) {
 
-	var e *EventPacket
 L:
 	for {
 		// read packet header
 		_, packetType, packetBody, err := recvPacket(conn)
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
 
 		switch packetType {
 		case PacketTypeEvent:
 			e, err = parseEventPacket(packetBody)
 			if err != nil {
-				return 0, err
+				return nil, err
 			}
 			break L
 		case PacketTypeProbeRequest:
 			err = sendProbeResponsePacket(conn)
 			if err != nil {
-				return 0, err
+				return nil, err
 			}
+		case PacketTypeProbeResponse, PacketTypeCancel:
+			// nothing to do for the initiator
+		default:
+			return nil, &ProtocolError{PacketType: packetType, Reason: "unexpected packet on event connection"}
 		}
 	}
 
-	return e.EventCode, nil
+	return e, nil
 }
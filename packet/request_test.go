@@ -0,0 +1,329 @@
+package packet_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/ptpiptest"
+)
+
+var initiatorGUID = []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F}
+
+func TestInitCommandRequest(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Expect(ptpiptest.Golden["InitCommandRequest"]).
+		Send(ptpiptest.Golden["InitCommandAck"])
+	peer.Start()
+
+	ack, err := packet.InitCommandRequest(conn, &packet.InitCommandRequestPacket{
+		GUID:            initiatorGUID,
+		FriendlyName:    "ptpip",
+		ProtocolVersion: 0x00010000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ack.ConnectionNumber != 1 {
+		t.Errorf("ConnectionNumber got %d expected 1", ack.ConnectionNumber)
+	}
+	if ack.FriendlyName != "cam" {
+		t.Errorf("FriendlyName got %q expected %q", ack.FriendlyName, "cam")
+	}
+	if ack.ProtocolVersion != 0x00010000 {
+		t.Errorf("ProtocolVersion got 0x%08x expected 0x00010000", ack.ProtocolVersion)
+	}
+}
+
+func TestInitCommandRequestFail(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.ExpectType(packet.PacketTypeInitCommandRequest).
+		Send(ptpiptest.Golden["InitFail"])
+	peer.Start()
+
+	_, err := packet.InitCommandRequest(conn, &packet.InitCommandRequestPacket{GUID: initiatorGUID, FriendlyName: "ptpip"})
+	if e, ok := err.(*packet.InitFailError); !ok || e.Reason != packet.InitFailReasonBusy {
+		t.Fatalf("got %v expected InitFailError busy", err)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitEventRequest(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Expect(ptpiptest.Golden["InitEventRequest"]).
+		Send(ptpiptest.Golden["InitEventAck"])
+	peer.Start()
+
+	if err := packet.InitEventRequest(conn, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOperationRequestNoData(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Expect(ptpiptest.Golden["OperationRequest"]).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeOK, 0))
+	peer.Start()
+
+	req := &packet.OperationRequestPacket{
+		DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn,
+		OperationCode: uint16(packet.OperationCodeOpenSession),
+		TransactionID: 0,
+		P1:            1,
+	}
+	data, err := packet.OperationRequest(conn, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Errorf("got data % x", data)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOperationRequestDataIn(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Expect(ptpiptest.OperationRequest(packet.DataPhaseInfoNoDataOrDataIn, uint16(packet.OperationCodeGetObject), 1, 0x10)).
+		Send(ptpiptest.Golden["StartData"], ptpiptest.Golden["Data"], ptpiptest.Golden["EndData"]).
+		Send(ptpiptest.Golden["OperationResponse"])
+	peer.Start()
+
+	req := &packet.OperationRequestPacket{
+		DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn,
+		OperationCode: uint16(packet.OperationCodeGetObject),
+		TransactionID: 1,
+		P1:            0x10,
+	}
+	data, resp, err := packet.OperationRequestWithResponse(conn, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{1, 2, 3, 4}) {
+		t.Errorf("got data % x", data)
+	}
+	if resp.P1 != 0x10 {
+		t.Errorf("P1 got 0x%x expected 0x10", resp.P1)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOperationRequestDataOut(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Expect(ptpiptest.OperationRequest(packet.DataPhaseInfoDataOut, uint16(packet.OperationCodeSendObject), 1)).
+		Expect(ptpiptest.Golden["StartData"]).
+		Expect(ptpiptest.Golden["Data"]).
+		Expect(ptpiptest.Golden["EndData"]).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeOK, 1))
+	peer.Start()
+
+	req := &packet.OperationRequestPacket{
+		DataPhaseInfo: packet.DataPhaseInfoDataOut,
+		OperationCode: uint16(packet.OperationCodeSendObject),
+		TransactionID: 1,
+	}
+	if _, err := packet.OperationRequest(conn, req, []byte{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOperationRequestErrorBeforeData(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.ExpectType(packet.PacketTypeOperationRequest).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeInvalidObjectHandle, 1))
+	peer.Start()
+
+	req := &packet.OperationRequestPacket{
+		DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn,
+		OperationCode: uint16(packet.OperationCodeGetObject),
+		TransactionID: 1,
+	}
+	_, err := packet.OperationRequest(conn, req, nil)
+	if e, ok := err.(*packet.ResponseError); !ok || e.Code != packet.ResponseCodeInvalidObjectHandle {
+		t.Fatalf("got %v expected ResponseError InvalidObjectHandle", err)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOperationRequestProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]byte
+	}{
+		{"transaction id", [][]byte{ptpiptest.StartData(2, 4)}},
+		{"data before StartData", [][]byte{ptpiptest.Data(1, []byte{1})}},
+		{"unexpected packet", [][]byte{ptpiptest.Golden["Event"]}},
+		{"data exceeds length", [][]byte{ptpiptest.StartData(1, 1), ptpiptest.Data(1, []byte{1, 2})}},
+		{"response before EndData", [][]byte{ptpiptest.StartData(1, 4), ptpiptest.OperationResponse(packet.ResponseCodeOK, 1)}},
+		{"response transaction id", [][]byte{ptpiptest.OperationResponse(packet.ResponseCodeOK, 2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peer, conn := ptpiptest.NewPeer()
+			peer.ExpectType(packet.PacketTypeOperationRequest).Send(tt.frames...)
+			peer.Start()
+
+			req := &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn, TransactionID: 1}
+			_, err := packet.OperationRequest(conn, req, nil)
+			if _, ok := err.(*packet.ProtocolError); !ok {
+				t.Fatalf("got %v expected ProtocolError", err)
+			}
+			conn.Close()
+			peer.Wait()
+		})
+	}
+}
+
+func TestOperationRequestCancelledByResponder(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.ExpectType(packet.PacketTypeOperationRequest).
+		Send(ptpiptest.StartData(1, 8), ptpiptest.Data(1, []byte{1, 2})).
+		Send(ptpiptest.Golden["Cancel"], ptpiptest.EndData(1, nil)).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeTransactionCancelled, 1))
+	peer.Start()
+
+	req := &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn, TransactionID: 1}
+	_, err := packet.OperationRequest(conn, req, nil)
+	if e, ok := err.(*packet.ResponseError); !ok || e.Code != packet.ResponseCodeTransactionCancelled {
+		t.Fatalf("got %v expected ResponseError TransactionCancelled", err)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	cPeer, cConn := ptpiptest.NewPeer()
+	cPeer.Expect(ptpiptest.Golden["Cancel"])
+	cPeer.Start()
+	ePeer, eConn := ptpiptest.NewPeer()
+	ePeer.Expect(ptpiptest.Event(uint16(packet.EventCodeCancelTransaction), 1, 0, 0, 0))
+	ePeer.Start()
+
+	if err := packet.Cancel(cConn, eConn, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cPeer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ePeer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// gatedConn holds the first Data packet written until release is closed.
+type gatedConn struct {
+	net.Conn
+	once    sync.Once
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (c *gatedConn) Write(b []byte) (int, error) {
+	if 8 <= len(b) && binary.LittleEndian.Uint32(b[4:]) == packet.PacketTypeData {
+		c.once.Do(func() {
+			close(c.writing)
+			<-c.release
+		})
+	}
+	return c.Conn.Write(b)
+}
+
+func TestCancelDataOut(t *testing.T) {
+	peer, pipe := ptpiptest.NewPeer()
+	peer.ExpectType(packet.PacketTypeOperationRequest).
+		Expect(ptpiptest.StartData(1, 3<<20)).
+		Expect(ptpiptest.Golden["Cancel"]).
+		ExpectType(packet.PacketTypeData).
+		Expect(ptpiptest.EndData(1, nil)).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeTransactionCancelled, 1))
+	peer.Start()
+	conn := &gatedConn{Conn: pipe, writing: make(chan struct{}), release: make(chan struct{})}
+
+	errc := make(chan error, 1)
+	go func() {
+		req := &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoDataOut, OperationCode: uint16(packet.OperationCodeSendObject), TransactionID: 1}
+		_, err := packet.OperationRequest(conn, req, make([]byte, 3<<20))
+		errc <- err
+	}()
+
+	// cancel while the first of three Data packets is being sent
+	<-conn.writing
+	if err := packet.Cancel(conn, nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	close(conn.release)
+
+	err := <-errc
+	if e, ok := err.(*packet.ResponseError); !ok || e.Code != packet.ResponseCodeTransactionCancelled {
+		t.Fatalf("got %v expected ResponseError TransactionCancelled", err)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecvEvent(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Send(ptpiptest.Golden["ProbeRequest"]).
+		Expect(ptpiptest.Golden["ProbeResponse"]).
+		Send(ptpiptest.Golden["Event"])
+	peer.Start()
+
+	e, err := packet.RecvEventPacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packet.EventCode(e.EventCode) != packet.EventCodeObjectAdded || e.P1 != 0x10 {
+		t.Errorf("got event 0x%04x P1 0x%x", e.EventCode, e.P1)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecvEventUnexpectedPacket(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Send(ptpiptest.Golden["StartData"])
+	peer.Start()
+
+	_, err := packet.RecvEvent(conn)
+	if _, ok := err.(*packet.ProtocolError); !ok {
+		t.Fatalf("got %v expected ProtocolError", err)
+	}
+	peer.Wait()
+}
+
+func TestProbe(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Expect(ptpiptest.Golden["ProbeRequest"])
+	peer.Start()
+
+	if err := packet.Probe(conn); err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+package ptpip
+
+import (
+	"github.com/takurooo/ptpip/packet"
+)
+
+// GetDevicePropDesc ...
+func (c *Client) GetDevicePropDesc(propCode uint16) (desc *packet.DevicePropDesc, err error) {
+	data, err := c.Transaction(uint16(packet.OperationCodeGetDevicePropDesc), packet.DataPhaseInfoNoDataOrDataIn, uint32(propCode), 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return packet.ParseDevicePropDesc(data)
+}
+
+// GetDevicePropValue returns the encoded value of a device property.
+// Decode it with packet.DecodeValue and the property's data type.
+func (c *Client) GetDevicePropValue(propCode uint16) (value []byte, err error) {
+	value, err = c.Transaction(uint16(packet.OperationCodeGetDevicePropValue), packet.DataPhaseInfoNoDataOrDataIn, uint32(propCode), 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// SetDevicePropValue sets a device property to an encoded value.
+// Encode it with packet.EncodeValue and the property's data type.
+func (c *Client) SetDevicePropValue(propCode uint16, value []byte) (err error) {
+	_, err = c.Transaction(uint16(packet.OperationCodeSetDevicePropValue), packet.DataPhaseInfoDataOut, uint32(propCode), 0, 0, 0, value)
+	return err
+}
@@ -0,0 +1,81 @@
+package ptpip
+
+import (
+	"net"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Protocol is the wire protocol used by Client on its connections.
+// The default is PTP-IP as implemented by package packet; vendor packages
+// whose devices deviate from it, such as a modified InitCommandRequest,
+// provide their own with SetProtocol.
+type Protocol interface {
+	// CommandPort and EventPort return the ports dialed by Connect, e.g. ":15740".
+	CommandPort() string
+	EventPort() string
+
+	// InitCommand performs the handshake on the command connection.
+	InitCommand(conn net.Conn, ini *Initiator) (connectionNumber uint32, err error)
+	// InitEvent performs the handshake on the event connection.
+	InitEvent(conn net.Conn, connectionNumber uint32) error
+
+	// OperationRequest performs one transaction on the command connection.
+	// See packet.OperationRequestWithResponse.
+	OperationRequest(conn net.Conn, req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error)
+	// RecvEvent waits for the next event on the event connection.
+	RecvEvent(conn net.Conn) (e *packet.EventPacket, err error)
+	// Cancel cancels the transaction in progress. It is called while
+	// OperationRequest runs in another goroutine and must not read.
+	// See packet.Cancel.
+	Cancel(cConn, eConn net.Conn, transactionID uint32) error
+}
+
+// Prober is implemented by a Protocol that can probe the responder on the
+// event connection. The keepalive of a Client whose Protocol is not a Prober
+// relies on TCP keepalive only. See KeepaliveOptions.
+type Prober interface {
+	// Probe sends a request the responder answers on the event connection.
+	Probe(eConn net.Conn) error
+}
+
+// ptpipProtocol is the PTP-IP Protocol.
+type ptpipProtocol struct{}
+
+func (ptpipProtocol) CommandPort() string { return port }
+
+func (ptpipProtocol) EventPort() string { return port }
+
+func (ptpipProtocol) InitCommand(conn net.Conn, ini *Initiator) (connectionNumber uint32, err error) {
+	initCommandRequestPacket := &(packet.InitCommandRequestPacket{
+		GUID:            ini.GUID,
+		FriendlyName:    ini.FriendlyName,
+		ProtocolVersion: ini.ProtocolVersion,
+	})
+
+	ackPacket, err := packet.InitCommandRequest(conn, initCommandRequestPacket)
+	if err != nil {
+		return 0, err
+	}
+	return ackPacket.ConnectionNumber, nil
+}
+
+func (ptpipProtocol) InitEvent(conn net.Conn, connectionNumber uint32) error {
+	return packet.InitEventRequest(conn, connectionNumber)
+}
+
+func (ptpipProtocol) OperationRequest(conn net.Conn, req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	return packet.OperationRequestWithResponse(conn, req, sendData)
+}
+
+func (ptpipProtocol) RecvEvent(conn net.Conn) (e *packet.EventPacket, err error) {
+	return packet.RecvEventPacket(conn)
+}
+
+func (ptpipProtocol) Cancel(cConn, eConn net.Conn, transactionID uint32) error {
+	return packet.Cancel(cConn, eConn, transactionID)
+}
+
+func (ptpipProtocol) Probe(eConn net.Conn) error {
+	return packet.Probe(eConn)
+}
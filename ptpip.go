@@ -1,16 +1,23 @@
 package ptpip
 
 import (
+	"errors"
 	"fmt"
-	"net"
+	"sync"
 
 	"github.com/takurooo/ptpip/packet"
 )
 
 const (
 	port string = ":15740"
+
+	// eventBufferSize is the number of events buffered per subscriber
+	eventBufferSize = 64
 )
 
+// ErrNoTransaction is returned by Cancel when no transaction is in progress.
+var ErrNoTransaction = errors.New("no transaction in progress")
+
 // Initiator ...
 type Initiator struct {
 	GUID            []byte
@@ -20,29 +27,96 @@ type Initiator struct {
 
 // Client ...
 type Client struct {
-	cConn net.Conn
-	eConn net.Conn
-	host  string
-	ini   *Initiator
-	stop  chan struct{}
-	done  chan struct{}
+	t             Transport
+	stop          chan struct{}
+	done          chan struct{}
+	transactionID uint32
+	vendor        *Vendor
+
+	// mu serializes transactions on the command connection
+	mu sync.Mutex
+
+	// activeMu guards the transaction in progress, read by Cancel without mu
+	activeMu      sync.Mutex
+	active        bool
+	activeTransID uint32
+
+	subsMu sync.Mutex
+	subs   map[chan *packet.EventPacket]struct{}
+
+	keepaliveOpts *KeepaliveOptions
+	onState       func(state ConnState, err error)
+	stateMu       sync.Mutex
+	state         ConnState
 }
 
 func (c *Client) eventReciever() {
 	defer func() {
+		c.closeSubscriptions()
 		close(c.done)
 	}()
 
 	for {
-		eventCode, err := packet.RecvEvent(c.eConn)
-		fmt.Println(eventCode, err)
+		e, err := c.t.RecvEvent()
+		if err != nil {
+			select {
+			case <-c.stop:
+			default:
+				// the error is reported to NotifyState as the cause of
+				// StateDead, unless the responder was already lost
+				c.dead(err)
+			}
+			return
+		}
+
+		c.publishEvent(e)
+	}
+}
+
+// Subscribe returns a channel receiving the events of the event connection
+// and a function that ends the subscription. Events are dropped for a
+// subscriber that does not keep up. The channel is closed when the event
+// connection closes or cancel is called.
+func (c *Client) Subscribe() (events <-chan *packet.EventPacket, cancel func()) {
+	ch := make(chan *packet.EventPacket, eventBufferSize)
+
+	c.subsMu.Lock()
+	if c.subs == nil {
+		c.subs = make(map[chan *packet.EventPacket]struct{})
+	}
+	c.subs[ch] = struct{}{}
+	c.subsMu.Unlock()
 
+	cancel = func() {
+		c.subsMu.Lock()
+		defer c.subsMu.Unlock()
+		if _, ok := c.subs[ch]; ok {
+			delete(c.subs, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+func (c *Client) publishEvent(e *packet.EventPacket) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	for ch := range c.subs {
 		select {
-		case <-c.stop:
-			return
+		case ch <- e:
 		default:
 		}
+	}
+}
+
+func (c *Client) closeSubscriptions() {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
 
+	for ch := range c.subs {
+		delete(c.subs, ch)
+		close(ch)
 	}
 }
 
@@ -54,62 +128,60 @@ func NewClient(host string, initiator *Initiator) *Client {
 		initiator.FriendlyName = "hogehoge"
 		initiator.ProtocolVersion = uint32(0x00010000)
 	}
-	return &Client{cConn: nil, eConn: nil, host: host, ini: initiator, stop: make(chan struct{}), done: make(chan struct{})}
+	return NewClientTransport(&ipTransport{host: host, ini: initiator, proto: ptpipProtocol{}})
+}
+
+// NewClientTransport returns a Client using t, e.g. a usb.Transport.
+func NewClientTransport(t Transport) *Client {
+	return &Client{t: t, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+// SetProtocol replaces the PTP-IP protocol used by Connect and OperationRequest.
+// It must be called before Connect and only for a Client created by NewClient.
+func (c *Client) SetProtocol(p Protocol) {
+	if t, ok := c.t.(*ipTransport); ok {
+		t.proto = p
+	}
 }
 
 // Disconnect ...
 func (c *Client) Disconnect() (err error) {
 
-	if err = c.cConn.Close(); err != nil {
-		return err
-	}
-	if err = c.eConn.Close(); err != nil {
-		return err
-	}
+	prev := c.setState(StateClosed, nil)
+
+	err = c.t.Close()
 
 	// TCPのコネクションを閉じないとgoroutineがTCPのリード待ちから返ってこれないので
 	// TCPのコネクションを閉じてからchannelで終了指示を送る
 	close(c.stop)
 	<-c.done
 
-	return nil
-}
-
-// Connect ...
-func (c *Client) Connect() (err error) {
-	addr := c.host + port
-	// ---------------------------------------
-	// establish connection for ptp-ip command
-	// ---------------------------------------
-	c.cConn, err = net.Dial("tcp", addr)
-	if err != nil {
-		return err
+	// the connections of a dead client are already closed
+	if prev == StateDead {
+		return nil
 	}
-
-	initCommandRequestPacket := &(packet.InitCommandRequestPacket{
-		GUID:            c.ini.GUID,
-		FriendlyName:    c.ini.FriendlyName,
-		ProtocolVersion: c.ini.ProtocolVersion,
-	})
-
-	ackPacket, err := packet.InitCommandRequest(c.cConn, initCommandRequestPacket)
 	if err != nil {
 		return err
 	}
 
-	// ---------------------------------------
-	// establish connection for ptp-ip event
-	// ---------------------------------------
-	c.eConn, err = net.Dial("tcp", addr)
+	return nil
+}
+
+// Connect ...
+func (c *Client) Connect() (err error) {
+	err = c.t.Connect()
 	if err != nil {
 		return err
 	}
 
-	err = packet.InitEventRequest(c.eConn, ackPacket.ConnectionNumber)
-	if err != nil {
-		return err
+	if c.keepaliveOpts != nil {
+		if err = c.startKeepalive(); err != nil {
+			c.t.Close()
+			return err
+		}
 	}
 
+	c.setState(StateConnected, nil)
 	go c.eventReciever()
 
 	return nil
@@ -117,7 +189,14 @@ func (c *Client) Connect() (err error) {
 
 // OperationRequest ...
 func (c *Client) OperationRequest(opCode uint16, phase uint32, transactionID uint32, p1, p2, p3, p4 uint32, sendData []byte) (recvData []byte, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	recvData, _, err = c.operationRequest(opCode, phase, transactionID, p1, p2, p3, p4, sendData)
+	return recvData, err
+}
+
+func (c *Client) operationRequest(opCode uint16, phase uint32, transactionID uint32, p1, p2, p3, p4 uint32, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
 	req := &packet.OperationRequestPacket{
 		DataPhaseInfo: phase,
 		OperationCode: opCode,
@@ -128,10 +207,138 @@ func (c *Client) OperationRequest(opCode uint16, phase uint32, transactionID uin
 		P4:            p4,
 	}
 
-	recvData, err = packet.OperationRequest(c.cConn, req, sendData)
+	return c.operationRequestPacket(req, sendData)
+}
+
+func (c *Client) operationRequestPacket(req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	c.activeMu.Lock()
+	c.active = true
+	c.activeTransID = req.TransactionID
+	c.activeMu.Unlock()
+
+	defer func() {
+		c.activeMu.Lock()
+		c.active = false
+		c.activeMu.Unlock()
+	}()
+
+	recvData, resp, err = c.t.OperationRequest(req, sendData)
+	if _, ok := err.(*packet.ProtocolError); ok {
+		// the connection is out of sync, later transactions fail instead of
+		// reading the rest of this one
+		c.dead(err)
+	}
+	return recvData, resp, err
+}
+
+// Cancel cancels the transaction in progress in another goroutine. The
+// cancelled transaction drains the rest of its data phase and returns the
+// ResponseError with packet.ResponseCodeTransactionCancelled, after which the
+// session can be used as before. A transaction that completes before the
+// responder handles the Cancel returns its result.
+func (c *Client) Cancel() (err error) {
+	c.activeMu.Lock()
+	active, transactionID := c.active, c.activeTransID
+	c.activeMu.Unlock()
+
+	if !active {
+		return ErrNoTransaction
+	}
+	// the Cancel is sent without activeMu, the transaction can complete
+	// while the Transport sends it
+	return c.t.Cancel(transactionID)
+}
+
+// GetDeviceInfo requests DeviceInfo and selects the registered vendor
+// extension matching the device. See Vendor.
+func (c *Client) GetDeviceInfo() (info *packet.DeviceInfo, err error) {
+	data, err := c.Transaction(uint16(packet.OperationCodeGetDeviceInfo), packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err = packet.ParseDeviceInfo(data)
 	if err != nil {
 		return nil, err
 	}
 
+	c.mu.Lock()
+	c.vendor = LookupVendor(info)
+	c.mu.Unlock()
+
+	return info, nil
+}
+
+// Vendor returns the vendor extension selected by GetDeviceInfo, or nil.
+func (c *Client) Vendor() *Vendor {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.vendor
+}
+
+// OpenSession ...
+func (c *Client) OpenSession(sessionID uint32) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// OpenSession is always issued with TransactionID 0
+	c.transactionID = 0
+	_, _, err = c.operationRequest(uint16(packet.OperationCodeOpenSession), packet.DataPhaseInfoNoDataOrDataIn, c.transactionID, sessionID, 0, 0, 0, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CloseSession ...
+func (c *Client) CloseSession() (err error) {
+	_, err = c.Transaction(uint16(packet.OperationCodeCloseSession), packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Transaction issues an operation with the next TransactionID of the session.
+// It is safe to call from multiple goroutines.
+func (c *Client) Transaction(opCode uint16, phase uint32, p1, p2, p3, p4 uint32, sendData []byte) (recvData []byte, err error) {
+	recvData, _, err = c.TransactionWithResponse(opCode, phase, p1, p2, p3, p4, sendData)
 	return recvData, err
 }
+
+// TransactionWithResponse is Transaction that also returns the OperationResponse.
+// See packet.OperationRequestWithResponse.
+func (c *Client) TransactionWithResponse(opCode uint16, phase uint32, p1, p2, p3, p4 uint32, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.transactionID++
+	return c.operationRequest(opCode, phase, c.transactionID, p1, p2, p3, p4, sendData)
+}
+
+// TransactionWithParams is TransactionWithResponse with up to five
+// parameters, for operations using Parameter5 such as MTP GetObjectPropList.
+func (c *Client) TransactionWithParams(opCode uint16, phase uint32, params []uint32, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	if 5 < len(params) {
+		return nil, nil, fmt.Errorf("invalid params len 5 < %d", len(params))
+	}
+	var p [5]uint32
+	copy(p[:], params)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.transactionID++
+	req := &packet.OperationRequestPacket{
+		DataPhaseInfo: phase,
+		OperationCode: opCode,
+		TransactionID: c.transactionID,
+		P1:            p[0],
+		P2:            p[1],
+		P3:            p[2],
+		P4:            p[3],
+		P5:            p[4],
+	}
+	return c.operationRequestPacket(req, sendData)
+}
@@ -0,0 +1,185 @@
+package ptpip_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/ptpiptest"
+)
+
+// connTransport runs transactions on the command connection of a
+// ptpiptest.Peer and cancels them with packet.Cancel. It has no event
+// connection.
+type connTransport struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (t *connTransport) Connect() error { return nil }
+
+func (t *connTransport) Close() error {
+	t.once.Do(func() {
+		t.conn.Close()
+		close(t.closed)
+	})
+	return nil
+}
+
+func (t *connTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	return packet.OperationRequestWithResponse(t.conn, req, sendData)
+}
+
+func (t *connTransport) RecvEvent() (*packet.EventPacket, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *connTransport) Cancel(transactionID uint32) error {
+	return packet.Cancel(t.conn, nil, transactionID)
+}
+
+// gatedConn holds the first Data packet written until release is closed.
+type gatedConn struct {
+	net.Conn
+	once    sync.Once
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (c *gatedConn) Write(b []byte) (int, error) {
+	if 8 <= len(b) && binary.LittleEndian.Uint32(b[4:]) == packet.PacketTypeData {
+		c.once.Do(func() {
+			close(c.writing)
+			<-c.release
+		})
+	}
+	return c.Conn.Write(b)
+}
+
+func TestCancelNoTransaction(t *testing.T) {
+	peer, conn := ptpiptest.NewPeer()
+	peer.Start()
+	c := ptpip.NewClientTransport(&connTransport{conn: conn, closed: make(chan struct{})})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	if err := c.Cancel(); err != ptpip.ErrNoTransaction {
+		t.Errorf("got %v expected ErrNoTransaction", err)
+	}
+}
+
+func TestCancelDataOut(t *testing.T) {
+	peer, pipe := ptpiptest.NewPeer()
+	peer.ExpectType(packet.PacketTypeOperationRequest).
+		Expect(ptpiptest.StartData(1, 3<<20)).
+		Expect(ptpiptest.Golden["Cancel"]).
+		ExpectType(packet.PacketTypeData).
+		Expect(ptpiptest.EndData(1, nil)).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeTransactionCancelled, 1)).
+		ExpectType(packet.PacketTypeOperationRequest).
+		Send(ptpiptest.OperationResponse(packet.ResponseCodeOK, 2))
+	peer.Start()
+	conn := &gatedConn{Conn: pipe, writing: make(chan struct{}), release: make(chan struct{})}
+	c := ptpip.NewClientTransport(&connTransport{conn: conn, closed: make(chan struct{})})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Transaction(uint16(packet.OperationCodeSendObject), packet.DataPhaseInfoDataOut, 0, 0, 0, 0, make([]byte, 3<<20))
+		errc <- err
+	}()
+
+	<-conn.writing
+	if err := c.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	close(conn.release)
+
+	err := <-errc
+	if e, ok := err.(*packet.ResponseError); !ok || e.Code != packet.ResponseCodeTransactionCancelled {
+		t.Fatalf("got %v expected ResponseError TransactionCancelled", err)
+	}
+	// the session is usable after the cancel
+	if _, err = c.Transaction(uint16(packet.OperationCodeGetStorageIDs), packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// slowCancelTransport completes the transaction while its Cancel is being
+// sent: Cancel returns only after the transaction returned to the caller.
+type slowCancelTransport struct {
+	started   chan struct{}
+	cancelled chan uint32
+	txDone    chan struct{}
+	closed    chan struct{}
+}
+
+func (t *slowCancelTransport) Connect() error { return nil }
+
+func (t *slowCancelTransport) Close() error {
+	close(t.closed)
+	return nil
+}
+
+func (t *slowCancelTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	close(t.started)
+	<-t.cancelled
+	return nil, &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}, nil
+}
+
+func (t *slowCancelTransport) RecvEvent() (*packet.EventPacket, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *slowCancelTransport) Cancel(transactionID uint32) error {
+	t.cancelled <- transactionID
+	select {
+	case <-t.txDone:
+		return nil
+	case <-time.After(5 * time.Second):
+		return errors.New("transaction blocked by Cancel")
+	}
+}
+
+func TestCancelCompletingTransaction(t *testing.T) {
+	tr := &slowCancelTransport{
+		started:   make(chan struct{}),
+		cancelled: make(chan uint32, 1),
+		txDone:    make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	go func() {
+		defer close(tr.txDone)
+		if _, err := c.Transaction(uint16(packet.OperationCodeGetStorageIDs), packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	<-tr.started
+	if err := c.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,152 @@
+package ptpiptest
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Frame returns a PTP-IP packet of packetType with body.
+func Frame(packetType uint32, body []byte) []byte {
+	b := make([]byte, 8+len(body))
+	binary.LittleEndian.PutUint32(b[0:], uint32(len(b)))
+	binary.LittleEndian.PutUint32(b[4:], packetType)
+	copy(b[8:], body)
+	return b
+}
+
+// InitCommandRequest ...
+func InitCommandRequest(guid []byte, friendlyName string, protocolVersion uint32) []byte {
+	body := append([]byte{}, guid...)
+	body = append(body, encodeString(friendlyName)...)
+	body = appendU32(body, protocolVersion)
+	return Frame(packet.PacketTypeInitCommandRequest, body)
+}
+
+// InitCommandAck ...
+func InitCommandAck(connectionNumber uint32, guid []byte, friendlyName string, protocolVersion uint32) []byte {
+	body := appendU32(nil, connectionNumber)
+	body = append(body, guid...)
+	body = append(body, encodeString(friendlyName)...)
+	body = appendU32(body, protocolVersion)
+	return Frame(packet.PacketTypeInitCommandAck, body)
+}
+
+// InitEventRequest ...
+func InitEventRequest(connectionNumber uint32) []byte {
+	return Frame(packet.PacketTypeInitEventRequest, appendU32(nil, connectionNumber))
+}
+
+// InitEventAck ...
+func InitEventAck() []byte {
+	return Frame(packet.PacketTypeInitEventAck, nil)
+}
+
+// InitFail ...
+func InitFail(reason uint32) []byte {
+	return Frame(packet.PacketTypeInitFail, appendU32(nil, reason))
+}
+
+// OperationRequest returns an OperationRequest with params, padded to the
+// four parameters sent by the packet package. A fifth parameter is added
+// if it is not zero.
+func OperationRequest(dataPhaseInfo uint32, operationCode uint16, transactionID uint32, params ...uint32) []byte {
+	body := appendU32(nil, dataPhaseInfo)
+	body = appendU16(body, operationCode)
+	body = appendU32(body, transactionID)
+	for i := 0; i < 4; i++ {
+		var p uint32
+		if i < len(params) {
+			p = params[i]
+		}
+		body = appendU32(body, p)
+	}
+	if 4 < len(params) && params[4] != 0 {
+		body = appendU32(body, params[4])
+	}
+	return Frame(packet.PacketTypeOperationRequest, body)
+}
+
+// OperationResponse returns an OperationResponse with as many parameters as params.
+func OperationResponse(responseCode uint16, transactionID uint32, params ...uint32) []byte {
+	body := appendU16(nil, responseCode)
+	body = appendU32(body, transactionID)
+	for _, p := range params {
+		body = appendU32(body, p)
+	}
+	return Frame(packet.PacketTypeOperationResponse, body)
+}
+
+// Event returns an Event with as many parameters as params.
+func Event(eventCode uint16, transactionID uint32, params ...uint32) []byte {
+	body := appendU16(nil, eventCode)
+	body = appendU32(body, transactionID)
+	for _, p := range params {
+		body = appendU32(body, p)
+	}
+	return Frame(packet.PacketTypeEvent, body)
+}
+
+// StartData ...
+func StartData(transactionID uint32, totalDataLength uint64) []byte {
+	body := appendU32(nil, transactionID)
+	body = appendU64(body, totalDataLength)
+	return Frame(packet.PacketTypeStartData, body)
+}
+
+// Data ...
+func Data(transactionID uint32, payload []byte) []byte {
+	return Frame(packet.PacketTypeData, append(appendU32(nil, transactionID), payload...))
+}
+
+// EndData ...
+func EndData(transactionID uint32, payload []byte) []byte {
+	return Frame(packet.PacketTypeEndData, append(appendU32(nil, transactionID), payload...))
+}
+
+// Cancel ...
+func Cancel(transactionID uint32) []byte {
+	return Frame(packet.PacketTypeCancel, appendU32(nil, transactionID))
+}
+
+// ProbeRequest ...
+func ProbeRequest() []byte {
+	return Frame(packet.PacketTypeProbeRequest, nil)
+}
+
+// ProbeResponse ...
+func ProbeResponse() []byte {
+	return Frame(packet.PacketTypeProbeResponse, nil)
+}
+
+// DataIn returns the frames of a data phase sending data in one Data packet
+// followed by an empty EndData, as the packet package does.
+func DataIn(transactionID uint32, data []byte) [][]byte {
+	return [][]byte{
+		StartData(transactionID, uint64(len(data))),
+		Data(transactionID, data),
+		EndData(transactionID, nil),
+	}
+}
+
+// encodeString encodes s as null terminated UTF-16LE.
+func encodeString(s string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = appendU16(b, c)
+	}
+	return appendU16(b, 0)
+}
+
+func appendU16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendU32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendU64(b []byte, v uint64) []byte {
+	return appendU32(appendU32(b, uint32(v)), uint32(v>>32))
+}
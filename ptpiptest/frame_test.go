@@ -0,0 +1,73 @@
+package ptpiptest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+func TestFramesMatchGolden(t *testing.T) {
+	guid := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F}
+	respGUID := []byte{0xF0, 0xF1, 0xF2, 0xF3, 0xF4, 0xF5, 0xF6, 0xF7, 0xF8, 0xF9, 0xFA, 0xFB, 0xFC, 0xFD, 0xFE, 0xFF}
+
+	frames := map[string][]byte{
+		"InitCommandRequest": InitCommandRequest(guid, "ptpip", 0x00010000),
+		"InitCommandAck":     InitCommandAck(1, respGUID, "cam", 0x00010000),
+		"InitEventRequest":   InitEventRequest(1),
+		"InitEventAck":       InitEventAck(),
+		"InitFail":           InitFail(packet.InitFailReasonBusy),
+		"OperationRequest":   OperationRequest(packet.DataPhaseInfoNoDataOrDataIn, uint16(packet.OperationCodeOpenSession), 0, 1),
+		"OperationResponse":  OperationResponse(packet.ResponseCodeOK, 1, 0x10),
+		"Event":              Event(uint16(packet.EventCodeObjectAdded), 0, 0x10),
+		"StartData":          StartData(1, 4),
+		"Data":               Data(1, []byte{1, 2, 3, 4}),
+		"Cancel":             Cancel(1),
+		"EndData":            EndData(1, nil),
+		"ProbeRequest":       ProbeRequest(),
+		"ProbeResponse":      ProbeResponse(),
+	}
+
+	if len(frames) != len(Golden) {
+		t.Errorf("got %d frames, %d golden frames", len(frames), len(Golden))
+	}
+	for name, frame := range frames {
+		golden, ok := Golden[name]
+		if !ok {
+			t.Errorf("%s: no golden frame", name)
+			continue
+		}
+		if !bytes.Equal(frame, golden) {
+			t.Errorf("%s: got\n% x\nexpected\n% x", name, frame, golden)
+		}
+	}
+}
+
+func TestPeerReportsMismatch(t *testing.T) {
+	peer, conn := NewPeer()
+	peer.Expect(ProbeRequest())
+	peer.Start()
+
+	conn.Write(ProbeResponse())
+	if err := peer.Wait(); err == nil {
+		t.Fatal("expected mismatch error")
+	}
+}
+
+func TestPeerExpectType(t *testing.T) {
+	peer, conn := NewPeer()
+	peer.ExpectType(packet.PacketTypeCancel).Send(ProbeRequest())
+	peer.Start()
+
+	conn.Write(Cancel(7))
+	frame, err := ReadFrame(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frame, Golden["ProbeRequest"]) {
+		t.Errorf("got % x", frame)
+	}
+	if err := peer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,84 @@
+package ptpiptest
+
+// Golden holds a reference frame of every PTP-IP packet type, keyed by the
+// name of the packet type. The frames are literal bytes laid out from the
+// PTP-IP specification, so that they check the frame functions of this
+// package as well as the encoders of the packet package.
+var Golden = map[string][]byte{
+	// GUID 00..0F, FriendlyName "ptpip", ProtocolVersion 0x00010000
+	"InitCommandRequest": {
+		0x28, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x70, 0x00, 0x74, 0x00, 0x70, 0x00, 0x69, 0x00,
+		0x70, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00,
+	},
+	// ConnectionNumber 1, GUID F0..FF, FriendlyName "cam", ProtocolVersion 0x00010000
+	"InitCommandAck": {
+		0x28, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0xf0, 0xf1, 0xf2, 0xf3,
+		0xf4, 0xf5, 0xf6, 0xf7, 0xf8, 0xf9, 0xfa, 0xfb,
+		0xfc, 0xfd, 0xfe, 0xff, 0x63, 0x00, 0x61, 0x00,
+		0x6d, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00,
+	},
+	// ConnectionNumber 1
+	"InitEventRequest": {
+		0x0c, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00,
+	},
+	"InitEventAck": {
+		0x08, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00,
+	},
+	// Reason Busy
+	"InitFail": {
+		0x0c, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00,
+		0x02, 0x00, 0x00, 0x00,
+	},
+	// NoDataOrDataIn OpenSession, TransactionID 0, SessionID 1
+	"OperationRequest": {
+		0x22, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x02, 0x10, 0x00, 0x00,
+		0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00,
+	},
+	// OK, TransactionID 1, P1 0x10
+	"OperationResponse": {
+		0x12, 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00,
+		0x01, 0x20, 0x01, 0x00, 0x00, 0x00, 0x10, 0x00,
+		0x00, 0x00,
+	},
+	// ObjectAdded, TransactionID 0, P1 0x10
+	"Event": {
+		0x12, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00,
+		0x02, 0x40, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00,
+		0x00, 0x00,
+	},
+	// TransactionID 1, TotalDataLength 4
+	"StartData": {
+		0x14, 0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	},
+	// TransactionID 1, payload 01 02 03 04
+	"Data": {
+		0x10, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+	},
+	// TransactionID 1
+	"Cancel": {
+		0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00,
+	},
+	// TransactionID 1, no payload
+	"EndData": {
+		0x0c, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00,
+	},
+	"ProbeRequest": {
+		0x08, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x00, 0x00,
+	},
+	"ProbeResponse": {
+		0x08, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x00, 0x00,
+	},
+}
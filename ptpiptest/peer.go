@@ -0,0 +1,141 @@
+// Package ptpiptest provides a scriptable fake PTP-IP responder for tests.
+//
+// A Peer is one end of a net.Pipe. The test declares the frames the
+// initiator is expected to send and the canned frames the peer answers,
+// then runs the code under test on the other end:
+//
+//	peer, conn := ptpiptest.NewPeer()
+//	peer.Expect(ptpiptest.OperationRequest(packet.DataPhaseInfoNoDataOrDataIn, 0x1002, 0, 1)).
+//		Send(ptpiptest.OperationResponse(packet.ResponseCodeOK, 0))
+//	peer.Start()
+//	_, err := packet.OperationRequest(conn, req, nil)
+//	if err := peer.Wait(); err != nil {
+//		t.Fatal(err)
+//	}
+//
+// The frame functions encode every PTP-IP packet type independently of the
+// packet package, and Golden holds reference frames of each of them.
+package ptpiptest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+type stepKind int
+
+const (
+	stepExpect stepKind = iota
+	stepExpectType
+	stepSend
+)
+
+type step struct {
+	kind       stepKind
+	frame      []byte
+	packetType uint32
+}
+
+// Peer is a fake responder running a script of expected and sent frames.
+type Peer struct {
+	conn  net.Conn
+	steps []step
+	done  chan error
+}
+
+// NewPeer returns a Peer and the initiator end of its connection.
+func NewPeer() (p *Peer, conn net.Conn) {
+	peerConn, conn := net.Pipe()
+	return &Peer{conn: peerConn, done: make(chan error, 1)}, conn
+}
+
+// NewPeerConn returns a Peer on conn, e.g. a connection accepted from a
+// net.Listener the code under test dials.
+func NewPeerConn(conn net.Conn) *Peer {
+	return &Peer{conn: conn, done: make(chan error, 1)}
+}
+
+// Expect adds a step reading the next frame and comparing it to frame.
+func (p *Peer) Expect(frame []byte) *Peer {
+	p.steps = append(p.steps, step{kind: stepExpect, frame: frame})
+	return p
+}
+
+// ExpectType adds a step reading the next frame and checking only its packet type.
+func (p *Peer) ExpectType(packetType uint32) *Peer {
+	p.steps = append(p.steps, step{kind: stepExpectType, packetType: packetType})
+	return p
+}
+
+// Send adds a step writing each frame.
+func (p *Peer) Send(frames ...[]byte) *Peer {
+	for _, f := range frames {
+		p.steps = append(p.steps, step{kind: stepSend, frame: f})
+	}
+	return p
+}
+
+// Start runs the script in a goroutine. The connection is closed when the
+// script ends or fails.
+func (p *Peer) Start() {
+	go func() {
+		err := p.run()
+		p.conn.Close()
+		p.done <- err
+	}()
+}
+
+// Wait waits for the end of the script and returns the first mismatch.
+func (p *Peer) Wait() error {
+	return <-p.done
+}
+
+func (p *Peer) run() error {
+	for i, s := range p.steps {
+		switch s.kind {
+		case stepSend:
+			if _, err := p.conn.Write(s.frame); err != nil {
+				return fmt.Errorf("step %d: send: %v", i, err)
+			}
+		case stepExpect:
+			frame, err := ReadFrame(p.conn)
+			if err != nil {
+				return fmt.Errorf("step %d: expect: %v", i, err)
+			}
+			if !bytes.Equal(frame, s.frame) {
+				return fmt.Errorf("step %d: got frame\n% x\nexpected\n% x", i, frame, s.frame)
+			}
+		case stepExpectType:
+			frame, err := ReadFrame(p.conn)
+			if err != nil {
+				return fmt.Errorf("step %d: expect: %v", i, err)
+			}
+			if packetType := binary.LittleEndian.Uint32(frame[4:]); packetType != s.packetType {
+				return fmt.Errorf("step %d: got packet type 0x%08x expected 0x%08x", i, packetType, s.packetType)
+			}
+		}
+	}
+	return nil
+}
+
+// ReadFrame reads one PTP-IP packet from r.
+func ReadFrame(r io.Reader) (frame []byte, err error) {
+	header := make([]byte, 8)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(header)
+	if length < 8 {
+		return nil, fmt.Errorf("invalid packet len 0x%x", length)
+	}
+
+	frame = make([]byte, length)
+	copy(frame, header)
+	if _, err = io.ReadFull(r, frame[8:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
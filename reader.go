@@ -0,0 +1,96 @@
+package ptpip
+
+import (
+	"errors"
+	"io"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// ObjectReader reads an object with GetPartialObject. It implements
+// io.Reader, io.Seeker and io.ReaderAt, so it can be passed to
+// http.ServeContent or io.Copy without loading the whole object.
+type ObjectReader struct {
+	c      *Client
+	handle uint32
+	info   *packet.ObjectInfo
+	size   int64
+	off    int64
+}
+
+// NewObjectReader returns an ObjectReader for the object. Its size is taken from ObjectInfo.
+func (c *Client) NewObjectReader(handle uint32) (r *ObjectReader, err error) {
+	info, err := c.GetObjectInfo(handle)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectReader{c: c, handle: handle, info: info, size: int64(info.ObjectCompressedSize)}, nil
+}
+
+// Info returns the ObjectInfo of the object.
+func (r *ObjectReader) Info() *packet.ObjectInfo {
+	return r.info
+}
+
+// Size ...
+func (r *ObjectReader) Size() int64 {
+	return r.size
+}
+
+// Read ...
+func (r *ObjectReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadAt(p, r.off)
+	r.off += int64(n)
+	return n, err
+}
+
+// ReadAt ...
+func (r *ObjectReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("ptpip: ObjectReader.ReadAt: negative offset")
+	}
+	if r.size <= off {
+		return 0, io.EOF
+	}
+
+	for n < len(p) && off < r.size {
+		want := len(p) - n
+		if int64(partialObjectChunkSize) < int64(want) {
+			want = int(partialObjectChunkSize)
+		}
+		// off < size, which is 32 bits like the offset of GetPartialObject
+		data, err := r.c.GetPartialObject(r.handle, uint32(off), uint32(want))
+		if err != nil {
+			return n, err
+		}
+		if len(data) == 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		m := copy(p[n:], data)
+		n += m
+		off += int64(m)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek ...
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("ptpip: ObjectReader.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("ptpip: ObjectReader.Seek: negative position")
+	}
+	r.off = offset
+	return offset, nil
+}
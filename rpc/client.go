@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/takurooo/ptpip/rpc/pb"
+	"google.golang.org/grpc"
+)
+
+// Client is a stub for a remote Server. The generated pb.CameraClient
+// methods are available directly; DownloadObject and UploadObject wrap the
+// streaming calls.
+type Client struct {
+	pb.CameraClient
+	conn *grpc.ClientConn
+}
+
+// Dial connects to the Server at target. See grpc.NewClient for opts, e.g.
+// grpc.WithTransportCredentials.
+func Dial(target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{CameraClient: pb.NewCameraClient(conn), conn: conn}, nil
+}
+
+// Close ...
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// DownloadObject writes the object to w starting at offset and returns the
+// number of bytes written. Pass the size of a partial download as offset to
+// resume it.
+func (c *Client) DownloadObject(ctx context.Context, handle uint32, w io.WriterAt, offset int64) (n int64, err error) {
+	stream, err := c.CameraClient.DownloadObject(ctx, &pb.DownloadObjectRequest{Handle: handle, Offset: uint64(offset)})
+	if err != nil {
+		return 0, err
+	}
+
+	var size uint64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		size = chunk.Size
+		m, err := w.WriteAt(chunk.Data, int64(chunk.Offset))
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	if uint64(offset+n) != size {
+		return n, fmt.Errorf("invalid object size %d expected %d", offset+n, size)
+	}
+	return n, nil
+}
+
+// UploadObject sends info followed by the data read from r and returns the
+// handle of the new object. ObjectCompressedSize is set by the server.
+func (c *Client) UploadObject(ctx context.Context, info *pb.ObjectInfo, r io.Reader) (resp *pb.UploadObjectResponse, err error) {
+	// canceling ends the call without the server creating the object
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.CameraClient.UploadObject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stream.Send(&pb.UploadObjectRequest{Part: &pb.UploadObjectRequest_Info{Info: info}})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			err = stream.Send(&pb.UploadObjectRequest{Part: &pb.UploadObjectRequest_Data{Data: data}})
+			if err == io.EOF {
+				// the server ended the call, CloseAndRecv returns its status
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return nil, rerr
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
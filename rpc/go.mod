@@ -0,0 +1,20 @@
+module github.com/takurooo/ptpip/rpc
+
+go 1.25.0
+
+require (
+	github.com/takurooo/ptpip v0.0.0
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/takurooo/binaryio v0.0.0-20200906093630-233bbf96d575 // indirect
+	github.com/takurooo/swriter v0.0.0-20200911015132-6c8cdd64923e // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+)
+
+replace github.com/takurooo/ptpip => ../
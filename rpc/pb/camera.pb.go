@@ -0,0 +1,2125 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: camera.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetDeviceInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeviceInfoRequest) Reset() {
+	*x = GetDeviceInfoRequest{}
+	mi := &file_camera_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeviceInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeviceInfoRequest) ProtoMessage() {}
+
+func (x *GetDeviceInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeviceInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetDeviceInfoRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{0}
+}
+
+type DeviceInfo struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	StandardVersion           uint32                 `protobuf:"varint,1,opt,name=standard_version,json=standardVersion,proto3" json:"standard_version,omitempty"`
+	VendorExtensionId         uint32                 `protobuf:"varint,2,opt,name=vendor_extension_id,json=vendorExtensionId,proto3" json:"vendor_extension_id,omitempty"`
+	VendorExtensionVersion    uint32                 `protobuf:"varint,3,opt,name=vendor_extension_version,json=vendorExtensionVersion,proto3" json:"vendor_extension_version,omitempty"`
+	VendorExtensionDesc       string                 `protobuf:"bytes,4,opt,name=vendor_extension_desc,json=vendorExtensionDesc,proto3" json:"vendor_extension_desc,omitempty"`
+	FunctionalMode            uint32                 `protobuf:"varint,5,opt,name=functional_mode,json=functionalMode,proto3" json:"functional_mode,omitempty"`
+	OperationsSupported       []uint32               `protobuf:"varint,6,rep,packed,name=operations_supported,json=operationsSupported,proto3" json:"operations_supported,omitempty"`
+	EventsSupported           []uint32               `protobuf:"varint,7,rep,packed,name=events_supported,json=eventsSupported,proto3" json:"events_supported,omitempty"`
+	DevicePropertiesSupported []uint32               `protobuf:"varint,8,rep,packed,name=device_properties_supported,json=devicePropertiesSupported,proto3" json:"device_properties_supported,omitempty"`
+	CaptureFormats            []uint32               `protobuf:"varint,9,rep,packed,name=capture_formats,json=captureFormats,proto3" json:"capture_formats,omitempty"`
+	ImageFormats              []uint32               `protobuf:"varint,10,rep,packed,name=image_formats,json=imageFormats,proto3" json:"image_formats,omitempty"`
+	Manufacturer              string                 `protobuf:"bytes,11,opt,name=manufacturer,proto3" json:"manufacturer,omitempty"`
+	Model                     string                 `protobuf:"bytes,12,opt,name=model,proto3" json:"model,omitempty"`
+	DeviceVersion             string                 `protobuf:"bytes,13,opt,name=device_version,json=deviceVersion,proto3" json:"device_version,omitempty"`
+	SerialNumber              string                 `protobuf:"bytes,14,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	// vendor is the name of the vendor extension selected for the device.
+	Vendor        string `protobuf:"bytes,15,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceInfo) Reset() {
+	*x = DeviceInfo{}
+	mi := &file_camera_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceInfo) ProtoMessage() {}
+
+func (x *DeviceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceInfo.ProtoReflect.Descriptor instead.
+func (*DeviceInfo) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeviceInfo) GetStandardVersion() uint32 {
+	if x != nil {
+		return x.StandardVersion
+	}
+	return 0
+}
+
+func (x *DeviceInfo) GetVendorExtensionId() uint32 {
+	if x != nil {
+		return x.VendorExtensionId
+	}
+	return 0
+}
+
+func (x *DeviceInfo) GetVendorExtensionVersion() uint32 {
+	if x != nil {
+		return x.VendorExtensionVersion
+	}
+	return 0
+}
+
+func (x *DeviceInfo) GetVendorExtensionDesc() string {
+	if x != nil {
+		return x.VendorExtensionDesc
+	}
+	return ""
+}
+
+func (x *DeviceInfo) GetFunctionalMode() uint32 {
+	if x != nil {
+		return x.FunctionalMode
+	}
+	return 0
+}
+
+func (x *DeviceInfo) GetOperationsSupported() []uint32 {
+	if x != nil {
+		return x.OperationsSupported
+	}
+	return nil
+}
+
+func (x *DeviceInfo) GetEventsSupported() []uint32 {
+	if x != nil {
+		return x.EventsSupported
+	}
+	return nil
+}
+
+func (x *DeviceInfo) GetDevicePropertiesSupported() []uint32 {
+	if x != nil {
+		return x.DevicePropertiesSupported
+	}
+	return nil
+}
+
+func (x *DeviceInfo) GetCaptureFormats() []uint32 {
+	if x != nil {
+		return x.CaptureFormats
+	}
+	return nil
+}
+
+func (x *DeviceInfo) GetImageFormats() []uint32 {
+	if x != nil {
+		return x.ImageFormats
+	}
+	return nil
+}
+
+func (x *DeviceInfo) GetManufacturer() string {
+	if x != nil {
+		return x.Manufacturer
+	}
+	return ""
+}
+
+func (x *DeviceInfo) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *DeviceInfo) GetDeviceVersion() string {
+	if x != nil {
+		return x.DeviceVersion
+	}
+	return ""
+}
+
+func (x *DeviceInfo) GetSerialNumber() string {
+	if x != nil {
+		return x.SerialNumber
+	}
+	return ""
+}
+
+func (x *DeviceInfo) GetVendor() string {
+	if x != nil {
+		return x.Vendor
+	}
+	return ""
+}
+
+type OperationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperationCode uint32                 `protobuf:"varint,1,opt,name=operation_code,json=operationCode,proto3" json:"operation_code,omitempty"`
+	// data_phase is a packet.DataPhaseInfo value.
+	DataPhase     uint32   `protobuf:"varint,2,opt,name=data_phase,json=dataPhase,proto3" json:"data_phase,omitempty"`
+	Params        []uint32 `protobuf:"varint,3,rep,packed,name=params,proto3" json:"params,omitempty"`
+	Data          []byte   `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OperationRequest) Reset() {
+	*x = OperationRequest{}
+	mi := &file_camera_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OperationRequest) ProtoMessage() {}
+
+func (x *OperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OperationRequest.ProtoReflect.Descriptor instead.
+func (*OperationRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OperationRequest) GetOperationCode() uint32 {
+	if x != nil {
+		return x.OperationCode
+	}
+	return 0
+}
+
+func (x *OperationRequest) GetDataPhase() uint32 {
+	if x != nil {
+		return x.DataPhase
+	}
+	return 0
+}
+
+func (x *OperationRequest) GetParams() []uint32 {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *OperationRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type OperationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResponseCode  uint32                 `protobuf:"varint,1,opt,name=response_code,json=responseCode,proto3" json:"response_code,omitempty"`
+	Params        []uint32               `protobuf:"varint,2,rep,packed,name=params,proto3" json:"params,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OperationResponse) Reset() {
+	*x = OperationResponse{}
+	mi := &file_camera_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OperationResponse) ProtoMessage() {}
+
+func (x *OperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OperationResponse.ProtoReflect.Descriptor instead.
+func (*OperationResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OperationResponse) GetResponseCode() uint32 {
+	if x != nil {
+		return x.ResponseCode
+	}
+	return 0
+}
+
+func (x *OperationResponse) GetParams() []uint32 {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *OperationResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type GetStorageIDsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStorageIDsRequest) Reset() {
+	*x = GetStorageIDsRequest{}
+	mi := &file_camera_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStorageIDsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStorageIDsRequest) ProtoMessage() {}
+
+func (x *GetStorageIDsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStorageIDsRequest.ProtoReflect.Descriptor instead.
+func (*GetStorageIDsRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{4}
+}
+
+type GetStorageIDsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StorageIds    []uint32               `protobuf:"varint,1,rep,packed,name=storage_ids,json=storageIds,proto3" json:"storage_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStorageIDsResponse) Reset() {
+	*x = GetStorageIDsResponse{}
+	mi := &file_camera_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStorageIDsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStorageIDsResponse) ProtoMessage() {}
+
+func (x *GetStorageIDsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStorageIDsResponse.ProtoReflect.Descriptor instead.
+func (*GetStorageIDsResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetStorageIDsResponse) GetStorageIds() []uint32 {
+	if x != nil {
+		return x.StorageIds
+	}
+	return nil
+}
+
+type GetObjectHandlesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StorageId     uint32                 `protobuf:"varint,1,opt,name=storage_id,json=storageId,proto3" json:"storage_id,omitempty"`
+	ObjectFormat  uint32                 `protobuf:"varint,2,opt,name=object_format,json=objectFormat,proto3" json:"object_format,omitempty"`
+	Parent        uint32                 `protobuf:"varint,3,opt,name=parent,proto3" json:"parent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetObjectHandlesRequest) Reset() {
+	*x = GetObjectHandlesRequest{}
+	mi := &file_camera_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetObjectHandlesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetObjectHandlesRequest) ProtoMessage() {}
+
+func (x *GetObjectHandlesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetObjectHandlesRequest.ProtoReflect.Descriptor instead.
+func (*GetObjectHandlesRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetObjectHandlesRequest) GetStorageId() uint32 {
+	if x != nil {
+		return x.StorageId
+	}
+	return 0
+}
+
+func (x *GetObjectHandlesRequest) GetObjectFormat() uint32 {
+	if x != nil {
+		return x.ObjectFormat
+	}
+	return 0
+}
+
+func (x *GetObjectHandlesRequest) GetParent() uint32 {
+	if x != nil {
+		return x.Parent
+	}
+	return 0
+}
+
+type GetObjectHandlesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Handles       []uint32               `protobuf:"varint,1,rep,packed,name=handles,proto3" json:"handles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetObjectHandlesResponse) Reset() {
+	*x = GetObjectHandlesResponse{}
+	mi := &file_camera_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetObjectHandlesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetObjectHandlesResponse) ProtoMessage() {}
+
+func (x *GetObjectHandlesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetObjectHandlesResponse.ProtoReflect.Descriptor instead.
+func (*GetObjectHandlesResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetObjectHandlesResponse) GetHandles() []uint32 {
+	if x != nil {
+		return x.Handles
+	}
+	return nil
+}
+
+type GetObjectInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Handle        uint32                 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetObjectInfoRequest) Reset() {
+	*x = GetObjectInfoRequest{}
+	mi := &file_camera_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetObjectInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetObjectInfoRequest) ProtoMessage() {}
+
+func (x *GetObjectInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetObjectInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetObjectInfoRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetObjectInfoRequest) GetHandle() uint32 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+type ObjectInfo struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	StorageId            uint32                 `protobuf:"varint,1,opt,name=storage_id,json=storageId,proto3" json:"storage_id,omitempty"`
+	ObjectFormat         uint32                 `protobuf:"varint,2,opt,name=object_format,json=objectFormat,proto3" json:"object_format,omitempty"`
+	ProtectionStatus     uint32                 `protobuf:"varint,3,opt,name=protection_status,json=protectionStatus,proto3" json:"protection_status,omitempty"`
+	ObjectCompressedSize uint32                 `protobuf:"varint,4,opt,name=object_compressed_size,json=objectCompressedSize,proto3" json:"object_compressed_size,omitempty"`
+	ThumbFormat          uint32                 `protobuf:"varint,5,opt,name=thumb_format,json=thumbFormat,proto3" json:"thumb_format,omitempty"`
+	ThumbCompressedSize  uint32                 `protobuf:"varint,6,opt,name=thumb_compressed_size,json=thumbCompressedSize,proto3" json:"thumb_compressed_size,omitempty"`
+	ThumbPixWidth        uint32                 `protobuf:"varint,7,opt,name=thumb_pix_width,json=thumbPixWidth,proto3" json:"thumb_pix_width,omitempty"`
+	ThumbPixHeight       uint32                 `protobuf:"varint,8,opt,name=thumb_pix_height,json=thumbPixHeight,proto3" json:"thumb_pix_height,omitempty"`
+	ImagePixWidth        uint32                 `protobuf:"varint,9,opt,name=image_pix_width,json=imagePixWidth,proto3" json:"image_pix_width,omitempty"`
+	ImagePixHeight       uint32                 `protobuf:"varint,10,opt,name=image_pix_height,json=imagePixHeight,proto3" json:"image_pix_height,omitempty"`
+	ImageBitDepth        uint32                 `protobuf:"varint,11,opt,name=image_bit_depth,json=imageBitDepth,proto3" json:"image_bit_depth,omitempty"`
+	ParentObject         uint32                 `protobuf:"varint,12,opt,name=parent_object,json=parentObject,proto3" json:"parent_object,omitempty"`
+	AssociationType      uint32                 `protobuf:"varint,13,opt,name=association_type,json=associationType,proto3" json:"association_type,omitempty"`
+	AssociationDesc      uint32                 `protobuf:"varint,14,opt,name=association_desc,json=associationDesc,proto3" json:"association_desc,omitempty"`
+	SequenceNumber       uint32                 `protobuf:"varint,15,opt,name=sequence_number,json=sequenceNumber,proto3" json:"sequence_number,omitempty"`
+	Filename             string                 `protobuf:"bytes,16,opt,name=filename,proto3" json:"filename,omitempty"`
+	// capture_date and modification_date are PTP DateTime strings, see packet.ParseDateTime.
+	CaptureDate      string `protobuf:"bytes,17,opt,name=capture_date,json=captureDate,proto3" json:"capture_date,omitempty"`
+	ModificationDate string `protobuf:"bytes,18,opt,name=modification_date,json=modificationDate,proto3" json:"modification_date,omitempty"`
+	Keywords         string `protobuf:"bytes,19,opt,name=keywords,proto3" json:"keywords,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ObjectInfo) Reset() {
+	*x = ObjectInfo{}
+	mi := &file_camera_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectInfo) ProtoMessage() {}
+
+func (x *ObjectInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectInfo.ProtoReflect.Descriptor instead.
+func (*ObjectInfo) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ObjectInfo) GetStorageId() uint32 {
+	if x != nil {
+		return x.StorageId
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetObjectFormat() uint32 {
+	if x != nil {
+		return x.ObjectFormat
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetProtectionStatus() uint32 {
+	if x != nil {
+		return x.ProtectionStatus
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetObjectCompressedSize() uint32 {
+	if x != nil {
+		return x.ObjectCompressedSize
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetThumbFormat() uint32 {
+	if x != nil {
+		return x.ThumbFormat
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetThumbCompressedSize() uint32 {
+	if x != nil {
+		return x.ThumbCompressedSize
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetThumbPixWidth() uint32 {
+	if x != nil {
+		return x.ThumbPixWidth
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetThumbPixHeight() uint32 {
+	if x != nil {
+		return x.ThumbPixHeight
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetImagePixWidth() uint32 {
+	if x != nil {
+		return x.ImagePixWidth
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetImagePixHeight() uint32 {
+	if x != nil {
+		return x.ImagePixHeight
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetImageBitDepth() uint32 {
+	if x != nil {
+		return x.ImageBitDepth
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetParentObject() uint32 {
+	if x != nil {
+		return x.ParentObject
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetAssociationType() uint32 {
+	if x != nil {
+		return x.AssociationType
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetAssociationDesc() uint32 {
+	if x != nil {
+		return x.AssociationDesc
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetSequenceNumber() uint32 {
+	if x != nil {
+		return x.SequenceNumber
+	}
+	return 0
+}
+
+func (x *ObjectInfo) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ObjectInfo) GetCaptureDate() string {
+	if x != nil {
+		return x.CaptureDate
+	}
+	return ""
+}
+
+func (x *ObjectInfo) GetModificationDate() string {
+	if x != nil {
+		return x.ModificationDate
+	}
+	return ""
+}
+
+func (x *ObjectInfo) GetKeywords() string {
+	if x != nil {
+		return x.Keywords
+	}
+	return ""
+}
+
+type GetThumbRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Handle        uint32                 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThumbRequest) Reset() {
+	*x = GetThumbRequest{}
+	mi := &file_camera_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThumbRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThumbRequest) ProtoMessage() {}
+
+func (x *GetThumbRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThumbRequest.ProtoReflect.Descriptor instead.
+func (*GetThumbRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetThumbRequest) GetHandle() uint32 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+type GetThumbResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThumbResponse) Reset() {
+	*x = GetThumbResponse{}
+	mi := &file_camera_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThumbResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThumbResponse) ProtoMessage() {}
+
+func (x *GetThumbResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThumbResponse.ProtoReflect.Descriptor instead.
+func (*GetThumbResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetThumbResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type DeleteObjectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Handle        uint32                 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteObjectRequest) Reset() {
+	*x = DeleteObjectRequest{}
+	mi := &file_camera_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteObjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteObjectRequest) ProtoMessage() {}
+
+func (x *DeleteObjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteObjectRequest.ProtoReflect.Descriptor instead.
+func (*DeleteObjectRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteObjectRequest) GetHandle() uint32 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+type DeleteObjectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteObjectResponse) Reset() {
+	*x = DeleteObjectResponse{}
+	mi := &file_camera_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteObjectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteObjectResponse) ProtoMessage() {}
+
+func (x *DeleteObjectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteObjectResponse.ProtoReflect.Descriptor instead.
+func (*DeleteObjectResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{13}
+}
+
+// Value is a PTP datatype value.
+type Value struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Value:
+	//
+	//	*Value_Int
+	//	*Value_Uint
+	//	*Value_String_
+	//	*Value_Raw
+	Value         isValue_Value `protobuf_oneof:"value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_camera_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Value) GetValue() isValue_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Value) GetInt() int64 {
+	if x != nil {
+		if x, ok := x.Value.(*Value_Int); ok {
+			return x.Int
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetUint() uint64 {
+	if x != nil {
+		if x, ok := x.Value.(*Value_Uint); ok {
+			return x.Uint
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetString_() string {
+	if x != nil {
+		if x, ok := x.Value.(*Value_String_); ok {
+			return x.String_
+		}
+	}
+	return ""
+}
+
+func (x *Value) GetRaw() []byte {
+	if x != nil {
+		if x, ok := x.Value.(*Value_Raw); ok {
+			return x.Raw
+		}
+	}
+	return nil
+}
+
+type isValue_Value interface {
+	isValue_Value()
+}
+
+type Value_Int struct {
+	Int int64 `protobuf:"varint,1,opt,name=int,proto3,oneof"`
+}
+
+type Value_Uint struct {
+	Uint uint64 `protobuf:"varint,2,opt,name=uint,proto3,oneof"`
+}
+
+type Value_String_ struct {
+	String_ string `protobuf:"bytes,3,opt,name=string,proto3,oneof"`
+}
+
+type Value_Raw struct {
+	// raw holds 128 bit integers and arrays in PTP encoding.
+	Raw []byte `protobuf:"bytes,4,opt,name=raw,proto3,oneof"`
+}
+
+func (*Value_Int) isValue_Value() {}
+
+func (*Value_Uint) isValue_Value() {}
+
+func (*Value_String_) isValue_Value() {}
+
+func (*Value_Raw) isValue_Value() {}
+
+type GetDevicePropDescRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDevicePropDescRequest) Reset() {
+	*x = GetDevicePropDescRequest{}
+	mi := &file_camera_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDevicePropDescRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDevicePropDescRequest) ProtoMessage() {}
+
+func (x *GetDevicePropDescRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDevicePropDescRequest.ProtoReflect.Descriptor instead.
+func (*GetDevicePropDescRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetDevicePropDescRequest) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+type DevicePropDesc struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Code           uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	DataType       uint32                 `protobuf:"varint,2,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`
+	GetSet         uint32                 `protobuf:"varint,3,opt,name=get_set,json=getSet,proto3" json:"get_set,omitempty"`
+	FactoryDefault *Value                 `protobuf:"bytes,4,opt,name=factory_default,json=factoryDefault,proto3" json:"factory_default,omitempty"`
+	Current        *Value                 `protobuf:"bytes,5,opt,name=current,proto3" json:"current,omitempty"`
+	FormFlag       uint32                 `protobuf:"varint,6,opt,name=form_flag,json=formFlag,proto3" json:"form_flag,omitempty"`
+	Min            *Value                 `protobuf:"bytes,7,opt,name=min,proto3" json:"min,omitempty"`
+	Max            *Value                 `protobuf:"bytes,8,opt,name=max,proto3" json:"max,omitempty"`
+	Step           *Value                 `protobuf:"bytes,9,opt,name=step,proto3" json:"step,omitempty"`
+	Values         []*Value               `protobuf:"bytes,10,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DevicePropDesc) Reset() {
+	*x = DevicePropDesc{}
+	mi := &file_camera_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DevicePropDesc) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DevicePropDesc) ProtoMessage() {}
+
+func (x *DevicePropDesc) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DevicePropDesc.ProtoReflect.Descriptor instead.
+func (*DevicePropDesc) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DevicePropDesc) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *DevicePropDesc) GetDataType() uint32 {
+	if x != nil {
+		return x.DataType
+	}
+	return 0
+}
+
+func (x *DevicePropDesc) GetGetSet() uint32 {
+	if x != nil {
+		return x.GetSet
+	}
+	return 0
+}
+
+func (x *DevicePropDesc) GetFactoryDefault() *Value {
+	if x != nil {
+		return x.FactoryDefault
+	}
+	return nil
+}
+
+func (x *DevicePropDesc) GetCurrent() *Value {
+	if x != nil {
+		return x.Current
+	}
+	return nil
+}
+
+func (x *DevicePropDesc) GetFormFlag() uint32 {
+	if x != nil {
+		return x.FormFlag
+	}
+	return 0
+}
+
+func (x *DevicePropDesc) GetMin() *Value {
+	if x != nil {
+		return x.Min
+	}
+	return nil
+}
+
+func (x *DevicePropDesc) GetMax() *Value {
+	if x != nil {
+		return x.Max
+	}
+	return nil
+}
+
+func (x *DevicePropDesc) GetStep() *Value {
+	if x != nil {
+		return x.Step
+	}
+	return nil
+}
+
+func (x *DevicePropDesc) GetValues() []*Value {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type SetDevicePropValueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Value         *Value                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDevicePropValueRequest) Reset() {
+	*x = SetDevicePropValueRequest{}
+	mi := &file_camera_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDevicePropValueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDevicePropValueRequest) ProtoMessage() {}
+
+func (x *SetDevicePropValueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDevicePropValueRequest.ProtoReflect.Descriptor instead.
+func (*SetDevicePropValueRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SetDevicePropValueRequest) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *SetDevicePropValueRequest) GetValue() *Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type SetDevicePropValueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDevicePropValueResponse) Reset() {
+	*x = SetDevicePropValueResponse{}
+	mi := &file_camera_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDevicePropValueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDevicePropValueResponse) ProtoMessage() {}
+
+func (x *SetDevicePropValueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDevicePropValueResponse.ProtoReflect.Descriptor instead.
+func (*SetDevicePropValueResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{18}
+}
+
+type InitiateCaptureRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StorageId     uint32                 `protobuf:"varint,1,opt,name=storage_id,json=storageId,proto3" json:"storage_id,omitempty"`
+	ObjectFormat  uint32                 `protobuf:"varint,2,opt,name=object_format,json=objectFormat,proto3" json:"object_format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InitiateCaptureRequest) Reset() {
+	*x = InitiateCaptureRequest{}
+	mi := &file_camera_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitiateCaptureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitiateCaptureRequest) ProtoMessage() {}
+
+func (x *InitiateCaptureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitiateCaptureRequest.ProtoReflect.Descriptor instead.
+func (*InitiateCaptureRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *InitiateCaptureRequest) GetStorageId() uint32 {
+	if x != nil {
+		return x.StorageId
+	}
+	return 0
+}
+
+func (x *InitiateCaptureRequest) GetObjectFormat() uint32 {
+	if x != nil {
+		return x.ObjectFormat
+	}
+	return 0
+}
+
+type InitiateCaptureResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InitiateCaptureResponse) Reset() {
+	*x = InitiateCaptureResponse{}
+	mi := &file_camera_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitiateCaptureResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitiateCaptureResponse) ProtoMessage() {}
+
+func (x *InitiateCaptureResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitiateCaptureResponse.ProtoReflect.Descriptor instead.
+func (*InitiateCaptureResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{20}
+}
+
+type StreamEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_camera_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{21}
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	TransactionId uint32                 `protobuf:"varint,3,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	Params        []uint32               `protobuf:"varint,4,rep,packed,name=params,proto3" json:"params,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_camera_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Event) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Event) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Event) GetTransactionId() uint32 {
+	if x != nil {
+		return x.TransactionId
+	}
+	return 0
+}
+
+func (x *Event) GetParams() []uint32 {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+type StreamLiveViewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fps           float64                `protobuf:"fixed64,1,opt,name=fps,proto3" json:"fps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamLiveViewRequest) Reset() {
+	*x = StreamLiveViewRequest{}
+	mi := &file_camera_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLiveViewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLiveViewRequest) ProtoMessage() {}
+
+func (x *StreamLiveViewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLiveViewRequest.ProtoReflect.Descriptor instead.
+func (*StreamLiveViewRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *StreamLiveViewRequest) GetFps() float64 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+type LiveViewFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jpeg          []byte                 `protobuf:"bytes,1,opt,name=jpeg,proto3" json:"jpeg,omitempty"`
+	TimeUnixNano  int64                  `protobuf:"varint,2,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Dropped       uint64                 `protobuf:"varint,4,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LiveViewFrame) Reset() {
+	*x = LiveViewFrame{}
+	mi := &file_camera_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LiveViewFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiveViewFrame) ProtoMessage() {}
+
+func (x *LiveViewFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiveViewFrame.ProtoReflect.Descriptor instead.
+func (*LiveViewFrame) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *LiveViewFrame) GetJpeg() []byte {
+	if x != nil {
+		return x.Jpeg
+	}
+	return nil
+}
+
+func (x *LiveViewFrame) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+func (x *LiveViewFrame) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *LiveViewFrame) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+type DownloadObjectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Handle        uint32                 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadObjectRequest) Reset() {
+	*x = DownloadObjectRequest{}
+	mi := &file_camera_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadObjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadObjectRequest) ProtoMessage() {}
+
+func (x *DownloadObjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadObjectRequest.ProtoReflect.Descriptor instead.
+func (*DownloadObjectRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DownloadObjectRequest) GetHandle() uint32 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+func (x *DownloadObjectRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ObjectChunk struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Offset uint64                 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data   []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// size is the total size of the object.
+	Size          uint64 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObjectChunk) Reset() {
+	*x = ObjectChunk{}
+	mi := &file_camera_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectChunk) ProtoMessage() {}
+
+func (x *ObjectChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectChunk.ProtoReflect.Descriptor instead.
+func (*ObjectChunk) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ObjectChunk) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ObjectChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ObjectChunk) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type UploadObjectRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Part:
+	//
+	//	*UploadObjectRequest_Info
+	//	*UploadObjectRequest_Data
+	Part          isUploadObjectRequest_Part `protobuf_oneof:"part"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadObjectRequest) Reset() {
+	*x = UploadObjectRequest{}
+	mi := &file_camera_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadObjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadObjectRequest) ProtoMessage() {}
+
+func (x *UploadObjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadObjectRequest.ProtoReflect.Descriptor instead.
+func (*UploadObjectRequest) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *UploadObjectRequest) GetPart() isUploadObjectRequest_Part {
+	if x != nil {
+		return x.Part
+	}
+	return nil
+}
+
+func (x *UploadObjectRequest) GetInfo() *ObjectInfo {
+	if x != nil {
+		if x, ok := x.Part.(*UploadObjectRequest_Info); ok {
+			return x.Info
+		}
+	}
+	return nil
+}
+
+func (x *UploadObjectRequest) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Part.(*UploadObjectRequest_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+type isUploadObjectRequest_Part interface {
+	isUploadObjectRequest_Part()
+}
+
+type UploadObjectRequest_Info struct {
+	Info *ObjectInfo `protobuf:"bytes,1,opt,name=info,proto3,oneof"`
+}
+
+type UploadObjectRequest_Data struct {
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"`
+}
+
+func (*UploadObjectRequest_Info) isUploadObjectRequest_Part() {}
+
+func (*UploadObjectRequest_Data) isUploadObjectRequest_Part() {}
+
+type UploadObjectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StorageId     uint32                 `protobuf:"varint,1,opt,name=storage_id,json=storageId,proto3" json:"storage_id,omitempty"`
+	Parent        uint32                 `protobuf:"varint,2,opt,name=parent,proto3" json:"parent,omitempty"`
+	Handle        uint32                 `protobuf:"varint,3,opt,name=handle,proto3" json:"handle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadObjectResponse) Reset() {
+	*x = UploadObjectResponse{}
+	mi := &file_camera_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadObjectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadObjectResponse) ProtoMessage() {}
+
+func (x *UploadObjectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_camera_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadObjectResponse.ProtoReflect.Descriptor instead.
+func (*UploadObjectResponse) Descriptor() ([]byte, []int) {
+	return file_camera_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *UploadObjectResponse) GetStorageId() uint32 {
+	if x != nil {
+		return x.StorageId
+	}
+	return 0
+}
+
+func (x *UploadObjectResponse) GetParent() uint32 {
+	if x != nil {
+		return x.Parent
+	}
+	return 0
+}
+
+func (x *UploadObjectResponse) GetHandle() uint32 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+var File_camera_proto protoreflect.FileDescriptor
+
+const file_camera_proto_rawDesc = "" +
+	"\n" +
+	"\fcamera.proto\x12\bptpip.v1\"\x16\n" +
+	"\x14GetDeviceInfoRequest\"\x88\x05\n" +
+	"\n" +
+	"DeviceInfo\x12)\n" +
+	"\x10standard_version\x18\x01 \x01(\rR\x0fstandardVersion\x12.\n" +
+	"\x13vendor_extension_id\x18\x02 \x01(\rR\x11vendorExtensionId\x128\n" +
+	"\x18vendor_extension_version\x18\x03 \x01(\rR\x16vendorExtensionVersion\x122\n" +
+	"\x15vendor_extension_desc\x18\x04 \x01(\tR\x13vendorExtensionDesc\x12'\n" +
+	"\x0ffunctional_mode\x18\x05 \x01(\rR\x0efunctionalMode\x121\n" +
+	"\x14operations_supported\x18\x06 \x03(\rR\x13operationsSupported\x12)\n" +
+	"\x10events_supported\x18\a \x03(\rR\x0feventsSupported\x12>\n" +
+	"\x1bdevice_properties_supported\x18\b \x03(\rR\x19devicePropertiesSupported\x12'\n" +
+	"\x0fcapture_formats\x18\t \x03(\rR\x0ecaptureFormats\x12#\n" +
+	"\rimage_formats\x18\n" +
+	" \x03(\rR\fimageFormats\x12\"\n" +
+	"\fmanufacturer\x18\v \x01(\tR\fmanufacturer\x12\x14\n" +
+	"\x05model\x18\f \x01(\tR\x05model\x12%\n" +
+	"\x0edevice_version\x18\r \x01(\tR\rdeviceVersion\x12#\n" +
+	"\rserial_number\x18\x0e \x01(\tR\fserialNumber\x12\x16\n" +
+	"\x06vendor\x18\x0f \x01(\tR\x06vendor\"\x84\x01\n" +
+	"\x10OperationRequest\x12%\n" +
+	"\x0eoperation_code\x18\x01 \x01(\rR\roperationCode\x12\x1d\n" +
+	"\n" +
+	"data_phase\x18\x02 \x01(\rR\tdataPhase\x12\x16\n" +
+	"\x06params\x18\x03 \x03(\rR\x06params\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\"d\n" +
+	"\x11OperationResponse\x12#\n" +
+	"\rresponse_code\x18\x01 \x01(\rR\fresponseCode\x12\x16\n" +
+	"\x06params\x18\x02 \x03(\rR\x06params\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\x16\n" +
+	"\x14GetStorageIDsRequest\"8\n" +
+	"\x15GetStorageIDsResponse\x12\x1f\n" +
+	"\vstorage_ids\x18\x01 \x03(\rR\n" +
+	"storageIds\"u\n" +
+	"\x17GetObjectHandlesRequest\x12\x1d\n" +
+	"\n" +
+	"storage_id\x18\x01 \x01(\rR\tstorageId\x12#\n" +
+	"\robject_format\x18\x02 \x01(\rR\fobjectFormat\x12\x16\n" +
+	"\x06parent\x18\x03 \x01(\rR\x06parent\"4\n" +
+	"\x18GetObjectHandlesResponse\x12\x18\n" +
+	"\ahandles\x18\x01 \x03(\rR\ahandles\".\n" +
+	"\x14GetObjectInfoRequest\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\rR\x06handle\"\x82\x06\n" +
+	"\n" +
+	"ObjectInfo\x12\x1d\n" +
+	"\n" +
+	"storage_id\x18\x01 \x01(\rR\tstorageId\x12#\n" +
+	"\robject_format\x18\x02 \x01(\rR\fobjectFormat\x12+\n" +
+	"\x11protection_status\x18\x03 \x01(\rR\x10protectionStatus\x124\n" +
+	"\x16object_compressed_size\x18\x04 \x01(\rR\x14objectCompressedSize\x12!\n" +
+	"\fthumb_format\x18\x05 \x01(\rR\vthumbFormat\x122\n" +
+	"\x15thumb_compressed_size\x18\x06 \x01(\rR\x13thumbCompressedSize\x12&\n" +
+	"\x0fthumb_pix_width\x18\a \x01(\rR\rthumbPixWidth\x12(\n" +
+	"\x10thumb_pix_height\x18\b \x01(\rR\x0ethumbPixHeight\x12&\n" +
+	"\x0fimage_pix_width\x18\t \x01(\rR\rimagePixWidth\x12(\n" +
+	"\x10image_pix_height\x18\n" +
+	" \x01(\rR\x0eimagePixHeight\x12&\n" +
+	"\x0fimage_bit_depth\x18\v \x01(\rR\rimageBitDepth\x12#\n" +
+	"\rparent_object\x18\f \x01(\rR\fparentObject\x12)\n" +
+	"\x10association_type\x18\r \x01(\rR\x0fassociationType\x12)\n" +
+	"\x10association_desc\x18\x0e \x01(\rR\x0fassociationDesc\x12'\n" +
+	"\x0fsequence_number\x18\x0f \x01(\rR\x0esequenceNumber\x12\x1a\n" +
+	"\bfilename\x18\x10 \x01(\tR\bfilename\x12!\n" +
+	"\fcapture_date\x18\x11 \x01(\tR\vcaptureDate\x12+\n" +
+	"\x11modification_date\x18\x12 \x01(\tR\x10modificationDate\x12\x1a\n" +
+	"\bkeywords\x18\x13 \x01(\tR\bkeywords\")\n" +
+	"\x0fGetThumbRequest\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\rR\x06handle\"&\n" +
+	"\x10GetThumbResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"-\n" +
+	"\x13DeleteObjectRequest\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\rR\x06handle\"\x16\n" +
+	"\x14DeleteObjectResponse\"h\n" +
+	"\x05Value\x12\x12\n" +
+	"\x03int\x18\x01 \x01(\x03H\x00R\x03int\x12\x14\n" +
+	"\x04uint\x18\x02 \x01(\x04H\x00R\x04uint\x12\x18\n" +
+	"\x06string\x18\x03 \x01(\tH\x00R\x06string\x12\x12\n" +
+	"\x03raw\x18\x04 \x01(\fH\x00R\x03rawB\a\n" +
+	"\x05value\".\n" +
+	"\x18GetDevicePropDescRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\"\xf0\x02\n" +
+	"\x0eDevicePropDesc\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\x12\x1b\n" +
+	"\tdata_type\x18\x02 \x01(\rR\bdataType\x12\x17\n" +
+	"\aget_set\x18\x03 \x01(\rR\x06getSet\x128\n" +
+	"\x0ffactory_default\x18\x04 \x01(\v2\x0f.ptpip.v1.ValueR\x0efactoryDefault\x12)\n" +
+	"\acurrent\x18\x05 \x01(\v2\x0f.ptpip.v1.ValueR\acurrent\x12\x1b\n" +
+	"\tform_flag\x18\x06 \x01(\rR\bformFlag\x12!\n" +
+	"\x03min\x18\a \x01(\v2\x0f.ptpip.v1.ValueR\x03min\x12!\n" +
+	"\x03max\x18\b \x01(\v2\x0f.ptpip.v1.ValueR\x03max\x12#\n" +
+	"\x04step\x18\t \x01(\v2\x0f.ptpip.v1.ValueR\x04step\x12'\n" +
+	"\x06values\x18\n" +
+	" \x03(\v2\x0f.ptpip.v1.ValueR\x06values\"V\n" +
+	"\x19SetDevicePropValueRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\x12%\n" +
+	"\x05value\x18\x02 \x01(\v2\x0f.ptpip.v1.ValueR\x05value\"\x1c\n" +
+	"\x1aSetDevicePropValueResponse\"\\\n" +
+	"\x16InitiateCaptureRequest\x12\x1d\n" +
+	"\n" +
+	"storage_id\x18\x01 \x01(\rR\tstorageId\x12#\n" +
+	"\robject_format\x18\x02 \x01(\rR\fobjectFormat\"\x19\n" +
+	"\x17InitiateCaptureResponse\"\x15\n" +
+	"\x13StreamEventsRequest\"n\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12%\n" +
+	"\x0etransaction_id\x18\x03 \x01(\rR\rtransactionId\x12\x16\n" +
+	"\x06params\x18\x04 \x03(\rR\x06params\")\n" +
+	"\x15StreamLiveViewRequest\x12\x10\n" +
+	"\x03fps\x18\x01 \x01(\x01R\x03fps\"u\n" +
+	"\rLiveViewFrame\x12\x12\n" +
+	"\x04jpeg\x18\x01 \x01(\fR\x04jpeg\x12$\n" +
+	"\x0etime_unix_nano\x18\x02 \x01(\x03R\ftimeUnixNano\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\x12\x18\n" +
+	"\adropped\x18\x04 \x01(\x04R\adropped\"G\n" +
+	"\x15DownloadObjectRequest\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\rR\x06handle\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\"M\n" +
+	"\vObjectChunk\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x04R\x04size\"_\n" +
+	"\x13UploadObjectRequest\x12*\n" +
+	"\x04info\x18\x01 \x01(\v2\x14.ptpip.v1.ObjectInfoH\x00R\x04info\x12\x14\n" +
+	"\x04data\x18\x02 \x01(\fH\x00R\x04dataB\x06\n" +
+	"\x04part\"e\n" +
+	"\x14UploadObjectResponse\x12\x1d\n" +
+	"\n" +
+	"storage_id\x18\x01 \x01(\rR\tstorageId\x12\x16\n" +
+	"\x06parent\x18\x02 \x01(\rR\x06parent\x12\x16\n" +
+	"\x06handle\x18\x03 \x01(\rR\x06handle2\xd4\b\n" +
+	"\x06Camera\x12E\n" +
+	"\rGetDeviceInfo\x12\x1e.ptpip.v1.GetDeviceInfoRequest\x1a\x14.ptpip.v1.DeviceInfo\x12D\n" +
+	"\tOperation\x12\x1a.ptpip.v1.OperationRequest\x1a\x1b.ptpip.v1.OperationResponse\x12P\n" +
+	"\rGetStorageIDs\x12\x1e.ptpip.v1.GetStorageIDsRequest\x1a\x1f.ptpip.v1.GetStorageIDsResponse\x12Y\n" +
+	"\x10GetObjectHandles\x12!.ptpip.v1.GetObjectHandlesRequest\x1a\".ptpip.v1.GetObjectHandlesResponse\x12E\n" +
+	"\rGetObjectInfo\x12\x1e.ptpip.v1.GetObjectInfoRequest\x1a\x14.ptpip.v1.ObjectInfo\x12A\n" +
+	"\bGetThumb\x12\x19.ptpip.v1.GetThumbRequest\x1a\x1a.ptpip.v1.GetThumbResponse\x12M\n" +
+	"\fDeleteObject\x12\x1d.ptpip.v1.DeleteObjectRequest\x1a\x1e.ptpip.v1.DeleteObjectResponse\x12Q\n" +
+	"\x11GetDevicePropDesc\x12\".ptpip.v1.GetDevicePropDescRequest\x1a\x18.ptpip.v1.DevicePropDesc\x12_\n" +
+	"\x12SetDevicePropValue\x12#.ptpip.v1.SetDevicePropValueRequest\x1a$.ptpip.v1.SetDevicePropValueResponse\x12V\n" +
+	"\x0fInitiateCapture\x12 .ptpip.v1.InitiateCaptureRequest\x1a!.ptpip.v1.InitiateCaptureResponse\x12@\n" +
+	"\fStreamEvents\x12\x1d.ptpip.v1.StreamEventsRequest\x1a\x0f.ptpip.v1.Event0\x01\x12L\n" +
+	"\x0eStreamLiveView\x12\x1f.ptpip.v1.StreamLiveViewRequest\x1a\x17.ptpip.v1.LiveViewFrame0\x01\x12J\n" +
+	"\x0eDownloadObject\x12\x1f.ptpip.v1.DownloadObjectRequest\x1a\x15.ptpip.v1.ObjectChunk0\x01\x12O\n" +
+	"\fUploadObject\x12\x1d.ptpip.v1.UploadObjectRequest\x1a\x1e.ptpip.v1.UploadObjectResponse(\x01B%Z#github.com/takurooo/ptpip/rpc/pb;pbb\x06proto3"
+
+var (
+	file_camera_proto_rawDescOnce sync.Once
+	file_camera_proto_rawDescData []byte
+)
+
+func file_camera_proto_rawDescGZIP() []byte {
+	file_camera_proto_rawDescOnce.Do(func() {
+		file_camera_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_camera_proto_rawDesc), len(file_camera_proto_rawDesc)))
+	})
+	return file_camera_proto_rawDescData
+}
+
+var file_camera_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_camera_proto_goTypes = []any{
+	(*GetDeviceInfoRequest)(nil),       // 0: ptpip.v1.GetDeviceInfoRequest
+	(*DeviceInfo)(nil),                 // 1: ptpip.v1.DeviceInfo
+	(*OperationRequest)(nil),           // 2: ptpip.v1.OperationRequest
+	(*OperationResponse)(nil),          // 3: ptpip.v1.OperationResponse
+	(*GetStorageIDsRequest)(nil),       // 4: ptpip.v1.GetStorageIDsRequest
+	(*GetStorageIDsResponse)(nil),      // 5: ptpip.v1.GetStorageIDsResponse
+	(*GetObjectHandlesRequest)(nil),    // 6: ptpip.v1.GetObjectHandlesRequest
+	(*GetObjectHandlesResponse)(nil),   // 7: ptpip.v1.GetObjectHandlesResponse
+	(*GetObjectInfoRequest)(nil),       // 8: ptpip.v1.GetObjectInfoRequest
+	(*ObjectInfo)(nil),                 // 9: ptpip.v1.ObjectInfo
+	(*GetThumbRequest)(nil),            // 10: ptpip.v1.GetThumbRequest
+	(*GetThumbResponse)(nil),           // 11: ptpip.v1.GetThumbResponse
+	(*DeleteObjectRequest)(nil),        // 12: ptpip.v1.DeleteObjectRequest
+	(*DeleteObjectResponse)(nil),       // 13: ptpip.v1.DeleteObjectResponse
+	(*Value)(nil),                      // 14: ptpip.v1.Value
+	(*GetDevicePropDescRequest)(nil),   // 15: ptpip.v1.GetDevicePropDescRequest
+	(*DevicePropDesc)(nil),             // 16: ptpip.v1.DevicePropDesc
+	(*SetDevicePropValueRequest)(nil),  // 17: ptpip.v1.SetDevicePropValueRequest
+	(*SetDevicePropValueResponse)(nil), // 18: ptpip.v1.SetDevicePropValueResponse
+	(*InitiateCaptureRequest)(nil),     // 19: ptpip.v1.InitiateCaptureRequest
+	(*InitiateCaptureResponse)(nil),    // 20: ptpip.v1.InitiateCaptureResponse
+	(*StreamEventsRequest)(nil),        // 21: ptpip.v1.StreamEventsRequest
+	(*Event)(nil),                      // 22: ptpip.v1.Event
+	(*StreamLiveViewRequest)(nil),      // 23: ptpip.v1.StreamLiveViewRequest
+	(*LiveViewFrame)(nil),              // 24: ptpip.v1.LiveViewFrame
+	(*DownloadObjectRequest)(nil),      // 25: ptpip.v1.DownloadObjectRequest
+	(*ObjectChunk)(nil),                // 26: ptpip.v1.ObjectChunk
+	(*UploadObjectRequest)(nil),        // 27: ptpip.v1.UploadObjectRequest
+	(*UploadObjectResponse)(nil),       // 28: ptpip.v1.UploadObjectResponse
+}
+var file_camera_proto_depIdxs = []int32{
+	14, // 0: ptpip.v1.DevicePropDesc.factory_default:type_name -> ptpip.v1.Value
+	14, // 1: ptpip.v1.DevicePropDesc.current:type_name -> ptpip.v1.Value
+	14, // 2: ptpip.v1.DevicePropDesc.min:type_name -> ptpip.v1.Value
+	14, // 3: ptpip.v1.DevicePropDesc.max:type_name -> ptpip.v1.Value
+	14, // 4: ptpip.v1.DevicePropDesc.step:type_name -> ptpip.v1.Value
+	14, // 5: ptpip.v1.DevicePropDesc.values:type_name -> ptpip.v1.Value
+	14, // 6: ptpip.v1.SetDevicePropValueRequest.value:type_name -> ptpip.v1.Value
+	9,  // 7: ptpip.v1.UploadObjectRequest.info:type_name -> ptpip.v1.ObjectInfo
+	0,  // 8: ptpip.v1.Camera.GetDeviceInfo:input_type -> ptpip.v1.GetDeviceInfoRequest
+	2,  // 9: ptpip.v1.Camera.Operation:input_type -> ptpip.v1.OperationRequest
+	4,  // 10: ptpip.v1.Camera.GetStorageIDs:input_type -> ptpip.v1.GetStorageIDsRequest
+	6,  // 11: ptpip.v1.Camera.GetObjectHandles:input_type -> ptpip.v1.GetObjectHandlesRequest
+	8,  // 12: ptpip.v1.Camera.GetObjectInfo:input_type -> ptpip.v1.GetObjectInfoRequest
+	10, // 13: ptpip.v1.Camera.GetThumb:input_type -> ptpip.v1.GetThumbRequest
+	12, // 14: ptpip.v1.Camera.DeleteObject:input_type -> ptpip.v1.DeleteObjectRequest
+	15, // 15: ptpip.v1.Camera.GetDevicePropDesc:input_type -> ptpip.v1.GetDevicePropDescRequest
+	17, // 16: ptpip.v1.Camera.SetDevicePropValue:input_type -> ptpip.v1.SetDevicePropValueRequest
+	19, // 17: ptpip.v1.Camera.InitiateCapture:input_type -> ptpip.v1.InitiateCaptureRequest
+	21, // 18: ptpip.v1.Camera.StreamEvents:input_type -> ptpip.v1.StreamEventsRequest
+	23, // 19: ptpip.v1.Camera.StreamLiveView:input_type -> ptpip.v1.StreamLiveViewRequest
+	25, // 20: ptpip.v1.Camera.DownloadObject:input_type -> ptpip.v1.DownloadObjectRequest
+	27, // 21: ptpip.v1.Camera.UploadObject:input_type -> ptpip.v1.UploadObjectRequest
+	1,  // 22: ptpip.v1.Camera.GetDeviceInfo:output_type -> ptpip.v1.DeviceInfo
+	3,  // 23: ptpip.v1.Camera.Operation:output_type -> ptpip.v1.OperationResponse
+	5,  // 24: ptpip.v1.Camera.GetStorageIDs:output_type -> ptpip.v1.GetStorageIDsResponse
+	7,  // 25: ptpip.v1.Camera.GetObjectHandles:output_type -> ptpip.v1.GetObjectHandlesResponse
+	9,  // 26: ptpip.v1.Camera.GetObjectInfo:output_type -> ptpip.v1.ObjectInfo
+	11, // 27: ptpip.v1.Camera.GetThumb:output_type -> ptpip.v1.GetThumbResponse
+	13, // 28: ptpip.v1.Camera.DeleteObject:output_type -> ptpip.v1.DeleteObjectResponse
+	16, // 29: ptpip.v1.Camera.GetDevicePropDesc:output_type -> ptpip.v1.DevicePropDesc
+	18, // 30: ptpip.v1.Camera.SetDevicePropValue:output_type -> ptpip.v1.SetDevicePropValueResponse
+	20, // 31: ptpip.v1.Camera.InitiateCapture:output_type -> ptpip.v1.InitiateCaptureResponse
+	22, // 32: ptpip.v1.Camera.StreamEvents:output_type -> ptpip.v1.Event
+	24, // 33: ptpip.v1.Camera.StreamLiveView:output_type -> ptpip.v1.LiveViewFrame
+	26, // 34: ptpip.v1.Camera.DownloadObject:output_type -> ptpip.v1.ObjectChunk
+	28, // 35: ptpip.v1.Camera.UploadObject:output_type -> ptpip.v1.UploadObjectResponse
+	22, // [22:36] is the sub-list for method output_type
+	8,  // [8:22] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_camera_proto_init() }
+func file_camera_proto_init() {
+	if File_camera_proto != nil {
+		return
+	}
+	file_camera_proto_msgTypes[14].OneofWrappers = []any{
+		(*Value_Int)(nil),
+		(*Value_Uint)(nil),
+		(*Value_String_)(nil),
+		(*Value_Raw)(nil),
+	}
+	file_camera_proto_msgTypes[27].OneofWrappers = []any{
+		(*UploadObjectRequest_Info)(nil),
+		(*UploadObjectRequest_Data)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_camera_proto_rawDesc), len(file_camera_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   29,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_camera_proto_goTypes,
+		DependencyIndexes: file_camera_proto_depIdxs,
+		MessageInfos:      file_camera_proto_msgTypes,
+	}.Build()
+	File_camera_proto = out.File
+	file_camera_proto_goTypes = nil
+	file_camera_proto_depIdxs = nil
+}
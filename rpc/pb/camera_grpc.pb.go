@@ -0,0 +1,628 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             (unknown)
+// source: camera.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Camera_GetDeviceInfo_FullMethodName      = "/ptpip.v1.Camera/GetDeviceInfo"
+	Camera_Operation_FullMethodName          = "/ptpip.v1.Camera/Operation"
+	Camera_GetStorageIDs_FullMethodName      = "/ptpip.v1.Camera/GetStorageIDs"
+	Camera_GetObjectHandles_FullMethodName   = "/ptpip.v1.Camera/GetObjectHandles"
+	Camera_GetObjectInfo_FullMethodName      = "/ptpip.v1.Camera/GetObjectInfo"
+	Camera_GetThumb_FullMethodName           = "/ptpip.v1.Camera/GetThumb"
+	Camera_DeleteObject_FullMethodName       = "/ptpip.v1.Camera/DeleteObject"
+	Camera_GetDevicePropDesc_FullMethodName  = "/ptpip.v1.Camera/GetDevicePropDesc"
+	Camera_SetDevicePropValue_FullMethodName = "/ptpip.v1.Camera/SetDevicePropValue"
+	Camera_InitiateCapture_FullMethodName    = "/ptpip.v1.Camera/InitiateCapture"
+	Camera_StreamEvents_FullMethodName       = "/ptpip.v1.Camera/StreamEvents"
+	Camera_StreamLiveView_FullMethodName     = "/ptpip.v1.Camera/StreamLiveView"
+	Camera_DownloadObject_FullMethodName     = "/ptpip.v1.Camera/DownloadObject"
+	Camera_UploadObject_FullMethodName       = "/ptpip.v1.Camera/UploadObject"
+)
+
+// CameraClient is the client API for Camera service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Camera mirrors the capabilities of ptpip.Client for remote camera control.
+type CameraClient interface {
+	GetDeviceInfo(ctx context.Context, in *GetDeviceInfoRequest, opts ...grpc.CallOption) (*DeviceInfo, error)
+	// Operation issues an arbitrary PTP operation, e.g. a vendor extension.
+	Operation(ctx context.Context, in *OperationRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	GetStorageIDs(ctx context.Context, in *GetStorageIDsRequest, opts ...grpc.CallOption) (*GetStorageIDsResponse, error)
+	GetObjectHandles(ctx context.Context, in *GetObjectHandlesRequest, opts ...grpc.CallOption) (*GetObjectHandlesResponse, error)
+	GetObjectInfo(ctx context.Context, in *GetObjectInfoRequest, opts ...grpc.CallOption) (*ObjectInfo, error)
+	GetThumb(ctx context.Context, in *GetThumbRequest, opts ...grpc.CallOption) (*GetThumbResponse, error)
+	DeleteObject(ctx context.Context, in *DeleteObjectRequest, opts ...grpc.CallOption) (*DeleteObjectResponse, error)
+	GetDevicePropDesc(ctx context.Context, in *GetDevicePropDescRequest, opts ...grpc.CallOption) (*DevicePropDesc, error)
+	SetDevicePropValue(ctx context.Context, in *SetDevicePropValueRequest, opts ...grpc.CallOption) (*SetDevicePropValueResponse, error)
+	InitiateCapture(ctx context.Context, in *InitiateCaptureRequest, opts ...grpc.CallOption) (*InitiateCaptureResponse, error)
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	StreamLiveView(ctx context.Context, in *StreamLiveViewRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LiveViewFrame], error)
+	// DownloadObject streams the object from offset with GetPartialObject.
+	DownloadObject(ctx context.Context, in *DownloadObjectRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ObjectChunk], error)
+	// UploadObject takes the ObjectInfo in the first message and the data in the following ones.
+	UploadObject(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadObjectRequest, UploadObjectResponse], error)
+}
+
+type cameraClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCameraClient(cc grpc.ClientConnInterface) CameraClient {
+	return &cameraClient{cc}
+}
+
+func (c *cameraClient) GetDeviceInfo(ctx context.Context, in *GetDeviceInfoRequest, opts ...grpc.CallOption) (*DeviceInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeviceInfo)
+	err := c.cc.Invoke(ctx, Camera_GetDeviceInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) Operation(ctx context.Context, in *OperationRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, Camera_Operation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) GetStorageIDs(ctx context.Context, in *GetStorageIDsRequest, opts ...grpc.CallOption) (*GetStorageIDsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStorageIDsResponse)
+	err := c.cc.Invoke(ctx, Camera_GetStorageIDs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) GetObjectHandles(ctx context.Context, in *GetObjectHandlesRequest, opts ...grpc.CallOption) (*GetObjectHandlesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetObjectHandlesResponse)
+	err := c.cc.Invoke(ctx, Camera_GetObjectHandles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) GetObjectInfo(ctx context.Context, in *GetObjectInfoRequest, opts ...grpc.CallOption) (*ObjectInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ObjectInfo)
+	err := c.cc.Invoke(ctx, Camera_GetObjectInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) GetThumb(ctx context.Context, in *GetThumbRequest, opts ...grpc.CallOption) (*GetThumbResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetThumbResponse)
+	err := c.cc.Invoke(ctx, Camera_GetThumb_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) DeleteObject(ctx context.Context, in *DeleteObjectRequest, opts ...grpc.CallOption) (*DeleteObjectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteObjectResponse)
+	err := c.cc.Invoke(ctx, Camera_DeleteObject_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) GetDevicePropDesc(ctx context.Context, in *GetDevicePropDescRequest, opts ...grpc.CallOption) (*DevicePropDesc, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DevicePropDesc)
+	err := c.cc.Invoke(ctx, Camera_GetDevicePropDesc_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) SetDevicePropValue(ctx context.Context, in *SetDevicePropValueRequest, opts ...grpc.CallOption) (*SetDevicePropValueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetDevicePropValueResponse)
+	err := c.cc.Invoke(ctx, Camera_SetDevicePropValue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) InitiateCapture(ctx context.Context, in *InitiateCaptureRequest, opts ...grpc.CallOption) (*InitiateCaptureResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InitiateCaptureResponse)
+	err := c.cc.Invoke(ctx, Camera_InitiateCapture_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cameraClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Camera_ServiceDesc.Streams[0], Camera_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *cameraClient) StreamLiveView(ctx context.Context, in *StreamLiveViewRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LiveViewFrame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Camera_ServiceDesc.Streams[1], Camera_StreamLiveView_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamLiveViewRequest, LiveViewFrame]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_StreamLiveViewClient = grpc.ServerStreamingClient[LiveViewFrame]
+
+func (c *cameraClient) DownloadObject(ctx context.Context, in *DownloadObjectRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ObjectChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Camera_ServiceDesc.Streams[2], Camera_DownloadObject_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadObjectRequest, ObjectChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_DownloadObjectClient = grpc.ServerStreamingClient[ObjectChunk]
+
+func (c *cameraClient) UploadObject(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadObjectRequest, UploadObjectResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Camera_ServiceDesc.Streams[3], Camera_UploadObject_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadObjectRequest, UploadObjectResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_UploadObjectClient = grpc.ClientStreamingClient[UploadObjectRequest, UploadObjectResponse]
+
+// CameraServer is the server API for Camera service.
+// All implementations must embed UnimplementedCameraServer
+// for forward compatibility.
+//
+// Camera mirrors the capabilities of ptpip.Client for remote camera control.
+type CameraServer interface {
+	GetDeviceInfo(context.Context, *GetDeviceInfoRequest) (*DeviceInfo, error)
+	// Operation issues an arbitrary PTP operation, e.g. a vendor extension.
+	Operation(context.Context, *OperationRequest) (*OperationResponse, error)
+	GetStorageIDs(context.Context, *GetStorageIDsRequest) (*GetStorageIDsResponse, error)
+	GetObjectHandles(context.Context, *GetObjectHandlesRequest) (*GetObjectHandlesResponse, error)
+	GetObjectInfo(context.Context, *GetObjectInfoRequest) (*ObjectInfo, error)
+	GetThumb(context.Context, *GetThumbRequest) (*GetThumbResponse, error)
+	DeleteObject(context.Context, *DeleteObjectRequest) (*DeleteObjectResponse, error)
+	GetDevicePropDesc(context.Context, *GetDevicePropDescRequest) (*DevicePropDesc, error)
+	SetDevicePropValue(context.Context, *SetDevicePropValueRequest) (*SetDevicePropValueResponse, error)
+	InitiateCapture(context.Context, *InitiateCaptureRequest) (*InitiateCaptureResponse, error)
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	StreamLiveView(*StreamLiveViewRequest, grpc.ServerStreamingServer[LiveViewFrame]) error
+	// DownloadObject streams the object from offset with GetPartialObject.
+	DownloadObject(*DownloadObjectRequest, grpc.ServerStreamingServer[ObjectChunk]) error
+	// UploadObject takes the ObjectInfo in the first message and the data in the following ones.
+	UploadObject(grpc.ClientStreamingServer[UploadObjectRequest, UploadObjectResponse]) error
+	mustEmbedUnimplementedCameraServer()
+}
+
+// UnimplementedCameraServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCameraServer struct{}
+
+func (UnimplementedCameraServer) GetDeviceInfo(context.Context, *GetDeviceInfoRequest) (*DeviceInfo, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDeviceInfo not implemented")
+}
+func (UnimplementedCameraServer) Operation(context.Context, *OperationRequest) (*OperationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Operation not implemented")
+}
+func (UnimplementedCameraServer) GetStorageIDs(context.Context, *GetStorageIDsRequest) (*GetStorageIDsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStorageIDs not implemented")
+}
+func (UnimplementedCameraServer) GetObjectHandles(context.Context, *GetObjectHandlesRequest) (*GetObjectHandlesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetObjectHandles not implemented")
+}
+func (UnimplementedCameraServer) GetObjectInfo(context.Context, *GetObjectInfoRequest) (*ObjectInfo, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetObjectInfo not implemented")
+}
+func (UnimplementedCameraServer) GetThumb(context.Context, *GetThumbRequest) (*GetThumbResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetThumb not implemented")
+}
+func (UnimplementedCameraServer) DeleteObject(context.Context, *DeleteObjectRequest) (*DeleteObjectResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteObject not implemented")
+}
+func (UnimplementedCameraServer) GetDevicePropDesc(context.Context, *GetDevicePropDescRequest) (*DevicePropDesc, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDevicePropDesc not implemented")
+}
+func (UnimplementedCameraServer) SetDevicePropValue(context.Context, *SetDevicePropValueRequest) (*SetDevicePropValueResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetDevicePropValue not implemented")
+}
+func (UnimplementedCameraServer) InitiateCapture(context.Context, *InitiateCaptureRequest) (*InitiateCaptureResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method InitiateCapture not implemented")
+}
+func (UnimplementedCameraServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedCameraServer) StreamLiveView(*StreamLiveViewRequest, grpc.ServerStreamingServer[LiveViewFrame]) error {
+	return status.Error(codes.Unimplemented, "method StreamLiveView not implemented")
+}
+func (UnimplementedCameraServer) DownloadObject(*DownloadObjectRequest, grpc.ServerStreamingServer[ObjectChunk]) error {
+	return status.Error(codes.Unimplemented, "method DownloadObject not implemented")
+}
+func (UnimplementedCameraServer) UploadObject(grpc.ClientStreamingServer[UploadObjectRequest, UploadObjectResponse]) error {
+	return status.Error(codes.Unimplemented, "method UploadObject not implemented")
+}
+func (UnimplementedCameraServer) mustEmbedUnimplementedCameraServer() {}
+func (UnimplementedCameraServer) testEmbeddedByValue()                {}
+
+// UnsafeCameraServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CameraServer will
+// result in compilation errors.
+type UnsafeCameraServer interface {
+	mustEmbedUnimplementedCameraServer()
+}
+
+func RegisterCameraServer(s grpc.ServiceRegistrar, srv CameraServer) {
+	// If the following call panics, it indicates UnimplementedCameraServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Camera_ServiceDesc, srv)
+}
+
+func _Camera_GetDeviceInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeviceInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).GetDeviceInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_GetDeviceInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).GetDeviceInfo(ctx, req.(*GetDeviceInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_Operation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).Operation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_Operation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).Operation(ctx, req.(*OperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_GetStorageIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStorageIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).GetStorageIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_GetStorageIDs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).GetStorageIDs(ctx, req.(*GetStorageIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_GetObjectHandles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetObjectHandlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).GetObjectHandles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_GetObjectHandles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).GetObjectHandles(ctx, req.(*GetObjectHandlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_GetObjectInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetObjectInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).GetObjectInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_GetObjectInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).GetObjectInfo(ctx, req.(*GetObjectInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_GetThumb_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThumbRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).GetThumb(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_GetThumb_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).GetThumb(ctx, req.(*GetThumbRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_DeleteObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).DeleteObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_DeleteObject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).DeleteObject(ctx, req.(*DeleteObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_GetDevicePropDesc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDevicePropDescRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).GetDevicePropDesc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_GetDevicePropDesc_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).GetDevicePropDesc(ctx, req.(*GetDevicePropDescRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_SetDevicePropValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDevicePropValueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).SetDevicePropValue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_SetDevicePropValue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).SetDevicePropValue(ctx, req.(*SetDevicePropValueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_InitiateCapture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitiateCaptureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CameraServer).InitiateCapture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Camera_InitiateCapture_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CameraServer).InitiateCapture(ctx, req.(*InitiateCaptureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Camera_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CameraServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+func _Camera_StreamLiveView_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamLiveViewRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CameraServer).StreamLiveView(m, &grpc.GenericServerStream[StreamLiveViewRequest, LiveViewFrame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_StreamLiveViewServer = grpc.ServerStreamingServer[LiveViewFrame]
+
+func _Camera_DownloadObject_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadObjectRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CameraServer).DownloadObject(m, &grpc.GenericServerStream[DownloadObjectRequest, ObjectChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_DownloadObjectServer = grpc.ServerStreamingServer[ObjectChunk]
+
+func _Camera_UploadObject_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CameraServer).UploadObject(&grpc.GenericServerStream[UploadObjectRequest, UploadObjectResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Camera_UploadObjectServer = grpc.ClientStreamingServer[UploadObjectRequest, UploadObjectResponse]
+
+// Camera_ServiceDesc is the grpc.ServiceDesc for Camera service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Camera_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ptpip.v1.Camera",
+	HandlerType: (*CameraServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDeviceInfo",
+			Handler:    _Camera_GetDeviceInfo_Handler,
+		},
+		{
+			MethodName: "Operation",
+			Handler:    _Camera_Operation_Handler,
+		},
+		{
+			MethodName: "GetStorageIDs",
+			Handler:    _Camera_GetStorageIDs_Handler,
+		},
+		{
+			MethodName: "GetObjectHandles",
+			Handler:    _Camera_GetObjectHandles_Handler,
+		},
+		{
+			MethodName: "GetObjectInfo",
+			Handler:    _Camera_GetObjectInfo_Handler,
+		},
+		{
+			MethodName: "GetThumb",
+			Handler:    _Camera_GetThumb_Handler,
+		},
+		{
+			MethodName: "DeleteObject",
+			Handler:    _Camera_DeleteObject_Handler,
+		},
+		{
+			MethodName: "GetDevicePropDesc",
+			Handler:    _Camera_GetDevicePropDesc_Handler,
+		},
+		{
+			MethodName: "SetDevicePropValue",
+			Handler:    _Camera_SetDevicePropValue_Handler,
+		},
+		{
+			MethodName: "InitiateCapture",
+			Handler:    _Camera_InitiateCapture_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Camera_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamLiveView",
+			Handler:       _Camera_StreamLiveView_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DownloadObject",
+			Handler:       _Camera_DownloadObject_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadObject",
+			Handler:       _Camera_UploadObject_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "camera.proto",
+}
@@ -0,0 +1,579 @@
+// Package rpc serves a ptpip.Client over gRPC.
+//
+// The service is defined in pb/camera.proto. Server implements it over a
+// connected Client and Client is a stub for the remote side. Regenerate the
+// pb package after changing the proto with
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative pb/camera.proto
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/liveview"
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/rpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// chunkSize is the size of the data messages sent by DownloadObject and UploadObject
+	chunkSize = 1 << 20
+
+	// defaultFPS is the live view frame rate used when the request does not set one
+	defaultFPS = 10
+
+	// maxObjectSize is the largest upload, ObjectCompressedSize is 32 bits
+	maxObjectSize = 0xFFFFFFFF
+)
+
+// Server implements pb.CameraServer over a connected ptpip.Client.
+type Server struct {
+	pb.UnimplementedCameraServer
+
+	c   *ptpip.Client
+	src liveview.Source
+
+	// mu serializes the requests on the single session, so that the
+	// transactions of one request, e.g. SendObjectInfo and SendObject, are
+	// not interleaved with another's. Operation can run any operation and
+	// is serialized as well. A download holds it only for each
+	// GetPartialObject, not while the chunk is sent.
+	mu sync.Mutex
+
+	// maxUploadSize bounds the object UploadObject buffers
+	maxUploadSize int64
+}
+
+// NewServer returns a Server for c. src provides live view frames, e.g.
+// canon.Camera, and may be nil if the camera has no live view.
+func NewServer(c *ptpip.Client, src liveview.Source) *Server {
+	return &Server{c: c, src: src, maxUploadSize: maxObjectSize}
+}
+
+// SetMaxUploadSize limits the size of the objects accepted by UploadObject,
+// which buffers the object before sending it. The limit cannot exceed the
+// default of 4 GiB - 1, the largest ObjectCompressedSize.
+func (s *Server) SetMaxUploadSize(n int64) {
+	if n <= 0 || maxObjectSize < n {
+		n = maxObjectSize
+	}
+	s.maxUploadSize = n
+}
+
+// GetDeviceInfo ...
+func (s *Server) GetDeviceInfo(ctx context.Context, req *pb.GetDeviceInfoRequest) (*pb.DeviceInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := s.c.GetDeviceInfo()
+	if err != nil {
+		return nil, deviceError(err)
+	}
+
+	resp := &pb.DeviceInfo{
+		StandardVersion:           uint32(info.StandardVersion),
+		VendorExtensionId:         info.VendorExtensionID,
+		VendorExtensionVersion:    uint32(info.VendorExtensionVersion),
+		VendorExtensionDesc:       info.VendorExtensionDesc,
+		FunctionalMode:            uint32(info.FunctionalMode),
+		OperationsSupported:       u16s(info.OperationsSupported),
+		EventsSupported:           u16s(info.EventsSupported),
+		DevicePropertiesSupported: u16s(info.DevicePropertiesSupported),
+		CaptureFormats:            u16s(info.CaptureFormats),
+		ImageFormats:              u16s(info.ImageFormats),
+		Manufacturer:              info.Manufacturer,
+		Model:                     info.Model,
+		DeviceVersion:             info.DeviceVersion,
+		SerialNumber:              info.SerialNumber,
+	}
+	if v := s.c.Vendor(); v != nil {
+		resp.Vendor = v.Name
+	}
+	return resp, nil
+}
+
+// Operation ...
+func (s *Server) Operation(ctx context.Context, req *pb.OperationRequest) (*pb.OperationResponse, error) {
+	if req.OperationCode > 0xFFFF {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid operation code 0x%x", req.OperationCode)
+	}
+	if len(req.Params) > 4 {
+		return nil, status.Errorf(codes.InvalidArgument, "too many params %d", len(req.Params))
+	}
+	var p [4]uint32
+	copy(p[:], req.Params)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, resp, err := s.c.TransactionWithResponse(uint16(req.OperationCode), req.DataPhase, p[0], p[1], p[2], p[3], req.Data)
+	if resp == nil {
+		return nil, deviceError(err)
+	}
+
+	// a response code other than OK is part of the result, not an RPC error
+	return &pb.OperationResponse{
+		ResponseCode: uint32(resp.ResponseCode),
+		Params:       []uint32{resp.P1, resp.P2, resp.P3, resp.P4},
+		Data:         data,
+	}, nil
+}
+
+// GetStorageIDs ...
+func (s *Server) GetStorageIDs(ctx context.Context, req *pb.GetStorageIDsRequest) (*pb.GetStorageIDsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.c.GetStorageIDs()
+	if err != nil {
+		return nil, deviceError(err)
+	}
+	return &pb.GetStorageIDsResponse{StorageIds: ids}, nil
+}
+
+// GetObjectHandles ...
+func (s *Server) GetObjectHandles(ctx context.Context, req *pb.GetObjectHandlesRequest) (*pb.GetObjectHandlesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	handles, err := s.c.GetObjectHandles(req.StorageId, uint16(req.ObjectFormat), req.Parent)
+	if err != nil {
+		return nil, deviceError(err)
+	}
+	return &pb.GetObjectHandlesResponse{Handles: handles}, nil
+}
+
+// GetObjectInfo ...
+func (s *Server) GetObjectInfo(ctx context.Context, req *pb.GetObjectInfoRequest) (*pb.ObjectInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := s.c.GetObjectInfo(req.Handle)
+	if err != nil {
+		return nil, deviceError(err)
+	}
+	return objectInfoToPB(info), nil
+}
+
+// GetThumb ...
+func (s *Server) GetThumb(ctx context.Context, req *pb.GetThumbRequest) (*pb.GetThumbResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.c.GetThumb(req.Handle)
+	if err == ptpip.ErrNoThumbnailPresent {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, deviceError(err)
+	}
+	return &pb.GetThumbResponse{Data: data}, nil
+}
+
+// DeleteObject ...
+func (s *Server) DeleteObject(ctx context.Context, req *pb.DeleteObjectRequest) (*pb.DeleteObjectResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.c.DeleteObject(req.Handle); err != nil {
+		return nil, deviceError(err)
+	}
+	return &pb.DeleteObjectResponse{}, nil
+}
+
+// GetDevicePropDesc ...
+func (s *Server) GetDevicePropDesc(ctx context.Context, req *pb.GetDevicePropDescRequest) (*pb.DevicePropDesc, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	desc, err := s.c.GetDevicePropDesc(uint16(req.Code))
+	if err != nil {
+		return nil, deviceError(err)
+	}
+
+	resp := &pb.DevicePropDesc{
+		Code:     uint32(desc.DevicePropCode),
+		DataType: uint32(desc.DataType),
+		GetSet:   uint32(desc.GetSet),
+		FormFlag: uint32(desc.FormFlag),
+	}
+	values := []struct {
+		dst **pb.Value
+		v   interface{}
+	}{
+		{&resp.FactoryDefault, desc.FactoryDefault},
+		{&resp.Current, desc.Current},
+		{&resp.Min, desc.Min},
+		{&resp.Max, desc.Max},
+		{&resp.Step, desc.Step},
+	}
+	for _, x := range values {
+		if x.v == nil {
+			continue
+		}
+		if *x.dst, err = valueToPB(x.v, desc.DataType); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	for _, v := range desc.Values {
+		pv, err := valueToPB(v, desc.DataType)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		resp.Values = append(resp.Values, pv)
+	}
+	return resp, nil
+}
+
+// SetDevicePropValue encodes the value as the data type of the property.
+// A raw value is sent as is.
+func (s *Server) SetDevicePropValue(ctx context.Context, req *pb.SetDevicePropValueRequest) (*pb.SetDevicePropValueResponse, error) {
+	if req.Value.GetValue() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing value")
+	}
+	code := uint16(req.Code)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := req.Value.GetRaw()
+	if _, raw := req.Value.Value.(*pb.Value_Raw); !raw {
+		desc, err := s.c.GetDevicePropDesc(code)
+		if err != nil {
+			return nil, deviceError(err)
+		}
+
+		var v interface{}
+		switch x := req.Value.Value.(type) {
+		case *pb.Value_Int:
+			v = x.Int
+		case *pb.Value_Uint:
+			v = x.Uint
+		case *pb.Value_String_:
+			v = x.String_
+		}
+		if data, err = packet.EncodeValue(v, desc.DataType); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if err := s.c.SetDevicePropValue(code, data); err != nil {
+		return nil, deviceError(err)
+	}
+	return &pb.SetDevicePropValueResponse{}, nil
+}
+
+// InitiateCapture ...
+func (s *Server) InitiateCapture(ctx context.Context, req *pb.InitiateCaptureRequest) (*pb.InitiateCaptureResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.c.InitiateCapture(req.StorageId, uint16(req.ObjectFormat)); err != nil {
+		return nil, deviceError(err)
+	}
+	return &pb.InitiateCaptureResponse{}, nil
+}
+
+// StreamEvents sends the events of the camera until the client cancels or
+// the event connection closes.
+func (s *Server) StreamEvents(req *pb.StreamEventsRequest, stream pb.Camera_StreamEventsServer) error {
+	events, cancel := s.c.Subscribe()
+	defer cancel()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "event connection closed")
+			}
+			name, ok := s.c.Vendor().EventName(e.EventCode)
+			if !ok {
+				name = packet.EventCode(e.EventCode).String()
+			}
+			err := stream.Send(&pb.Event{
+				Code:          uint32(e.EventCode),
+				Name:          name,
+				TransactionId: e.TransactionID,
+				Params:        []uint32{e.P1, e.P2, e.P3},
+			})
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// StreamLiveView sends live view frames at the requested rate until the
+// client cancels. Frames the client cannot keep up with are dropped.
+func (s *Server) StreamLiveView(req *pb.StreamLiveViewRequest, stream pb.Camera_StreamLiveViewServer) error {
+	if s.src == nil {
+		return status.Error(codes.Unimplemented, "live view not available")
+	}
+	fps := req.Fps
+	if fps <= 0 {
+		fps = defaultFPS
+	}
+
+	lv := liveview.NewStream(&lockedSource{s}, fps)
+	lv.Start()
+	defer lv.Stop()
+
+	for {
+		select {
+		case f := <-lv.Frames():
+			err := stream.Send(&pb.LiveViewFrame{
+				Jpeg:         f.JPEG,
+				TimeUnixNano: f.Time.UnixNano(),
+				Seq:          f.Seq,
+				Dropped:      lv.Stats().Dropped,
+			})
+			if err != nil {
+				return err
+			}
+		case <-lv.Done():
+			return status.Error(codes.Unavailable, lv.Err().Error())
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// lockedSource polls the live view source of a Server under its mu, so that
+// the frames are taken between the transactions of other requests.
+type lockedSource struct {
+	s *Server
+}
+
+func (l *lockedSource) LiveViewImage() (jpeg []byte, err error) {
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	return l.s.src.LiveViewImage()
+}
+
+// DownloadObject ...
+func (s *Server) DownloadObject(req *pb.DownloadObjectRequest, stream pb.Camera_DownloadObjectServer) error {
+	s.mu.Lock()
+	r, err := s.c.NewObjectReader(req.Handle)
+	s.mu.Unlock()
+	if err != nil {
+		return deviceError(err)
+	}
+	size := uint64(r.Size())
+	if size < req.Offset {
+		return status.Errorf(codes.OutOfRange, "offset %d beyond object size %d", req.Offset, size)
+	}
+
+	buf := make([]byte, chunkSize)
+	for off := req.Offset; off < size; {
+		s.mu.Lock()
+		n, err := r.ReadAt(buf, int64(off))
+		s.mu.Unlock()
+		if n > 0 {
+			err := stream.Send(&pb.ObjectChunk{Offset: off, Data: buf[:n], Size: size})
+			if err != nil {
+				return err
+			}
+			off += uint64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return deviceError(err)
+		}
+	}
+	return nil
+}
+
+// UploadObject ...
+func (s *Server) UploadObject(stream pb.Camera_UploadObjectServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	pi := first.GetInfo()
+	if pi == nil {
+		return status.Error(codes.InvalidArgument, "first message must hold the object info")
+	}
+
+	var buf bytes.Buffer
+	for {
+		m, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if m.GetInfo() != nil {
+			return status.Error(codes.InvalidArgument, "object info sent twice")
+		}
+		if s.maxUploadSize < int64(buf.Len())+int64(len(m.GetData())) {
+			return status.Errorf(codes.ResourceExhausted, "object larger than %d bytes", s.maxUploadSize)
+		}
+		buf.Write(m.GetData())
+	}
+
+	info, err := objectInfoFromPB(pi)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	info.ObjectCompressedSize = uint32(buf.Len())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	storageID, parent, handle, err := s.c.SendObjectInfo(info.StorageID, info.ParentObject, info)
+	if err != nil {
+		return deviceError(err)
+	}
+	if err = s.c.SendObject(buf.Bytes()); err != nil {
+		return deviceError(err)
+	}
+	return stream.SendAndClose(&pb.UploadObjectResponse{StorageId: storageID, Parent: parent, Handle: handle})
+}
+
+func objectInfoToPB(info *packet.ObjectInfo) *pb.ObjectInfo {
+	return &pb.ObjectInfo{
+		StorageId:            info.StorageID,
+		ObjectFormat:         uint32(info.ObjectFormat),
+		ProtectionStatus:     uint32(info.ProtectionStatus),
+		ObjectCompressedSize: info.ObjectCompressedSize,
+		ThumbFormat:          uint32(info.ThumbFormat),
+		ThumbCompressedSize:  info.ThumbCompressedSize,
+		ThumbPixWidth:        info.ThumbPixWidth,
+		ThumbPixHeight:       info.ThumbPixHeight,
+		ImagePixWidth:        info.ImagePixWidth,
+		ImagePixHeight:       info.ImagePixHeight,
+		ImageBitDepth:        info.ImageBitDepth,
+		ParentObject:         info.ParentObject,
+		AssociationType:      uint32(info.AssociationType),
+		AssociationDesc:      info.AssociationDesc,
+		SequenceNumber:       info.SequenceNumber,
+		Filename:             info.Filename,
+		CaptureDate:          packet.FormatDateTime(info.CaptureDate),
+		ModificationDate:     packet.FormatDateTime(info.ModificationDate),
+		Keywords:             info.Keywords,
+	}
+}
+
+func objectInfoFromPB(pi *pb.ObjectInfo) (info *packet.ObjectInfo, err error) {
+	captureDate, err := packet.ParseDateTime(pi.CaptureDate)
+	if err != nil {
+		return nil, err
+	}
+	modificationDate, err := packet.ParseDateTime(pi.ModificationDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &packet.ObjectInfo{
+		StorageID:            pi.StorageId,
+		ObjectFormat:         uint16(pi.ObjectFormat),
+		ProtectionStatus:     uint16(pi.ProtectionStatus),
+		ObjectCompressedSize: pi.ObjectCompressedSize,
+		ThumbFormat:          uint16(pi.ThumbFormat),
+		ThumbCompressedSize:  pi.ThumbCompressedSize,
+		ThumbPixWidth:        pi.ThumbPixWidth,
+		ThumbPixHeight:       pi.ThumbPixHeight,
+		ImagePixWidth:        pi.ImagePixWidth,
+		ImagePixHeight:       pi.ImagePixHeight,
+		ImageBitDepth:        pi.ImageBitDepth,
+		ParentObject:         pi.ParentObject,
+		AssociationType:      uint16(pi.AssociationType),
+		AssociationDesc:      pi.AssociationDesc,
+		SequenceNumber:       pi.SequenceNumber,
+		Filename:             pi.Filename,
+		CaptureDate:          captureDate,
+		ModificationDate:     modificationDate,
+		Keywords:             pi.Keywords,
+	}, nil
+}
+
+// valueToPB converts a value returned by packet.DecodeValue.
+// 128 bit integers and arrays are returned in PTP encoding.
+func valueToPB(v interface{}, dataType uint16) (pv *pb.Value, err error) {
+	switch x := v.(type) {
+	case int8, int16, int32, int64:
+		return &pb.Value{Value: &pb.Value_Int{Int: reflect.ValueOf(x).Int()}}, nil
+	case uint8, uint16, uint32, uint64:
+		return &pb.Value{Value: &pb.Value_Uint{Uint: reflect.ValueOf(x).Uint()}}, nil
+	case string:
+		return &pb.Value{Value: &pb.Value_String_{String_: x}}, nil
+	}
+
+	if dataType&packet.DataTypeArray == 0 {
+		// 128 bit values are decoded as []byte already
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %T for data type 0x%04x", v, dataType)
+		}
+		return &pb.Value{Value: &pb.Value_Raw{Raw: b}}, nil
+	}
+
+	a := reflect.ValueOf(v)
+	if a.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("invalid value %T for data type 0x%04x", v, dataType)
+	}
+	elemType := dataType &^ packet.DataTypeArray
+	raw := make([]byte, 4)
+	binary.LittleEndian.PutUint32(raw, uint32(a.Len()))
+	for i := 0; i < a.Len(); i++ {
+		e := a.Index(i).Interface()
+		if b, ok := e.([]byte); ok {
+			raw = append(raw, b...)
+			continue
+		}
+		b, err := packet.EncodeValue(e, elemType)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, b...)
+	}
+	return &pb.Value{Value: &pb.Value_Raw{Raw: raw}}, nil
+}
+
+func u16s(a []uint16) []uint32 {
+	b := make([]uint32, len(a))
+	for i, v := range a {
+		b[i] = uint32(v)
+	}
+	return b
+}
+
+// deviceError maps an error from the device to a gRPC status.
+func deviceError(err error) error {
+	re, ok := err.(*packet.ResponseError)
+	if !ok {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	code := codes.Unknown
+	switch re.Code {
+	case packet.ResponseCodeInvalidObjectHandle, packet.ResponseCodeInvalidStorageID, packet.ResponseCodeDevicePropNotSupported:
+		code = codes.NotFound
+	case packet.ResponseCodeOperationNotSupported:
+		code = codes.Unimplemented
+	case packet.ResponseCodeDeviceBusy:
+		code = codes.Unavailable
+	case packet.ResponseCodeAccessDenied, packet.ResponseCodeObjectWriteProtected, packet.ResponseCodeStoreReadOnly:
+		code = codes.PermissionDenied
+	case packet.ResponseCodeStoreFull:
+		code = codes.ResourceExhausted
+	case packet.ResponseCodeInvalidParameter, packet.ResponseCodeInvalidDevicePropValue, packet.ResponseCodeInvalidDevicePropFormat:
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
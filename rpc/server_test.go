@@ -0,0 +1,198 @@
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+	"github.com/takurooo/ptpip/rpc"
+	"github.com/takurooo/ptpip/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// sessionTransport serves one object and accepts uploads. It fails
+// SendObject when another operation was run after SendObjectInfo, as a
+// device does.
+type sessionTransport struct {
+	object []byte
+
+	mu          sync.Mutex
+	objectInfo  bool
+	interleaved bool
+
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (t *sessionTransport) Connect() error { return nil }
+
+func (t *sessionTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+func (t *sessionTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	// give the other requests a chance to run in between
+	time.Sleep(time.Millisecond)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp := &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}
+	objectInfo := t.objectInfo
+	t.objectInfo = false
+
+	switch packet.OperationCode(req.OperationCode) {
+	case packet.OperationCodeGetObjectInfo:
+		data, err := packet.MarshalObjectInfo(&packet.ObjectInfo{ObjectCompressedSize: uint32(len(t.object)), Filename: "DSC00001.JPG"})
+		if err != nil {
+			return nil, nil, err
+		}
+		return data, resp, nil
+	case packet.OperationCodeGetPartialObject:
+		off, n := int(req.P2), int(req.P3)
+		if len(t.object) < off+n {
+			n = len(t.object) - off
+		}
+		return t.object[off : off+n], resp, nil
+	case packet.OperationCodeSendObjectInfo:
+		t.objectInfo = true
+		resp.P1, resp.P2, resp.P3 = req.P1, req.P2, 0x20
+	case packet.OperationCodeSendObject:
+		if !objectInfo {
+			t.interleaved = true
+			resp.ResponseCode = packet.ResponseCodeNoValidObjectInfo
+		}
+	default:
+		resp.ResponseCode = packet.ResponseCodeOperationNotSupported
+	}
+	return nil, resp, nil
+}
+
+func (t *sessionTransport) RecvEvent() (*packet.EventPacket, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *sessionTransport) Cancel(transactionID uint32) error { return nil }
+
+// writerAt collects the data of a download.
+type writerAt struct {
+	b []byte
+}
+
+func (w *writerAt) WriteAt(p []byte, off int64) (int, error) {
+	if int64(len(w.b)) < off+int64(len(p)) {
+		w.b = append(w.b, make([]byte, off+int64(len(p))-int64(len(w.b)))...)
+	}
+	return copy(w.b[off:], p), nil
+}
+
+// serve runs a Server for a Client over tr and returns a connected rpc.Client.
+func serve(t *testing.T, tr ptpip.Transport, configure func(*rpc.Server)) *rpc.Client {
+	t.Helper()
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Disconnect() })
+
+	s := rpc.NewServer(c, nil)
+	if configure != nil {
+		configure(s)
+	}
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	pb.RegisterCameraServer(gs, s)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	dial := func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }
+	rc, err := rpc.Dial("passthrough:///bufconn", grpc.WithContextDialer(dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rc.Close() })
+	return rc
+}
+
+func TestUploadDuringDownload(t *testing.T) {
+	object := bytes.Repeat([]byte{0xFF, 0xD8}, 3<<20/2)
+	tr := &sessionTransport{object: object, closed: make(chan struct{})}
+	rc := serve(t, tr, nil)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			w := &writerAt{}
+			if _, err := rc.DownloadObject(ctx, 0x10, w, 0); err != nil {
+				t.Errorf("download: %v", err)
+				return
+			}
+			if !bytes.Equal(w.b, object) {
+				t.Errorf("download: len %d", len(w.b))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			info := &pb.ObjectInfo{StorageId: 0x10001, Filename: "A.JPG"}
+			if _, err := rc.UploadObject(ctx, info, bytes.NewReader([]byte{0xFF, 0xD8})); err != nil {
+				t.Errorf("upload: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if tr.interleaved {
+		t.Error("an operation was run between SendObjectInfo and SendObject")
+	}
+}
+
+func TestSlowDownload(t *testing.T) {
+	object := bytes.Repeat([]byte{0xFF, 0xD8}, 8<<20/2)
+	rc := serve(t, &sessionTransport{object: object, closed: make(chan struct{})}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the download stalls once the flow control window is full
+	stream, err := rc.CameraClient.DownloadObject(ctx, &pb.DownloadObjectRequest{Handle: 0x10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel2()
+	if _, err = rc.GetObjectInfo(ctx2, &pb.GetObjectInfoRequest{Handle: 0x10}); err != nil {
+		t.Errorf("blocked by the unread download: %v", err)
+	}
+}
+
+func TestUploadTooLarge(t *testing.T) {
+	tr := &sessionTransport{closed: make(chan struct{})}
+	rc := serve(t, tr, func(s *rpc.Server) { s.SetMaxUploadSize(4) })
+
+	info := &pb.ObjectInfo{StorageId: 0x10001, Filename: "A.JPG"}
+	_, err := rc.UploadObject(context.Background(), info, bytes.NewReader(make([]byte, 8)))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("got %v expected ResourceExhausted", err)
+	}
+	if _, err = rc.UploadObject(context.Background(), info, bytes.NewReader(make([]byte, 4))); err != nil {
+		t.Errorf("upload within the limit: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Package schedule runs timed capture sequences: interval shooting,
+// exposure brackets, focus stacks and day to night exposure ramps.
+//
+// A Scheduler sets the properties of each shot, triggers the capture and
+// waits for the CaptureComplete event before the next shot. Every shot is
+// reported to OnShot, e.g. a JSONLog.
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	defaultCompleteTimeout = 30 * time.Second
+)
+
+// ErrCaptureTimeout is returned when no CaptureComplete event arrives within CompleteTimeout.
+var ErrCaptureTimeout = errors.New("schedule: capture complete timeout")
+
+// ErrFocusUnsupported is returned for a step with FocusSteps when Scheduler.Focus is nil.
+var ErrFocusUnsupported = errors.New("schedule: focus drive unsupported")
+
+// Setting is a property value encoded as its data type, see packet.EncodeValue.
+type Setting struct {
+	Code  uint16 `json:"code"`
+	Value []byte `json:"value"`
+}
+
+// Step is one shot.
+type Step struct {
+	// Settings are set before the shot.
+	Settings []Setting
+	// FocusSteps moves the focus before the shot, see Scheduler.Focus.
+	FocusSteps int
+}
+
+// Shot is the log record of one shot.
+type Shot struct {
+	// Seq numbers the shots of the Scheduler, starting at 1.
+	Seq int
+	// Step is the index of the step in its sequence.
+	Step int
+	// Planned is the time the shot was scheduled for, zero outside of a timelapse.
+	Planned time.Time
+	Start   time.Time
+	// Duration is the time from Start to the CaptureComplete event.
+	Duration   time.Duration
+	Settings   []Setting
+	FocusSteps int
+	// Skipped is the number of interval slots missed before this shot.
+	Skipped int
+	Err     error
+}
+
+// Scheduler ...
+type Scheduler struct {
+	c *ptpip.Client
+
+	// Capture triggers a shot. The default is InitiateCapture of the
+	// default storage and format; vendors use their own, e.g.
+	// sony.Camera.Capture.
+	Capture func() error
+	// SetProperty sets a property. The default is SetDevicePropValue;
+	// vendors use their own, e.g. canon.Camera.SetDevicePropValueExRaw.
+	SetProperty func(code uint16, value []byte) error
+	// Focus moves the focus by steps toward infinity for positive steps.
+	// It is nil by default. sony.Camera.Focus takes an int16, e.g.
+	//
+	//	s.Focus = func(steps int) error { return cam.Focus(int16(steps)) }
+	Focus func(steps int) error
+
+	// CompleteEvents end a shot. The default is packet.EventCodeCaptureComplete.
+	CompleteEvents []uint16
+	// CompleteTimeout is the time to wait for a CompleteEvent, default 30s.
+	CompleteTimeout time.Duration
+	// NoWait does not wait for a CompleteEvent, for cameras that do not
+	// report it on the event connection.
+	NoWait bool
+
+	// OnShot is called after every shot.
+	OnShot func(s *Shot)
+
+	mu  sync.Mutex
+	seq int
+}
+
+// New returns a Scheduler using the standard operations of c.
+func New(c *ptpip.Client) *Scheduler {
+	s := &Scheduler{c: c}
+	s.Capture = func() error { return c.InitiateCapture(0, 0) }
+	s.SetProperty = c.SetDevicePropValue
+	return s
+}
+
+// Shoot takes the steps in order. It stops at the first failed shot.
+func (s *Scheduler) Shoot(steps []Step) error {
+	for i, step := range steps {
+		shot := s.shoot(i, step, time.Time{}, 0)
+		if shot.Err != nil {
+			return shot.Err
+		}
+	}
+	return nil
+}
+
+// shoot takes one shot and reports it.
+func (s *Scheduler) shoot(i int, step Step, planned time.Time, skipped int) (shot *Shot) {
+	s.mu.Lock()
+	s.seq++
+	shot = &Shot{Seq: s.seq, Step: i, Planned: planned, Settings: step.Settings, FocusSteps: step.FocusSteps, Skipped: skipped}
+	s.mu.Unlock()
+
+	shot.Start = time.Now()
+	shot.Err = s.take(step)
+	shot.Duration = time.Since(shot.Start)
+
+	if s.OnShot != nil {
+		s.OnShot(shot)
+	}
+	return shot
+}
+
+func (s *Scheduler) take(step Step) (err error) {
+	for _, setting := range step.Settings {
+		if err = s.SetProperty(setting.Code, setting.Value); err != nil {
+			return err
+		}
+	}
+
+	if step.FocusSteps != 0 {
+		if s.Focus == nil {
+			return ErrFocusUnsupported
+		}
+		if err = s.Focus(step.FocusSteps); err != nil {
+			return err
+		}
+	}
+
+	if s.NoWait {
+		return s.Capture()
+	}
+
+	// subscribe before the capture to not miss a fast CaptureComplete
+	events, cancel := s.c.Subscribe()
+	defer cancel()
+
+	if err = s.Capture(); err != nil {
+		return err
+	}
+	return s.waitComplete(events)
+}
+
+func (s *Scheduler) waitComplete(events <-chan *packet.EventPacket) error {
+	codes := s.CompleteEvents
+	if len(codes) == 0 {
+		codes = []uint16{uint16(packet.EventCodeCaptureComplete)}
+	}
+	timeout := s.CompleteTimeout
+	if timeout == 0 {
+		timeout = defaultCompleteTimeout
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return errors.New("schedule: event connection closed")
+			}
+			for _, code := range codes {
+				if e.EventCode == code {
+					return nil
+				}
+			}
+		case <-timer.C:
+			return ErrCaptureTimeout
+		}
+	}
+}
+
+// jsonShot is the JSON form of a Shot.
+type jsonShot struct {
+	Seq        int       `json:"seq"`
+	Step       int       `json:"step"`
+	Planned    time.Time `json:"planned,omitempty"`
+	Start      time.Time `json:"start"`
+	DurationMs float64   `json:"durationMs"`
+	Settings   []Setting `json:"settings,omitempty"`
+	FocusSteps int       `json:"focusSteps,omitempty"`
+	Skipped    int       `json:"skipped,omitempty"`
+	Err        string    `json:"error,omitempty"`
+}
+
+// JSONLog returns an OnShot function writing every shot as a JSON line to w.
+func JSONLog(w io.Writer) func(s *Shot) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	return func(s *Shot) {
+		js := jsonShot{
+			Seq:        s.Seq,
+			Step:       s.Step,
+			Planned:    s.Planned,
+			Start:      s.Start,
+			DurationMs: float64(s.Duration) / float64(time.Millisecond),
+			Settings:   s.Settings,
+			FocusSteps: s.FocusSteps,
+			Skipped:    s.Skipped,
+		}
+		if s.Err != nil {
+			js.Err = s.Err.Error()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(js)
+	}
+}
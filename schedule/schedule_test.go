@@ -0,0 +1,163 @@
+package schedule_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip/schedule"
+)
+
+const (
+	exposureTime  = 0x500D
+	exposureIndex = 0x500F
+)
+
+func setting(code uint16, v byte) schedule.Setting {
+	return schedule.Setting{Code: code, Value: []byte{v}}
+}
+
+// shotLog records the settings of every shot as "500D=1,500F=2", with
+// "!" appended to failed shots.
+type shotLog struct {
+	mu    sync.Mutex
+	shots []string
+}
+
+func (l *shotLog) onShot(s *schedule.Shot) {
+	var a []string
+	for _, setting := range s.Settings {
+		a = append(a, fmt.Sprintf("%04X=%d", setting.Code, setting.Value[0]))
+	}
+	shot := strings.Join(a, ",")
+	if s.Err != nil {
+		shot += "!"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.shots = append(l.shots, shot)
+}
+
+func TestTimelapseRamp(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	stops := func(n int) (s [][]schedule.Setting) {
+		for i := 0; i < n; i++ {
+			s = append(s, []schedule.Setting{setting(exposureTime, byte(10+i)), setting(exposureIndex, byte(20+i))})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		count int
+		steps []schedule.Step
+		ramp  *schedule.Ramp
+		// fail fails the calls of SetProperty with these numbers, from 1
+		fail   map[int]bool
+		expect []string
+	}{
+		{
+			name:   "no ramp",
+			count:  2,
+			steps:  []schedule.Step{{Settings: []schedule.Setting{setting(exposureTime, 1)}}},
+			expect: []string{"500D=1", "500D=1"},
+		},
+		{
+			name:   "every stop",
+			count:  4,
+			ramp:   &schedule.Ramp{End: 3 * interval, Stops: stops(4)},
+			expect: []string{"500D=10,500F=20", "500D=11,500F=21", "500D=12,500F=22", "500D=13,500F=23"},
+		},
+		{
+			name:   "unchanged stop",
+			count:  3,
+			ramp:   &schedule.Ramp{End: time.Hour, Stops: stops(2)},
+			expect: []string{"500D=10,500F=20", "", ""},
+		},
+		{
+			// the bracket step sets ExposureTime, the ramp value is set
+			// again with the next shot of the sequence
+			name:  "step sets ramp property",
+			count: 2,
+			steps: []schedule.Step{{Settings: []schedule.Setting{setting(exposureTime, 1)}}, {}},
+			ramp:  &schedule.Ramp{End: time.Hour, Stops: stops(1)},
+			expect: []string{
+				"500F=20,500D=1", "500D=10",
+				"500D=1", "500D=10",
+			},
+		},
+		{
+			name:   "failed ramp set again",
+			count:  3,
+			ramp:   &schedule.Ramp{End: time.Hour, Stops: stops(1)},
+			fail:   map[int]bool{2: true},
+			expect: []string{"500D=10,500F=20!", "500D=10,500F=20", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log shotLog
+			calls := 0
+			s := &schedule.Scheduler{
+				Capture: func() error { return nil },
+				SetProperty: func(code uint16, value []byte) error {
+					calls++
+					if tt.fail[calls] {
+						return errors.New("device busy")
+					}
+					return nil
+				},
+				NoWait: true,
+				OnShot: log.onShot,
+			}
+
+			err := s.RunTimelapse(make(chan struct{}), schedule.Timelapse{Interval: interval, Count: tt.count, Steps: tt.steps, Ramp: tt.ramp})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(log.shots, tt.expect) {
+				t.Errorf("got %q expected %q", log.shots, tt.expect)
+			}
+		})
+	}
+}
+
+func TestTimelapseStop(t *testing.T) {
+	var log shotLog
+	s := &schedule.Scheduler{
+		Capture: func() error { return nil },
+		NoWait:  true,
+		OnShot:  log.onShot,
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- s.RunTimelapse(stop, schedule.Timelapse{Interval: time.Hour})
+	}()
+
+	// the first shot is taken right away, the next one an hour later
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunTimelapse did not return on stop")
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.shots) != 1 {
+		t.Errorf("got %d shots expected 1", len(log.shots))
+	}
+}
@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"time"
+)
+
+// BracketProp is a property and the values it takes in a bracket.
+type BracketProp struct {
+	Code   uint16
+	Values [][]byte
+}
+
+// Bracket returns a step for every combination of the values of props,
+// the last prop changing fastest. E.g. ExposureTime and ExposureIndex
+// with 3 values each result in 9 steps.
+func Bracket(props ...BracketProp) []Step {
+	steps := []Step{{}}
+	for _, p := range props {
+		if len(p.Values) == 0 {
+			continue
+		}
+		next := make([]Step, 0, len(steps)*len(p.Values))
+		for _, step := range steps {
+			for _, v := range p.Values {
+				settings := make([]Setting, len(step.Settings), len(step.Settings)+1)
+				copy(settings, step.Settings)
+				next = append(next, Step{Settings: append(settings, Setting{Code: p.Code, Value: v})})
+			}
+		}
+		steps = next
+	}
+	return steps
+}
+
+// sets reports whether the step sets the property code.
+func (step Step) sets(code uint16) bool {
+	for _, setting := range step.Settings {
+		if setting.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// FocusStack returns shots steps moving the focus by focusSteps between
+// the shots. The first shot is taken at the current focus.
+func FocusStack(shots int, focusSteps int) []Step {
+	steps := make([]Step, shots)
+	for i := 1; i < shots; i++ {
+		steps[i].FocusSteps = focusSteps
+	}
+	return steps
+}
+
+// Ramp changes the exposure gradually over a timelapse, e.g. from day to
+// night ("holy grail" timelapse). Stops are the settings in order, e.g. in
+// 1/3 EV steps of ExposureTime and ExposureIndex. The stop is interpolated
+// linearly between Start and End, measured from the start of the timelapse,
+// and set only when it changes.
+type Ramp struct {
+	Start time.Duration
+	End   time.Duration
+	Stops [][]Setting
+}
+
+// stop returns the index of the stop at elapsed.
+func (r *Ramp) stop(elapsed time.Duration) int {
+	n := len(r.Stops)
+	switch {
+	case n == 0:
+		return -1
+	case elapsed <= r.Start:
+		return 0
+	case elapsed >= r.End:
+		return n - 1
+	}
+	f := float64(elapsed-r.Start) / float64(r.End-r.Start)
+	return int(f*float64(n-1) + 0.5)
+}
+
+// Timelapse ...
+type Timelapse struct {
+	// Interval is the time between the starts of the sequences.
+	Interval time.Duration
+	// Count is the number of sequences, 0 runs until stopped.
+	Count int
+	// Steps is the sequence taken at every interval, e.g. a Bracket.
+	// The default is a single shot.
+	Steps []Step
+	// Ramp is applied before every sequence, if set. A ramp property set
+	// by a step, e.g. by a Bracket of ExposureTime, is set to the ramp value
+	// again before the next shot that does not set it itself.
+	Ramp *Ramp
+}
+
+// RunTimelapse takes t.Steps every t.Interval until t.Count sequences are
+// taken or stop is closed. The schedule does not drift: sequence n starts
+// at n*Interval after the first one. If a sequence overruns the interval
+// the next sequence starts right away; slots overrun entirely are skipped
+// and counted in Shot.Skipped. Failed shots are logged and do not end the
+// timelapse.
+func (s *Scheduler) RunTimelapse(stop <-chan struct{}, t Timelapse) error {
+	steps := t.Steps
+	if len(steps) == 0 {
+		steps = []Step{{}}
+	}
+
+	start := time.Now()
+	lastStop := -1
+	skipped := 0
+	// dirty holds the properties whose value is not the ramp value, as a
+	// step set them or setting the ramp failed
+	dirty := make(map[uint16]bool)
+
+	for n := 0; t.Count == 0 || n < t.Count; {
+		planned := start.Add(time.Duration(n) * t.Interval)
+
+		timer := time.NewTimer(time.Until(planned))
+		select {
+		case <-stop:
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		var stop []Setting
+		changed := false
+		if t.Ramp != nil {
+			if i := t.Ramp.stop(planned.Sub(start)); i >= 0 {
+				stop = t.Ramp.Stops[i]
+				changed = i != lastStop
+				lastStop = i
+			}
+		}
+
+		for i, step := range steps {
+			var ramp []Setting
+			for _, setting := range stop {
+				if (changed || dirty[setting.Code]) && !step.sets(setting.Code) {
+					ramp = append(ramp, setting)
+				}
+			}
+			changed = false
+
+			if len(ramp) > 0 {
+				step.Settings = append(append([]Setting{}, ramp...), step.Settings...)
+			}
+			shot := s.shoot(i, step, planned, skipped)
+			skipped = 0
+
+			// set the ramp again with the next shot if it failed
+			for _, setting := range ramp {
+				dirty[setting.Code] = shot.Err != nil
+			}
+			for _, setting := range steps[i].Settings {
+				dirty[setting.Code] = true
+			}
+		}
+
+		// a late slot is taken right away, but not if the following one is due too
+		n++
+		if t.Interval > 0 {
+			for (t.Count == 0 || n+1 < t.Count) && !start.Add(time.Duration(n+1)*t.Interval).After(time.Now()) {
+				n++
+				skipped++
+			}
+		}
+	}
+	return nil
+}
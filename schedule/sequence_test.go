@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRampStop(t *testing.T) {
+	stops := make([][]Setting, 4)
+	r := &Ramp{Start: 10 * time.Second, End: 40 * time.Second, Stops: stops}
+
+	tests := []struct {
+		name    string
+		ramp    *Ramp
+		elapsed time.Duration
+		expect  int
+	}{
+		{"before start", r, 0, 0},
+		{"at start", r, 10 * time.Second, 0},
+		{"rounded down", r, 14 * time.Second, 0},
+		{"rounded up", r, 15 * time.Second, 1},
+		{"middle", r, 25 * time.Second, 2},
+		{"at end", r, 40 * time.Second, 3},
+		{"after end", r, time.Hour, 3},
+		{"no stops", &Ramp{End: time.Second}, 0, -1},
+		{"start is end", &Ramp{Start: time.Second, End: time.Second, Stops: stops}, 2 * time.Second, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ramp.stop(tt.elapsed); got != tt.expect {
+				t.Errorf("got %d expected %d", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestBracket(t *testing.T) {
+	v := func(b byte) []byte { return []byte{b} }
+	a := BracketProp{Code: 0x500D, Values: [][]byte{v(1), v(2)}}
+	b := BracketProp{Code: 0x500F, Values: [][]byte{v(3), v(4), v(5)}}
+	empty := BracketProp{Code: 0x5010}
+
+	tests := []struct {
+		name   string
+		props  []BracketProp
+		expect [][]Setting
+	}{
+		{"no props", nil, [][]Setting{nil}},
+		{"one prop", []BracketProp{a}, [][]Setting{
+			{{0x500D, v(1)}},
+			{{0x500D, v(2)}},
+		}},
+		{"last prop fastest", []BracketProp{a, b}, [][]Setting{
+			{{0x500D, v(1)}, {0x500F, v(3)}},
+			{{0x500D, v(1)}, {0x500F, v(4)}},
+			{{0x500D, v(1)}, {0x500F, v(5)}},
+			{{0x500D, v(2)}, {0x500F, v(3)}},
+			{{0x500D, v(2)}, {0x500F, v(4)}},
+			{{0x500D, v(2)}, {0x500F, v(5)}},
+		}},
+		{"prop without values", []BracketProp{empty, a}, [][]Setting{
+			{{0x500D, v(1)}},
+			{{0x500D, v(2)}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]Setting
+			for _, step := range Bracket(tt.props...) {
+				got = append(got, step.Settings)
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("got %v expected %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestBracketNoAliasing(t *testing.T) {
+	steps := Bracket(
+		BracketProp{Code: 0x500D, Values: [][]byte{{1}}},
+		BracketProp{Code: 0x500F, Values: [][]byte{{3}, {4}}},
+	)
+	// appending to one step must not change the settings of another
+	steps[0].Settings = append(steps[0].Settings, Setting{Code: 0x5010})
+	if len(steps[1].Settings) != 2 || steps[1].Settings[1].Value[0] != 4 {
+		t.Errorf("got %v", steps[1].Settings)
+	}
+}
@@ -0,0 +1,43 @@
+package sony
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/takurooo/ptpip/liveview"
+)
+
+// ErrNoLiveViewImage is returned when the live view object holds no JPEG.
+var ErrNoLiveViewImage error = liveview.NoFrameError("sony: no live view image")
+
+// LiveViewImage returns the current live view frame as JPEG.
+func (cam *Camera) LiveViewImage() (jpeg []byte, err error) {
+	data, err := cam.c.GetObject(liveViewObjectHandle)
+	if err != nil {
+		return nil, err
+	}
+	return parseLiveViewImage(data)
+}
+
+// parseLiveViewImage extracts the JPEG from the live view object, which
+// starts with Offset(4) Size(4) of the JPEG within the object.
+func parseLiveViewImage(data []byte) (jpeg []byte, err error) {
+	if 8 <= len(data) {
+		off := uint64(binary.LittleEndian.Uint32(data[0:]))
+		size := uint64(binary.LittleEndian.Uint32(data[4:]))
+		if 8 <= off && 0 < size && off+size <= uint64(len(data)) {
+			return data[off : off+size], nil
+		}
+	}
+
+	// older bodies send no header, look for the JPEG markers
+	soi := bytes.Index(data, []byte{0xFF, 0xD8})
+	if soi < 0 {
+		return nil, ErrNoLiveViewImage
+	}
+	jpeg = data[soi:]
+	if eoi := bytes.LastIndex(jpeg, []byte{0xFF, 0xD9}); 0 <= eoi {
+		jpeg = jpeg[:eoi+2]
+	}
+	return jpeg, nil
+}
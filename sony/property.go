@@ -0,0 +1,143 @@
+package sony
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Form Flag
+const (
+	FormFlagNone        uint8 = 0x00
+	FormFlagRange       uint8 = 0x01
+	FormFlagEnumeration uint8 = 0x02
+)
+
+// PropInfo is one device property of the GetAllExtDevicePropInfo dataset.
+// Values are decoded with packet.DecodeValue according to DataType.
+type PropInfo struct {
+	PropCode       uint16
+	DataType       uint16
+	GetSet         uint8
+	IsEnabled      uint8
+	FactoryDefault interface{}
+	Current        interface{}
+	FormFlag       uint8
+
+	// FormFlagRange
+	Min  interface{}
+	Max  interface{}
+	Step interface{}
+
+	// FormFlagEnumeration
+	Values []interface{}
+	// SupportedValues is the second enumeration sent from protocol version 300 on.
+	SupportedValues []interface{}
+}
+
+// ParseAllExtDevicePropInfo decodes the dataset returned by
+// GetAllExtDevicePropInfo: Count(8) followed by Count property descriptions.
+func ParseAllExtDevicePropInfo(data []byte, version uint16) (props map[uint16]*PropInfo, err error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("sony: invalid AllExtDevicePropInfo len %d", len(data))
+	}
+
+	count := binary.LittleEndian.Uint64(data)
+	props = make(map[uint16]*PropInfo)
+
+	off := 8
+	for i := uint64(0); i < count; i++ {
+		p, n, err := parsePropInfo(data[off:], version)
+		if err != nil {
+			return nil, fmt.Errorf("sony: invalid property %d at 0x%x: %v", i, off, err)
+		}
+		props[p.PropCode] = p
+		off += n
+	}
+
+	return props, nil
+}
+
+func parsePropInfo(data []byte, version uint16) (p *PropInfo, n int, err error) {
+	// PropCode(2) DataType(2) GetSet(1) IsEnabled(1)
+	if len(data) < 6 {
+		return nil, 0, fmt.Errorf("invalid len %d", len(data))
+	}
+
+	p = &PropInfo{
+		PropCode:  binary.LittleEndian.Uint16(data[0:]),
+		DataType:  binary.LittleEndian.Uint16(data[2:]),
+		GetSet:    data[4],
+		IsEnabled: data[5],
+	}
+	n = 6
+
+	value := func() (v interface{}) {
+		if err != nil {
+			return nil
+		}
+		v, m, e := packet.DecodeValue(data[n:], p.DataType)
+		err = e
+		n += m
+		return v
+	}
+	list := func() (values []interface{}) {
+		if err != nil {
+			return nil
+		}
+		if len(data) < n+2 {
+			err = fmt.Errorf("invalid enumeration len %d", len(data)-n)
+			return nil
+		}
+		count := int(binary.LittleEndian.Uint16(data[n:]))
+		n += 2
+		for i := 0; i < count && err == nil; i++ {
+			values = append(values, value())
+		}
+		return values
+	}
+
+	p.FactoryDefault = value()
+	p.Current = value()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(data) < n+1 {
+		return nil, 0, fmt.Errorf("invalid form flag len %d", len(data)-n)
+	}
+	p.FormFlag = data[n]
+	n++
+
+	switch p.FormFlag {
+	case FormFlagRange:
+		p.Min = value()
+		p.Max = value()
+		p.Step = value()
+	case FormFlagEnumeration:
+		p.Values = list()
+		if 300 <= version {
+			p.SupportedValues = list()
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return p, n, nil
+}
+
+// GetAllExtDevicePropInfo returns all device properties keyed by property code.
+func (cam *Camera) GetAllExtDevicePropInfo() (props map[uint16]*PropInfo, err error) {
+	data, err := cam.c.Transaction(OperationCodeGetAllExtDevicePropInfo, packet.DataPhaseInfoNoDataOrDataIn, 0, 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	version := ProtocolVersion
+	if cam.info != nil {
+		version = cam.info.Version
+	}
+	return ParseAllExtDevicePropInfo(data, version)
+}
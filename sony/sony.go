@@ -0,0 +1,317 @@
+// Package sony implements the Sony SDIO extension to PTP on top of ptpip.Client.
+//
+// Sony bodies only accept remote control after the SDIO handshake performed
+// by Connect. Properties are read in one go with GetAllExtDevicePropInfo and
+// set with SetControlDeviceA (settings) or SetControlDeviceB (buttons).
+package sony
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	// VendorExtensionID is the VendorExtensionID reported by Sony in DeviceInfo.
+	VendorExtensionID uint32 = 0x00000011
+
+	// ProtocolVersion is the SDIO extension version requested by Connect.
+	ProtocolVersion uint16 = 0x00C8
+
+	shutterInterval = 100 * time.Millisecond
+)
+
+// Operation Code
+const (
+	OperationCodeSDIOConnect             uint16 = 0x9201
+	OperationCodeGetSDIOExtDeviceInfo    uint16 = 0x9202
+	OperationCodeGetExtDevicePropDesc    uint16 = 0x9203
+	OperationCodeGetExtDevicePropValue   uint16 = 0x9204
+	OperationCodeSetControlDeviceA       uint16 = 0x9205
+	OperationCodeGetControlDeviceDesc    uint16 = 0x9206
+	OperationCodeSetControlDeviceB       uint16 = 0x9207
+	OperationCodeGetAllExtDevicePropInfo uint16 = 0x9209
+)
+
+// Event Code
+const (
+	EventCodeObjectAdded     uint16 = 0xC201
+	EventCodeObjectRemoved   uint16 = 0xC202
+	EventCodePropertyChanged uint16 = 0xC203
+)
+
+// Device Property Code
+const (
+	DevicePropCodeExposureCompensation uint16 = 0xD200
+	DevicePropCodeDRangeOptimize       uint16 = 0xD201
+	DevicePropCodeImageSize            uint16 = 0xD203
+	DevicePropCodeShutterSpeed         uint16 = 0xD20D
+	DevicePropCodeColorTemp            uint16 = 0xD20F
+	DevicePropCodeCCFilter             uint16 = 0xD210
+	DevicePropCodeAspectRatio          uint16 = 0xD211
+	DevicePropCodeFocusFound           uint16 = 0xD213
+	DevicePropCodeObjectInMemory       uint16 = 0xD215
+	DevicePropCodeExposeIndex          uint16 = 0xD216
+	DevicePropCodeBatteryLevel         uint16 = 0xD218
+	DevicePropCodePictureEffect        uint16 = 0xD21B
+	DevicePropCodeABFilter             uint16 = 0xD21C
+	DevicePropCodeISO                  uint16 = 0xD21E
+	DevicePropCodeAutoFocus            uint16 = 0xD2C1 // S1 button
+	DevicePropCodeCapture              uint16 = 0xD2C2 // S2 button
+	DevicePropCodeStillImage           uint16 = 0xD2C7
+	DevicePropCodeMovie                uint16 = 0xD2C8
+	DevicePropCodeNearFar              uint16 = 0xD2D1
+	DevicePropCodeZoom                 uint16 = 0xD2DD
+)
+
+// Button value for SetControlDeviceB
+const (
+	ButtonUp   uint16 = 0x0001
+	ButtonDown uint16 = 0x0002
+)
+
+// Zoom value
+const (
+	ZoomWide int8 = -1
+	ZoomStop int8 = 0
+	ZoomTele int8 = 1
+)
+
+const (
+	// liveViewObjectHandle is the object handle of the current live view frame
+	liveViewObjectHandle uint32 = 0xFFFFC002
+)
+
+// Vendor is the Sony vendor extension registered with ptpip.
+var Vendor = &ptpip.Vendor{
+	Name:              "sony",
+	VendorExtensionID: VendorExtensionID,
+	Manufacturer:      "Sony",
+	Operations: map[uint16]string{
+		OperationCodeSDIOConnect:             "SDIOConnect",
+		OperationCodeGetSDIOExtDeviceInfo:    "GetSDIOExtDeviceInfo",
+		OperationCodeGetExtDevicePropDesc:    "GetExtDevicePropDesc",
+		OperationCodeGetExtDevicePropValue:   "GetExtDevicePropValue",
+		OperationCodeSetControlDeviceA:       "SetControlDeviceA",
+		OperationCodeGetControlDeviceDesc:    "GetControlDeviceDesc",
+		OperationCodeSetControlDeviceB:       "SetControlDeviceB",
+		OperationCodeGetAllExtDevicePropInfo: "GetAllExtDevicePropInfo",
+	},
+	Events: map[uint16]string{
+		EventCodeObjectAdded:     "ObjectAdded",
+		EventCodeObjectRemoved:   "ObjectRemoved",
+		EventCodePropertyChanged: "PropertyChanged",
+	},
+	DeviceProps: map[uint16]string{
+		DevicePropCodeExposureCompensation: "ExposureCompensation",
+		DevicePropCodeDRangeOptimize:       "DRangeOptimize",
+		DevicePropCodeImageSize:            "ImageSize",
+		DevicePropCodeShutterSpeed:         "ShutterSpeed",
+		DevicePropCodeColorTemp:            "ColorTemp",
+		DevicePropCodeCCFilter:             "CCFilter",
+		DevicePropCodeAspectRatio:          "AspectRatio",
+		DevicePropCodeFocusFound:           "FocusFound",
+		DevicePropCodeObjectInMemory:       "ObjectInMemory",
+		DevicePropCodeExposeIndex:          "ExposeIndex",
+		DevicePropCodeBatteryLevel:         "BatteryLevel",
+		DevicePropCodePictureEffect:        "PictureEffect",
+		DevicePropCodeABFilter:             "ABFilter",
+		DevicePropCodeISO:                  "ISO",
+		DevicePropCodeAutoFocus:            "AutoFocus",
+		DevicePropCodeCapture:              "Capture",
+		DevicePropCodeStillImage:           "StillImage",
+		DevicePropCodeMovie:                "Movie",
+		DevicePropCodeNearFar:              "NearFar",
+		DevicePropCodeZoom:                 "Zoom",
+	},
+	DevicePropTypes: map[uint16]uint16{
+		DevicePropCodeAutoFocus:  packet.DataTypeUInt16,
+		DevicePropCodeCapture:    packet.DataTypeUInt16,
+		DevicePropCodeStillImage: packet.DataTypeUInt16,
+		DevicePropCodeMovie:      packet.DataTypeUInt16,
+		DevicePropCodeNearFar:    packet.DataTypeInt16,
+		DevicePropCodeZoom:       packet.DataTypeInt8,
+	},
+	Decoders: map[uint16]ptpip.DatasetDecoder{
+		OperationCodeGetSDIOExtDeviceInfo: func(data []byte) (interface{}, error) {
+			return ParseExtDeviceInfo(data)
+		},
+		OperationCodeGetAllExtDevicePropInfo: func(data []byte) (interface{}, error) {
+			return ParseAllExtDevicePropInfo(data, ProtocolVersion)
+		},
+	},
+}
+
+func init() {
+	ptpip.RegisterVendor(Vendor)
+}
+
+// ExtDeviceInfo is the dataset returned by GetSDIOExtDeviceInfo.
+type ExtDeviceInfo struct {
+	Version uint16
+	// DeviceProps lists the supported device property codes.
+	DeviceProps []uint16
+	// Controls lists the codes accepted by SetControlDeviceA/B.
+	Controls []uint16
+}
+
+// ParseExtDeviceInfo decodes Version(2) followed by two uint16 arrays.
+func ParseExtDeviceInfo(data []byte) (info *ExtDeviceInfo, err error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("sony: invalid ExtDeviceInfo len %d", len(data))
+	}
+
+	info = &ExtDeviceInfo{Version: binary.LittleEndian.Uint16(data)}
+	off := 2
+
+	v, n, err := packet.DecodeValue(data[off:], packet.DataTypeArray|packet.DataTypeUInt16)
+	if err != nil {
+		return nil, fmt.Errorf("sony: invalid ExtDeviceInfo: %v", err)
+	}
+	info.DeviceProps = v.([]uint16)
+	off += n
+
+	// older bodies omit the control list
+	if off < len(data) {
+		v, _, err = packet.DecodeValue(data[off:], packet.DataTypeArray|packet.DataTypeUInt16)
+		if err != nil {
+			return nil, fmt.Errorf("sony: invalid ExtDeviceInfo: %v", err)
+		}
+		info.Controls = v.([]uint16)
+	}
+
+	return info, nil
+}
+
+// Camera wraps a ptpip.Client connected to a Sony body.
+type Camera struct {
+	c    *ptpip.Client
+	info *ExtDeviceInfo
+}
+
+// New ...
+func New(c *ptpip.Client) *Camera {
+	return &Camera{c: c}
+}
+
+// Client returns the underlying ptpip.Client.
+func (cam *Camera) Client() *ptpip.Client {
+	return cam.c
+}
+
+// ExtDeviceInfo returns the dataset received during the SDIO handshake.
+func (cam *Camera) ExtDeviceInfo() *ExtDeviceInfo {
+	return cam.info
+}
+
+// Connect establishes the PTP-IP connection, opens a session and performs
+// the SDIO handshake: SDIOConnect phase 1 and 2, GetSDIOExtDeviceInfo and
+// SDIOConnect phase 3.
+func (cam *Camera) Connect(sessionID uint32) (err error) {
+	if err = cam.c.Connect(); err != nil {
+		return err
+	}
+	if err = cam.c.OpenSession(sessionID); err != nil {
+		return err
+	}
+	return cam.Handshake()
+}
+
+// Handshake performs the SDIO handshake on an open session.
+func (cam *Camera) Handshake() (err error) {
+	if err = cam.SDIOConnect(1); err != nil {
+		return err
+	}
+	if err = cam.SDIOConnect(2); err != nil {
+		return err
+	}
+	if cam.info, err = cam.GetSDIOExtDeviceInfo(ProtocolVersion); err != nil {
+		return err
+	}
+	if err = cam.SDIOConnect(3); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SDIOConnect ...
+func (cam *Camera) SDIOConnect(phase uint32) (err error) {
+	_, err = cam.c.Transaction(OperationCodeSDIOConnect, packet.DataPhaseInfoNoDataOrDataIn, phase, 0, 0, 0, nil)
+	if err != nil {
+		return fmt.Errorf("sony: SDIOConnect phase %d: %v", phase, err)
+	}
+	return nil
+}
+
+// GetSDIOExtDeviceInfo ...
+func (cam *Camera) GetSDIOExtDeviceInfo(version uint16) (info *ExtDeviceInfo, err error) {
+	data, err := cam.c.Transaction(OperationCodeGetSDIOExtDeviceInfo, packet.DataPhaseInfoNoDataOrDataIn, uint32(version), 0, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseExtDeviceInfo(data)
+}
+
+// SetControlDeviceA changes a setting such as ISO or ShutterSpeed.
+func (cam *Camera) SetControlDeviceA(propCode uint16, value interface{}, dataType uint16) (err error) {
+	return cam.setControlDevice(OperationCodeSetControlDeviceA, propCode, value, dataType)
+}
+
+// SetControlDeviceB operates a control such as the shutter button or zoom lever.
+func (cam *Camera) SetControlDeviceB(propCode uint16, value interface{}, dataType uint16) (err error) {
+	return cam.setControlDevice(OperationCodeSetControlDeviceB, propCode, value, dataType)
+}
+
+func (cam *Camera) setControlDevice(opCode uint16, propCode uint16, value interface{}, dataType uint16) (err error) {
+	data, err := packet.EncodeValue(value, dataType)
+	if err != nil {
+		return err
+	}
+	_, err = cam.c.Transaction(opCode, packet.DataPhaseInfoDataOut, uint32(propCode), 0, 0, 0, data)
+	return err
+}
+
+// HalfPress presses (down true) or releases the shutter button halfway, which focuses.
+func (cam *Camera) HalfPress(down bool) (err error) {
+	return cam.SetControlDeviceB(DevicePropCodeAutoFocus, buttonValue(down), packet.DataTypeUInt16)
+}
+
+// FullPress presses (down true) or releases the shutter button fully.
+func (cam *Camera) FullPress(down bool) (err error) {
+	return cam.SetControlDeviceB(DevicePropCodeCapture, buttonValue(down), packet.DataTypeUInt16)
+}
+
+// Capture takes a picture by pressing and releasing the shutter button.
+func (cam *Camera) Capture() (err error) {
+	if err = cam.HalfPress(true); err != nil {
+		return err
+	}
+	defer cam.HalfPress(false)
+
+	time.Sleep(shutterInterval)
+	if err = cam.FullPress(true); err != nil {
+		return err
+	}
+	time.Sleep(shutterInterval)
+	return cam.FullPress(false)
+}
+
+// Focus moves the focus by steps, towards near for negative values and far for positive values.
+func (cam *Camera) Focus(steps int16) (err error) {
+	return cam.SetControlDeviceB(DevicePropCodeNearFar, steps, packet.DataTypeInt16)
+}
+
+// Zoom drives the zoom lever with ZoomWide, ZoomTele or ZoomStop.
+func (cam *Camera) Zoom(direction int8) (err error) {
+	return cam.SetControlDeviceB(DevicePropCodeZoom, direction, packet.DataTypeInt8)
+}
+
+func buttonValue(down bool) uint16 {
+	if down {
+		return ButtonDown
+	}
+	return ButtonUp
+}
@@ -0,0 +1,237 @@
+package ptpip
+
+import (
+	"sync"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// StorageWatchOptions ...
+type StorageWatchOptions struct {
+	// MinFreeBytes and MinFreeImages are the thresholds below which a
+	// storage is low on space. Zero disables a threshold.
+	MinFreeBytes  uint64
+	MinFreeImages uint32
+
+	// OnChange is called when a storage is added or its StorageInfo
+	// changes, and with a nil info when it is removed.
+	OnChange func(storageID uint32, info *packet.StorageInfo)
+	// OnLow is called when a storage falls below a threshold or the device
+	// reports StoreFull. It is called again only after the storage
+	// recovered above the thresholds and, after StoreFull, its free space
+	// increased.
+	OnLow func(storageID uint32, info *packet.StorageInfo)
+	// OnError is called when a StorageInfo cannot be read.
+	OnError func(storageID uint32, err error)
+}
+
+// StorageWatcher keeps the StorageInfo of all storages up to date with the
+// StoreAdded, StoreRemoved, StorageInfoChanged and StoreFull events. As not
+// all devices report StorageInfoChanged when an object is written, the
+// storages are also refreshed on ObjectAdded and ObjectRemoved.
+type StorageWatcher struct {
+	c    *Client
+	opts StorageWatchOptions
+
+	mu    sync.Mutex
+	infos map[uint32]*packet.StorageInfo
+	low   map[uint32]bool
+	// full holds the StorageInfo read on StoreFull until the free space increases
+	full map[uint32]*packet.StorageInfo
+
+	cancel func()
+	done   chan struct{}
+}
+
+// WatchStorages reads the StorageInfo of all storages and keeps it up to
+// date until Stop is called. The session must be open.
+func (c *Client) WatchStorages(opts StorageWatchOptions) (w *StorageWatcher, err error) {
+	w = &StorageWatcher{
+		c:     c,
+		opts:  opts,
+		infos: make(map[uint32]*packet.StorageInfo),
+		low:   make(map[uint32]bool),
+		full:  make(map[uint32]*packet.StorageInfo),
+		done:  make(chan struct{}),
+	}
+
+	// subscribe first to not miss changes during the initial refresh
+	events, cancel := c.Subscribe()
+	if err = w.Refresh(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	w.cancel = cancel
+	go w.run(events)
+
+	return w, nil
+}
+
+// Storages returns the current StorageInfo of each storage.
+func (w *StorageWatcher) Storages() map[uint32]*packet.StorageInfo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	infos := make(map[uint32]*packet.StorageInfo, len(w.infos))
+	for id, info := range w.infos {
+		infos[id] = info
+	}
+	return infos
+}
+
+// Storage returns the current StorageInfo of storageID, or nil.
+func (w *StorageWatcher) Storage(storageID uint32) *packet.StorageInfo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.infos[storageID]
+}
+
+// Refresh reads the storage IDs and the StorageInfo of every storage.
+func (w *StorageWatcher) Refresh() (err error) {
+	ids, err := w.c.GetStorageIDs()
+	if err != nil {
+		return err
+	}
+
+	present := make(map[uint32]bool, len(ids))
+	for _, id := range ids {
+		present[id] = true
+		w.update(id)
+	}
+
+	w.mu.Lock()
+	var removed []uint32
+	for id := range w.infos {
+		if !present[id] {
+			removed = append(removed, id)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, id := range removed {
+		w.remove(id)
+	}
+	return nil
+}
+
+// Stop ends watching the events.
+func (w *StorageWatcher) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+func (w *StorageWatcher) run(events <-chan *packet.EventPacket) {
+	defer close(w.done)
+
+	for e := range events {
+		switch packet.EventCode(e.EventCode) {
+		case packet.EventCodeStoreAdded, packet.EventCodeStorageInfoChanged:
+			w.update(e.P1)
+		case packet.EventCodeStoreRemoved:
+			w.remove(e.P1)
+		case packet.EventCodeStoreFull:
+			w.storeFull(e.P1)
+		case packet.EventCodeObjectAdded, packet.EventCodeObjectRemoved:
+			if err := w.Refresh(); err != nil && w.opts.OnError != nil {
+				w.opts.OnError(0, err)
+			}
+		}
+	}
+}
+
+// update reads the StorageInfo of storageID and reports the changes. It
+// returns nil if the StorageInfo cannot be read.
+func (w *StorageWatcher) update(storageID uint32) (info *packet.StorageInfo) {
+	info, err := w.c.GetStorageInfo(storageID)
+	if err != nil {
+		if w.opts.OnError != nil {
+			w.opts.OnError(storageID, err)
+		}
+		return nil
+	}
+
+	w.mu.Lock()
+	old := w.infos[storageID]
+	w.infos[storageID] = info
+	w.mu.Unlock()
+
+	if w.opts.OnChange != nil && (old == nil || *old != *info) {
+		w.opts.OnChange(storageID, info)
+	}
+
+	if w.isLow(info) {
+		w.setLow(storageID, info)
+		return info
+	}
+
+	w.mu.Lock()
+	if full := w.full[storageID]; full == nil || hasMoreSpace(info, full) {
+		delete(w.full, storageID)
+		delete(w.low, storageID)
+	}
+	w.mu.Unlock()
+	return info
+}
+
+// storeFull reports storageID as low after StoreFull. It stays low until
+// its free space increases, as the thresholds may be below the space the
+// device needs.
+func (w *StorageWatcher) storeFull(storageID uint32) {
+	info := w.update(storageID)
+	if info == nil {
+		return
+	}
+
+	w.mu.Lock()
+	if _, ok := w.full[storageID]; !ok {
+		w.full[storageID] = info
+	}
+	w.mu.Unlock()
+
+	w.setLow(storageID, info)
+}
+
+// hasMoreSpace reports whether info has more free space than full.
+func hasMoreSpace(info, full *packet.StorageInfo) bool {
+	if full.FreeSpaceInBytes < info.FreeSpaceInBytes {
+		return true
+	}
+	return info.FreeSpaceInImages != packet.FreeSpaceInImagesUnused && full.FreeSpaceInImages != packet.FreeSpaceInImagesUnused && full.FreeSpaceInImages < info.FreeSpaceInImages
+}
+
+func (w *StorageWatcher) remove(storageID uint32) {
+	w.mu.Lock()
+	_, ok := w.infos[storageID]
+	delete(w.infos, storageID)
+	delete(w.low, storageID)
+	delete(w.full, storageID)
+	w.mu.Unlock()
+
+	if ok && w.opts.OnChange != nil {
+		w.opts.OnChange(storageID, nil)
+	}
+}
+
+func (w *StorageWatcher) isLow(info *packet.StorageInfo) bool {
+	if w.opts.MinFreeBytes != 0 && info.FreeSpaceInBytes < w.opts.MinFreeBytes {
+		return true
+	}
+	if w.opts.MinFreeImages != 0 && info.FreeSpaceInImages != packet.FreeSpaceInImagesUnused && info.FreeSpaceInImages < w.opts.MinFreeImages {
+		return true
+	}
+	return false
+}
+
+// setLow reports storageID as low unless it was already reported.
+func (w *StorageWatcher) setLow(storageID uint32, info *packet.StorageInfo) {
+	w.mu.Lock()
+	reported := w.low[storageID]
+	w.low[storageID] = true
+	w.mu.Unlock()
+
+	if !reported && w.opts.OnLow != nil {
+		w.opts.OnLow(storageID, info)
+	}
+}
@@ -0,0 +1,215 @@
+package ptpip_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// storageTransport serves the StorageInfo of its storages and delivers the
+// events sent on events.
+type storageTransport struct {
+	mu   sync.Mutex
+	free map[uint32]uint64
+	fail map[uint32]bool
+
+	events chan *packet.EventPacket
+}
+
+func (t *storageTransport) Connect() error { return nil }
+
+func (t *storageTransport) Close() error {
+	close(t.events)
+	return nil
+}
+
+func (t *storageTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp := &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}
+	switch packet.OperationCode(req.OperationCode) {
+	case packet.OperationCodeGetStorageIDs:
+		data := make([]byte, 4, 4+4*len(t.free))
+		binary.LittleEndian.PutUint32(data, uint32(len(t.free)))
+		for id := range t.free {
+			data = append(data, byte(id), byte(id>>8), byte(id>>16), byte(id>>24))
+		}
+		return data, resp, nil
+	case packet.OperationCodeGetStorageInfo:
+		free, ok := t.free[req.P1]
+		if !ok || t.fail[req.P1] {
+			return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeInvalidStorageID}
+		}
+		return storageInfo(free), resp, nil
+	}
+	return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeOperationNotSupported}
+}
+
+func (t *storageTransport) RecvEvent() (*packet.EventPacket, error) {
+	e, ok := <-t.events
+	if !ok {
+		return nil, io.EOF
+	}
+	return e, nil
+}
+
+func (t *storageTransport) Cancel(transactionID uint32) error { return nil }
+
+func (t *storageTransport) set(storageID uint32, free uint64, fail bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.free[storageID] = free
+	t.fail[storageID] = fail
+}
+
+// storageInfo returns the StorageInfo dataset of a storage with free bytes.
+func storageInfo(free uint64) []byte {
+	b := make([]byte, 26, 28)
+	binary.LittleEndian.PutUint16(b[0:], packet.StorageTypeRemovableRAM)
+	binary.LittleEndian.PutUint16(b[2:], packet.FilesystemTypeDCF)
+	binary.LittleEndian.PutUint16(b[4:], packet.AccessCapabilityReadWrite)
+	binary.LittleEndian.PutUint64(b[6:], 1<<30)
+	binary.LittleEndian.PutUint64(b[14:], free)
+	binary.LittleEndian.PutUint32(b[22:], packet.FreeSpaceInImagesUnused)
+	// empty StorageDescription and VolumeLabel
+	return append(b, 0, 0)
+}
+
+// storageCalls records the callbacks of a StorageWatcher.
+type storageCalls struct {
+	mu    sync.Mutex
+	calls []string
+	sync  chan struct{}
+}
+
+// barrierID is a storage whose StorageInfoChanged marks that the events
+// before it were handled.
+const barrierID = 0xFFFF0001
+
+func (c *storageCalls) options(opts ptpip.StorageWatchOptions) ptpip.StorageWatchOptions {
+	record := func(s string) {
+		c.mu.Lock()
+		c.calls = append(c.calls, s)
+		c.mu.Unlock()
+	}
+	opts.OnChange = func(id uint32, info *packet.StorageInfo) {
+		if id == barrierID {
+			c.sync <- struct{}{}
+			return
+		}
+		if info == nil {
+			record(fmt.Sprintf("removed %d", id))
+		}
+	}
+	opts.OnLow = func(id uint32, info *packet.StorageInfo) {
+		if info == nil {
+			record(fmt.Sprintf("low %d without info", id))
+			return
+		}
+		record(fmt.Sprintf("low %d %d", id, info.FreeSpaceInBytes))
+	}
+	opts.OnError = func(id uint32, err error) {
+		record(fmt.Sprintf("error %d", id))
+	}
+	return opts
+}
+
+func (c *storageCalls) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := c.calls
+	c.calls = nil
+	return calls
+}
+
+type storageStep struct {
+	free   uint64
+	fail   bool
+	event  packet.EventCode
+	expect []string
+}
+
+func runStorageSteps(t *testing.T, opts ptpip.StorageWatchOptions, initial uint64, steps []storageStep) {
+	tr := &storageTransport{
+		free:   map[uint32]uint64{1: initial, barrierID: 0},
+		fail:   map[uint32]bool{},
+		events: make(chan *packet.EventPacket),
+	}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	calls := &storageCalls{sync: make(chan struct{}, 1)}
+	w, err := c.WatchStorages(calls.options(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	// the initial refresh reports the barrier storage as added
+	<-calls.sync
+	calls.take()
+
+	var barrier uint64
+	for i, st := range steps {
+		tr.set(1, st.free, st.fail)
+		tr.events <- &packet.EventPacket{EventCode: uint16(st.event), P1: 1}
+
+		barrier++
+		tr.set(barrierID, barrier, false)
+		tr.events <- &packet.EventPacket{EventCode: uint16(packet.EventCodeStorageInfoChanged), P1: barrierID}
+		select {
+		case <-calls.sync:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("step %d: event not handled", i)
+		}
+
+		if got := calls.take(); !reflect.DeepEqual(got, st.expect) {
+			t.Errorf("step %d: %s free %d: got %q expected %q", i, st.event, st.free, got, st.expect)
+		}
+	}
+}
+
+func TestStorageWatcherStoreFull(t *testing.T) {
+	runStorageSteps(t, ptpip.StorageWatchOptions{}, 100, []storageStep{
+		{free: 100, event: packet.EventCodeStoreFull, expect: []string{"low 1 100"}},
+		// StoreFull again and changes without more space do not report again
+		{free: 100, event: packet.EventCodeStoreFull},
+		{free: 100, event: packet.EventCodeStorageInfoChanged},
+		{free: 90, event: packet.EventCodeStorageInfoChanged},
+		// an object was deleted
+		{free: 200, event: packet.EventCodeStorageInfoChanged},
+		{free: 200, event: packet.EventCodeStoreFull, expect: []string{"low 1 200"}},
+	})
+}
+
+func TestStorageWatcherStoreFullWithoutInfo(t *testing.T) {
+	runStorageSteps(t, ptpip.StorageWatchOptions{}, 100, []storageStep{
+		{free: 100, fail: true, event: packet.EventCodeStoreFull, expect: []string{"error 1"}},
+		{free: 100, event: packet.EventCodeStoreFull, expect: []string{"low 1 100"}},
+	})
+}
+
+func TestStorageWatcherThresholds(t *testing.T) {
+	runStorageSteps(t, ptpip.StorageWatchOptions{MinFreeBytes: 50}, 100, []storageStep{
+		{free: 40, event: packet.EventCodeStorageInfoChanged, expect: []string{"low 1 40"}},
+		{free: 30, event: packet.EventCodeStorageInfoChanged},
+		{free: 60, event: packet.EventCodeStorageInfoChanged},
+		{free: 40, event: packet.EventCodeObjectAdded, expect: []string{"low 1 40"}},
+		// StoreFull of a storage already low
+		{free: 40, event: packet.EventCodeStoreFull},
+		// above the threshold after StoreFull recovers by the increase
+		{free: 60, event: packet.EventCodeStorageInfoChanged},
+		{free: 40, event: packet.EventCodeStorageInfoChanged, expect: []string{"low 1 40"}},
+		{free: 40, event: packet.EventCodeStoreRemoved, expect: []string{"removed 1"}},
+	})
+}
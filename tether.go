@@ -0,0 +1,306 @@
+package ptpip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+const (
+	defaultTetherTemplate = "{name}"
+)
+
+// TetherOptions ...
+type TetherOptions struct {
+	// Dir is the destination directory. It must exist.
+	Dir string
+
+	// Template is the path of a file relative to Dir with the placeholders
+	//
+	//	{date}   capture date as YYYYMMDD
+	//	{time}   capture time as hhmmss
+	//	{serial} serial number of the camera
+	//	{seq}    number of the object in this tether session as 0001, 0002, ...
+	//	{name}   original file name
+	//	{base}   original file name without extension
+	//	{ext}    extension of the original file name, including the dot
+	//
+	// The date and time are those of the download when the camera does not
+	// report a capture date. The default is "{name}". Subdirectories are
+	// created as needed. An existing file is not overwritten, a -1, -2, ...
+	// suffix is added instead.
+	Template string
+
+	// Delete removes each object from the camera after it was saved.
+	Delete bool
+
+	// EventCodes are the events reporting a new object handle in P1.
+	// The default is packet.EventCodeObjectAdded. Vendor events are
+	// added here, or handles are passed to Tether.Add.
+	EventCodes []uint16
+
+	// OnResult is called for every object from the tether goroutine.
+	OnResult func(r *TetherResult)
+}
+
+// TetherResult is the outcome of downloading one object.
+type TetherResult struct {
+	Handle uint32
+	Info   *packet.ObjectInfo
+	// Path is the saved file.
+	Path    string
+	Deleted bool
+	Err     error
+}
+
+// Tether downloads new objects of the camera as they are reported.
+type Tether struct {
+	c      *Client
+	opts   TetherOptions
+	serial string
+	seq    int
+
+	mu    sync.Mutex
+	queue []uint32
+	wake  chan struct{}
+
+	cancel func()
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// StartTether starts downloading the objects reported by the events of opts
+// until Stop is called. The session must be open.
+func (c *Client) StartTether(opts TetherOptions) (t *Tether, err error) {
+	fi, err := os.Stat(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", opts.Dir)
+	}
+	if opts.Template == "" {
+		opts.Template = defaultTetherTemplate
+	}
+	if len(opts.EventCodes) == 0 {
+		opts.EventCodes = []uint16{uint16(packet.EventCodeObjectAdded)}
+	}
+
+	info, err := c.GetDeviceInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	t = &Tether{
+		c:      c,
+		opts:   opts,
+		serial: info.SerialNumber,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	events, cancel := c.Subscribe()
+	t.cancel = cancel
+	go t.listen(events)
+	go t.run()
+
+	return t, nil
+}
+
+// Add queues the object for download, e.g. one reported by a vendor event
+// that does not carry the handle in P1.
+func (t *Tether) Add(handle uint32) {
+	t.mu.Lock()
+	t.queue = append(t.queue, handle)
+	t.mu.Unlock()
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop ends the tether after the object being downloaded. Objects still
+// queued are not downloaded.
+func (t *Tether) Stop() {
+	t.cancel()
+	close(t.stop)
+	<-t.done
+}
+
+// listen queues the objects of the events. Events are moved to the queue
+// right away so that the subscription does not drop them during downloads.
+func (t *Tether) listen(events <-chan *packet.EventPacket) {
+	for e := range events {
+		for _, code := range t.opts.EventCodes {
+			if e.EventCode == code {
+				t.Add(e.P1)
+				break
+			}
+		}
+	}
+}
+
+func (t *Tether) run() {
+	defer close(t.done)
+
+	for {
+		select {
+		case <-t.wake:
+		case <-t.stop:
+			return
+		}
+
+		for {
+			t.mu.Lock()
+			if len(t.queue) == 0 {
+				t.mu.Unlock()
+				break
+			}
+			handle := t.queue[0]
+			t.queue = t.queue[1:]
+			t.mu.Unlock()
+
+			r := t.fetch(handle)
+			if r != nil && t.opts.OnResult != nil {
+				t.opts.OnResult(r)
+			}
+
+			select {
+			case <-t.stop:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// fetch downloads one object. It returns nil for folders.
+func (t *Tether) fetch(handle uint32) (r *TetherResult) {
+	r = &TetherResult{Handle: handle}
+
+	r.Info, r.Err = t.c.GetObjectInfo(handle)
+	if r.Err != nil {
+		return r
+	}
+	// folders are not downloaded
+	if packet.ObjectFormatCode(r.Info.ObjectFormat) == packet.ObjectFormatCodeAssociation {
+		return nil
+	}
+
+	data, err := t.c.GetObject(handle)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	t.seq++
+	r.Path, r.Err = writeFileAtomic(filepath.Join(t.opts.Dir, t.filename(r.Info)), data)
+	if r.Err != nil {
+		return r
+	}
+
+	if t.opts.Delete {
+		if r.Err = t.c.DeleteObject(handle); r.Err == nil {
+			r.Deleted = true
+		}
+	}
+	return r
+}
+
+// filename expands the template for the object.
+func (t *Tether) filename(info *packet.ObjectInfo) string {
+	date := info.CaptureDate
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	name := safeName(info.Filename)
+	if name == "" {
+		name = fmt.Sprintf("%08X", t.seq)
+	}
+	ext := filepath.Ext(name)
+
+	r := strings.NewReplacer(
+		"{date}", date.Format("20060102"),
+		"{time}", date.Format("150405"),
+		"{serial}", safeName(t.serial),
+		"{seq}", fmt.Sprintf("%04d", t.seq),
+		"{name}", name,
+		"{base}", strings.TrimSuffix(name, ext),
+		"{ext}", ext,
+	)
+	return filepath.FromSlash(r.Replace(t.opts.Template))
+}
+
+// safeName removes path separators from a name reported by the camera.
+func safeName(s string) string {
+	s = strings.NewReplacer("/", "_", "\\", "_").Replace(s)
+	if s == "." || s == ".." {
+		return ""
+	}
+	return s
+}
+
+// writeFileAtomic writes data to a temporary file renamed to path, or to
+// path with a -1, -2, ... suffix if path exists. It returns the path written.
+func writeFileAtomic(path string, data []byte) (written string, err error) {
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return "", err
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	written = path
+	for i := 1; ; i++ {
+		// os.Link fails if the destination exists, unlike os.Rename
+		err = os.Link(tmp, written)
+		if err == nil {
+			os.Remove(tmp)
+			return written, nil
+		}
+		if !os.IsExist(err) {
+			// no hard links, e.g. on FAT
+			if _, serr := os.Stat(written); os.IsNotExist(serr) {
+				if err = os.Rename(tmp, written); err != nil {
+					return "", err
+				}
+				return written, nil
+			} else if serr != nil {
+				return "", err
+			}
+		}
+		written = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
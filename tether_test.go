@@ -0,0 +1,291 @@
+package ptpip_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// tetherObject is an object of a tetherTransport. Objects without data fail
+// GetObject.
+type tetherObject struct {
+	info *packet.ObjectInfo
+	data []byte
+}
+
+// tetherTransport serves the DeviceInfo and the objects of a camera, records
+// DeleteObject and delivers the events sent on events.
+type tetherTransport struct {
+	serial string
+
+	mu      sync.Mutex
+	objects map[uint32]tetherObject
+	deleted []uint32
+
+	events chan *packet.EventPacket
+}
+
+func (t *tetherTransport) Connect() error { return nil }
+
+func (t *tetherTransport) Close() error {
+	close(t.events)
+	return nil
+}
+
+func (t *tetherTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) ([]byte, *packet.OperationResponsePacket, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp := &packet.OperationResponsePacket{ResponseCode: packet.ResponseCodeOK, TransactionID: req.TransactionID}
+	if packet.OperationCode(req.OperationCode) == packet.OperationCodeGetDeviceInfo {
+		return deviceInfo(t.serial), resp, nil
+	}
+
+	o, ok := t.objects[req.P1]
+	if !ok {
+		return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeInvalidObjectHandle}
+	}
+	switch packet.OperationCode(req.OperationCode) {
+	case packet.OperationCodeGetObjectInfo:
+		data, err := packet.MarshalObjectInfo(o.info)
+		if err != nil {
+			return nil, nil, err
+		}
+		return data, resp, nil
+	case packet.OperationCodeGetObject:
+		if o.data == nil {
+			return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeAccessDenied}
+		}
+		return o.data, resp, nil
+	case packet.OperationCodeDeleteObject:
+		delete(t.objects, req.P1)
+		t.deleted = append(t.deleted, req.P1)
+		return nil, resp, nil
+	}
+	return nil, resp, &packet.ResponseError{Code: packet.ResponseCodeOperationNotSupported}
+}
+
+func (t *tetherTransport) RecvEvent() (*packet.EventPacket, error) {
+	e, ok := <-t.events
+	if !ok {
+		return nil, io.EOF
+	}
+	return e, nil
+}
+
+func (t *tetherTransport) Cancel(transactionID uint32) error { return nil }
+
+// deviceInfo returns the DeviceInfo dataset of a camera without vendor
+// extension.
+func deviceInfo(serial string) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint16(b[0:], 100)
+	binary.LittleEndian.PutUint16(b[6:], 100)
+	b = append(b, encodeString("")...)
+	b = append(b, 0, 0)
+	// empty supported operations, events, properties and formats
+	b = append(b, make([]byte, 5*4)...)
+	for _, s := range []string{"Maker", "Model", "1.00", serial} {
+		b = append(b, encodeString(s)...)
+	}
+	return b
+}
+
+func encodeString(s string) []byte {
+	b, err := packet.EncodeValue(s, packet.DataTypeString)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// jpeg returns the ObjectInfo of a JPEG captured at 2020-09-06 09:36:30.
+func jpeg(name string) *packet.ObjectInfo {
+	return &packet.ObjectInfo{
+		ObjectFormat: uint16(packet.ObjectFormatCodeEXIFJPEG),
+		Filename:     name,
+		CaptureDate:  time.Date(2020, 9, 6, 9, 36, 30, 0, time.UTC),
+	}
+}
+
+// startTether starts a tether over tr into a new directory and returns the
+// directory and the results on a channel.
+func startTether(t *testing.T, tr *tetherTransport, opts ptpip.TetherOptions) (tether *ptpip.Tether, dir string, results chan *ptpip.TetherResult, cleanup func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "tether")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ptpip.NewClientTransport(tr)
+	if err := c.Connect(); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	results = make(chan *ptpip.TetherResult, 16)
+	opts.Dir = dir
+	opts.OnResult = func(r *ptpip.TetherResult) { results <- r }
+	tether, err = c.StartTether(opts)
+	if err != nil {
+		c.Disconnect()
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return tether, dir, results, func() {
+		tether.Stop()
+		c.Disconnect()
+		os.RemoveAll(dir)
+	}
+}
+
+func waitResult(t *testing.T, results <-chan *ptpip.TetherResult) *ptpip.TetherResult {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tether result")
+	}
+	return nil
+}
+
+func TestTetherFilename(t *testing.T) {
+	folder := &packet.ObjectInfo{ObjectFormat: uint16(packet.ObjectFormatCodeAssociation), Filename: "100MSDCF"}
+
+	tests := []struct {
+		name     string
+		template string
+		existing []string
+		objects  []*packet.ObjectInfo
+		expect   []string
+	}{
+		{"default", "", nil, []*packet.ObjectInfo{jpeg("DSC00001.JPG")}, []string{"DSC00001.JPG"}},
+		{"date serial seq", "{date}/{serial}_{seq}{ext}", nil, []*packet.ObjectInfo{jpeg("DSC00001.JPG"), jpeg("DSC00002.ARW")}, []string{"20200906/SN_1_0001.JPG", "20200906/SN_1_0002.ARW"}},
+		{"base time", "{base}_{time}{ext}", nil, []*packet.ObjectInfo{jpeg("DSC00001.JPG")}, []string{"DSC00001_093630.JPG"}},
+		{"existing file", "", []string{"DSC00001.JPG", "DSC00001-1.JPG"}, []*packet.ObjectInfo{jpeg("DSC00001.JPG"), jpeg("DSC00001.JPG")}, []string{"DSC00001-2.JPG", "DSC00001-3.JPG"}},
+		{"separator in name", "", nil, []*packet.ObjectInfo{jpeg("A/B.JPG"), jpeg(`C\D.JPG`)}, []string{"A_B.JPG", "C_D.JPG"}},
+		{"dot name", "{name}", nil, []*packet.ObjectInfo{jpeg(".."), jpeg("")}, []string{"00000001", "00000002"}},
+		{"folder", "", nil, []*packet.ObjectInfo{folder, jpeg("DSC00001.JPG")}, []string{"DSC00001.JPG"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &tetherTransport{serial: "SN/1", objects: map[uint32]tetherObject{}, events: make(chan *packet.EventPacket)}
+			for i, info := range tt.objects {
+				tr.objects[uint32(i+1)] = tetherObject{info, []byte{byte(i), 0xFF, 0xD8}}
+			}
+			tether, dir, results, cleanup := startTether(t, tr, ptpip.TetherOptions{Template: tt.template})
+			defer cleanup()
+
+			for _, name := range tt.existing {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := range tt.objects {
+				tether.Add(uint32(i + 1))
+			}
+
+			var got []string
+			for range tt.expect {
+				r := waitResult(t, results)
+				if r.Err != nil {
+					t.Fatalf("handle %d: %v", r.Handle, r.Err)
+				}
+				rel, err := filepath.Rel(dir, r.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+
+				data, err := ioutil.ReadFile(r.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if expect := tr.objects[r.Handle].data; !bytes.Equal(data, expect) {
+					t.Errorf("%s: got % x expected % x", rel, data, expect)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("got %q expected %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestTetherDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		delete  bool
+		data    []byte
+		deleted []uint32
+		err     bool
+	}{
+		{"delete", true, []byte{0xFF, 0xD8}, []uint32{1}, false},
+		{"keep", false, []byte{0xFF, 0xD8}, nil, false},
+		{"download failed", true, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &tetherTransport{
+				objects: map[uint32]tetherObject{1: {jpeg("DSC00001.JPG"), tt.data}},
+				events:  make(chan *packet.EventPacket),
+			}
+			tether, _, results, cleanup := startTether(t, tr, ptpip.TetherOptions{Delete: tt.delete})
+			defer cleanup()
+
+			tether.Add(1)
+			r := waitResult(t, results)
+			if (r.Err != nil) != tt.err {
+				t.Errorf("got error %v", r.Err)
+			}
+			if r.Deleted != (tt.deleted != nil) {
+				t.Errorf("got Deleted %v", r.Deleted)
+			}
+			tr.mu.Lock()
+			defer tr.mu.Unlock()
+			if !reflect.DeepEqual(tr.deleted, tt.deleted) {
+				t.Errorf("got DeleteObject of %v expected %v", tr.deleted, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestTetherEvents(t *testing.T) {
+	const vendorObjectAdded = 0xC101
+
+	tr := &tetherTransport{objects: map[uint32]tetherObject{}, events: make(chan *packet.EventPacket)}
+	for h := uint32(1); h <= 3; h++ {
+		tr.objects[h] = tetherObject{jpeg(""), []byte{byte(h)}}
+	}
+	_, _, results, cleanup := startTether(t, tr, ptpip.TetherOptions{Template: "{seq}", EventCodes: []uint16{vendorObjectAdded}})
+	defer cleanup()
+
+	// ObjectAdded is not one of the EventCodes
+	tr.events <- &packet.EventPacket{EventCode: uint16(packet.EventCodeObjectAdded), P1: 1}
+	tr.events <- &packet.EventPacket{EventCode: vendorObjectAdded, P1: 2}
+	tr.events <- &packet.EventPacket{EventCode: vendorObjectAdded, P1: 3}
+
+	for _, expect := range []uint32{2, 3} {
+		if r := waitResult(t, results); r.Handle != expect || r.Err != nil {
+			t.Errorf("got handle %d error %v expected handle %d", r.Handle, r.Err, expect)
+		}
+	}
+	select {
+	case r := <-results:
+		t.Errorf("unexpected result for handle %d", r.Handle)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,113 @@
+package ptpip
+
+import (
+	"net"
+	"time"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Transport carries the transactions and events of a Client to a device.
+// The default is PTP-IP; package usb provides PTP over USB. Sessions,
+// transaction IDs, datasets and event subscriptions are handled by Client
+// above the Transport.
+type Transport interface {
+	// Connect opens the connection to the device.
+	Connect() error
+	// Close closes the connection. A blocked OperationRequest or RecvEvent returns.
+	Close() error
+
+	// OperationRequest performs one transaction.
+	// See packet.OperationRequestWithResponse.
+	OperationRequest(req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error)
+	// RecvEvent waits for the next event.
+	RecvEvent() (e *packet.EventPacket, err error)
+	// Cancel cancels the transaction in progress. It is called while
+	// OperationRequest runs in another goroutine.
+	Cancel(transactionID uint32) error
+}
+
+// ipTransport is the PTP-IP Transport. Its wire protocol is a Protocol,
+// replaced by SetProtocol for vendors deviating from PTP-IP.
+type ipTransport struct {
+	host  string
+	ini   *Initiator
+	proto Protocol
+
+	cConn net.Conn
+	eConn net.Conn
+}
+
+func (t *ipTransport) Connect() (err error) {
+	// ---------------------------------------
+	// establish connection for ptp-ip command
+	// ---------------------------------------
+	t.cConn, err = net.Dial("tcp", t.host+t.proto.CommandPort())
+	if err != nil {
+		return err
+	}
+
+	connectionNumber, err := t.proto.InitCommand(t.cConn, t.ini)
+	if err != nil {
+		t.cConn.Close()
+		return err
+	}
+
+	// ---------------------------------------
+	// establish connection for ptp-ip event
+	// ---------------------------------------
+	t.eConn, err = net.Dial("tcp", t.host+t.proto.EventPort())
+	if err != nil {
+		t.cConn.Close()
+		return err
+	}
+
+	err = t.proto.InitEvent(t.eConn, connectionNumber)
+	if err != nil {
+		t.cConn.Close()
+		t.eConn.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (t *ipTransport) Close() (err error) {
+	cErr := t.cConn.Close()
+	eErr := t.eConn.Close()
+	if cErr != nil {
+		return cErr
+	}
+	return eErr
+}
+
+func (t *ipTransport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	return t.proto.OperationRequest(t.cConn, req, sendData)
+}
+
+func (t *ipTransport) RecvEvent() (e *packet.EventPacket, err error) {
+	return t.proto.RecvEvent(t.eConn)
+}
+
+func (t *ipTransport) Cancel(transactionID uint32) error {
+	return t.proto.Cancel(t.cConn, t.eConn, transactionID)
+}
+
+// startKeepalive enables TCP keepalive on both sockets and, if the Protocol
+// is a Prober, returns the event connection recording its reads.
+func (t *ipTransport) startKeepalive(period time.Duration) (conn *aliveConn, p Prober, err error) {
+	if err = setTCPKeepAlive(t.cConn, period); err != nil {
+		return nil, nil, err
+	}
+	if err = setTCPKeepAlive(t.eConn, period); err != nil {
+		return nil, nil, err
+	}
+
+	p, ok := t.proto.(Prober)
+	if !ok {
+		return nil, nil, nil
+	}
+	conn = &aliveConn{Conn: t.eConn, last: time.Now()}
+	t.eConn = conn
+	return conn, p, nil
+}
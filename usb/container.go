@@ -0,0 +1,190 @@
+package usb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// Container Type
+const (
+	ContainerTypeCommand  uint16 = 0x0001
+	ContainerTypeData     uint16 = 0x0002
+	ContainerTypeResponse uint16 = 0x0003
+	ContainerTypeEvent    uint16 = 0x0004
+)
+
+// ContainerHeaderSize is the size of Length(4) Type(2) Code(2) TransactionID(4).
+const ContainerHeaderSize = 12
+
+// maxInitialPayloadSize is the buffer allocated before a payload is read
+const maxInitialPayloadSize uint32 = 1 << 20
+
+// Container is a USB bulk or interrupt container.
+type Container struct {
+	Type          uint16
+	Code          uint16
+	TransactionID uint32
+	Payload       []byte
+}
+
+// Params decodes up to 5 parameters of the payload, missing ones are 0.
+func (c *Container) Params() (p [5]uint32) {
+	for i := range p {
+		if len(c.Payload) < (i+1)*4 {
+			break
+		}
+		p[i] = binary.LittleEndian.Uint32(c.Payload[i*4:])
+	}
+	return p
+}
+
+// ParamsPayload encodes params as payload, leaving out trailing zero
+// parameters as they are variable in number on USB.
+func ParamsPayload(params ...uint32) []byte {
+	for 0 < len(params) && params[len(params)-1] == 0 {
+		params = params[:len(params)-1]
+	}
+	payload := make([]byte, len(params)*4)
+	for i, p := range params {
+		binary.LittleEndian.PutUint32(payload[i*4:], p)
+	}
+	return payload
+}
+
+// WriteContainer writes c with a single Write.
+func WriteContainer(w io.Writer, c *Container) (err error) {
+	b := make([]byte, ContainerHeaderSize+len(c.Payload))
+	binary.LittleEndian.PutUint32(b[0:], uint32(len(b)))
+	binary.LittleEndian.PutUint16(b[4:], c.Type)
+	binary.LittleEndian.PutUint16(b[6:], c.Code)
+	binary.LittleEndian.PutUint32(b[8:], c.TransactionID)
+	copy(b[ContainerHeaderSize:], c.Payload)
+
+	_, err = w.Write(b)
+	return err
+}
+
+// ReadContainer reads one container. A length shorter than the header is a
+// packet.ProtocolError whose PacketType is the container type.
+func ReadContainer(r io.Reader) (c *Container, err error) {
+	header := make([]byte, ContainerHeaderSize)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:])
+	c = &Container{
+		Type:          binary.LittleEndian.Uint16(header[4:]),
+		Code:          binary.LittleEndian.Uint16(header[6:]),
+		TransactionID: binary.LittleEndian.Uint32(header[8:]),
+	}
+	if length < ContainerHeaderSize {
+		return nil, &packet.ProtocolError{PacketType: uint32(c.Type), Reason: fmt.Sprintf("invalid container len 0x%x", length)}
+	}
+
+	// grow the buffer as the payload arrives so that a corrupt length
+	// cannot force a huge allocation
+	payloadLen := length - ContainerHeaderSize
+	initialSize := payloadLen
+	if maxInitialPayloadSize < initialSize {
+		initialSize = maxInitialPayloadSize
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, initialSize))
+	if _, err = io.CopyN(buf, r, int64(payloadLen)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	c.Payload = buf.Bytes()
+
+	return c, nil
+}
+
+// OperationRequest performs one transaction over containers: the Command
+// container and the Data container of a DataOut phase are written to w, the
+// Data container of a DataIn phase and the Response container are read from
+// r. Containers out of order or of another transaction are returned as
+// packet.ProtocolError. See packet.OperationRequestWithResponse.
+func OperationRequest(r io.Reader, w io.Writer, req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	cmd := &Container{
+		Type:          ContainerTypeCommand,
+		Code:          req.OperationCode,
+		TransactionID: req.TransactionID,
+		Payload:       ParamsPayload(req.P1, req.P2, req.P3, req.P4, req.P5),
+	}
+	if err = WriteContainer(w, cmd); err != nil {
+		return nil, nil, err
+	}
+
+	if req.DataPhaseInfo == packet.DataPhaseInfoDataOut {
+		data := &Container{Type: ContainerTypeData, Code: req.OperationCode, TransactionID: req.TransactionID, Payload: sendData}
+		if err = WriteContainer(w, data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var gotData bool
+	for {
+		c, err := ReadContainer(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if c.TransactionID != req.TransactionID {
+			return nil, nil, &packet.ProtocolError{PacketType: uint32(c.Type), Reason: fmt.Sprintf("invalid transaction id 0x%08x expected 0x%08x", c.TransactionID, req.TransactionID)}
+		}
+
+		switch c.Type {
+		case ContainerTypeData:
+			if gotData || req.DataPhaseInfo == packet.DataPhaseInfoDataOut {
+				return nil, nil, &packet.ProtocolError{PacketType: uint32(c.Type), Reason: "unexpected data container"}
+			}
+			gotData = true
+			recvData = c.Payload
+		case ContainerTypeResponse:
+			p := c.Params()
+			resp = &packet.OperationResponsePacket{
+				ResponseCode:  c.Code,
+				TransactionID: c.TransactionID,
+				P1:            p[0],
+				P2:            p[1],
+				P3:            p[2],
+				P4:            p[3],
+			}
+			if c.Code != packet.ResponseCodeOK {
+				return nil, resp, &packet.ResponseError{Code: c.Code}
+			}
+			return recvData, resp, nil
+		default:
+			return nil, nil, &packet.ProtocolError{PacketType: uint32(c.Type), Reason: "unexpected container in transaction"}
+		}
+	}
+}
+
+// RecvEvent reads containers from r up to the next Event container. Other
+// containers are skipped.
+func RecvEvent(r io.Reader) (e *packet.EventPacket, err error) {
+	for {
+		c, err := ReadContainer(r)
+		if err != nil {
+			return nil, err
+		}
+		if c.Type != ContainerTypeEvent {
+			continue
+		}
+
+		p := c.Params()
+		e = &packet.EventPacket{
+			EventCode:     c.Code,
+			TransactionID: c.TransactionID,
+			P1:            p[0],
+			P2:            p[1],
+			P3:            p[2],
+		}
+		return e, nil
+	}
+}
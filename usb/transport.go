@@ -0,0 +1,126 @@
+// Package usb is PTP over USB as a ptpip.Transport.
+//
+// The Transport speaks the USB still image class containers on endpoints
+// opened by the caller with a USB library, so the whole ptpip.Client API
+// works for USB tethered cameras:
+//
+//	c := usb.NewClient(usb.Endpoints{BulkIn: in, BulkOut: out, Interrupt: intr})
+//	if err := c.Connect(); err != nil {
+//		...
+//	}
+//	err = c.OpenSession(1)
+//
+// The framing only needs io.Reader and io.Writer, so a Transport can be
+// tested over an in-memory pipe without a device.
+package usb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// ErrCancelUnsupported is returned by Cancel when Endpoints.Cancel is nil.
+var ErrCancelUnsupported = errors.New("usb: cancel not supported")
+
+// Endpoints are the pipes of the still image interface of a device.
+type Endpoints struct {
+	// BulkIn and BulkOut carry operations, data and responses. Every
+	// container is written with a single Write.
+	BulkIn  io.Reader
+	BulkOut io.Writer
+	// Interrupt carries the events. It is optional, without it no events
+	// are received.
+	Interrupt io.Reader
+
+	// Cancel sends the Cancel Request class request with the data of
+	// CancelRequestData. It is optional.
+	Cancel func(transactionID uint32) error
+	// Close releases the device. It should make blocked reads return. It is
+	// optional; RecvEvent returns on Close in any case.
+	Close func() error
+}
+
+// Transport is the USB ptpip.Transport.
+type Transport struct {
+	ep Endpoints
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewTransport returns a Transport on ep.
+func NewTransport(ep Endpoints) *Transport {
+	return &Transport{ep: ep, closed: make(chan struct{})}
+}
+
+// NewClient returns a ptpip.Client on ep.
+func NewClient(ep Endpoints) *ptpip.Client {
+	return ptpip.NewClientTransport(NewTransport(ep))
+}
+
+// Connect does nothing, the endpoints are open.
+func (t *Transport) Connect() error {
+	return nil
+}
+
+// Close makes a blocked RecvEvent return and closes the endpoints.
+func (t *Transport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	if t.ep.Close == nil {
+		return nil
+	}
+	return t.ep.Close()
+}
+
+// OperationRequest ...
+func (t *Transport) OperationRequest(req *packet.OperationRequestPacket, sendData []byte) (recvData []byte, resp *packet.OperationResponsePacket, err error) {
+	return OperationRequest(t.ep.BulkIn, t.ep.BulkOut, req, sendData)
+}
+
+// RecvEvent waits for the next event on the interrupt endpoint. It returns
+// io.EOF after Close, even if the read of the endpoint does not return.
+func (t *Transport) RecvEvent() (e *packet.EventPacket, err error) {
+	if t.ep.Interrupt == nil {
+		<-t.closed
+		return nil, io.EOF
+	}
+
+	type result struct {
+		e   *packet.EventPacket
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		e, err := RecvEvent(t.ep.Interrupt)
+		ch <- result{e, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.e, r.err
+	case <-t.closed:
+		return nil, io.EOF
+	}
+}
+
+// Cancel ...
+func (t *Transport) Cancel(transactionID uint32) error {
+	if t.ep.Cancel == nil {
+		return ErrCancelUnsupported
+	}
+	return t.ep.Cancel(transactionID)
+}
+
+// CancelRequestData returns the data of the Cancel Request class request:
+// the CancelTransaction event code and the transaction ID.
+func CancelRequestData(transactionID uint32) []byte {
+	b := make([]byte, 6)
+	binary.LittleEndian.PutUint16(b[0:], uint16(packet.EventCodeCancelTransaction))
+	binary.LittleEndian.PutUint32(b[2:], transactionID)
+	return b
+}
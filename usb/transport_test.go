@@ -0,0 +1,266 @@
+package usb
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/takurooo/ptpip"
+	"github.com/takurooo/ptpip/packet"
+)
+
+// device is the device end of in-memory endpoints.
+type device struct {
+	bulkOut   *io.PipeReader // what the host writes
+	bulkIn    *io.PipeWriter // what the host reads
+	interrupt *io.PipeWriter
+}
+
+func newPipes() (ep Endpoints, d *device) {
+	outR, outW := io.Pipe()
+	inR, inW := io.Pipe()
+	intrR, intrW := io.Pipe()
+
+	ep = Endpoints{
+		BulkIn:    inR,
+		BulkOut:   outW,
+		Interrupt: intrR,
+		Close: func() error {
+			outW.Close()
+			inR.Close()
+			intrR.Close()
+			return nil
+		},
+	}
+	return ep, &device{bulkOut: outR, bulkIn: inW, interrupt: intrW}
+}
+
+// run reads the containers of expect from the host, compares them and
+// writes replies.
+func (d *device) run(t *testing.T, expect [][]byte, replies [][]byte) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, e := range expect {
+			c, err := ReadContainer(d.bulkOut)
+			if err != nil {
+				t.Errorf("read container: %v", err)
+				return
+			}
+			var b bytes.Buffer
+			WriteContainer(&b, c)
+			if !bytes.Equal(b.Bytes(), e) {
+				t.Errorf("got container\n% x\nexpected\n% x", b.Bytes(), e)
+			}
+		}
+		for _, r := range replies {
+			d.bulkIn.Write(r)
+		}
+	}()
+	return done
+}
+
+func container(typ, code uint16, transactionID uint32, payload ...byte) []byte {
+	var b bytes.Buffer
+	WriteContainer(&b, &Container{Type: typ, Code: code, TransactionID: transactionID, Payload: payload})
+	return b.Bytes()
+}
+
+func TestContainerGolden(t *testing.T) {
+	// GetObjectHandles(0xFFFFFFFF) of transaction 3
+	golden := []byte{
+		0x10, 0x00, 0x00, 0x00, // Length
+		0x01, 0x00, // Command
+		0x07, 0x10, // GetObjectHandles
+		0x03, 0x00, 0x00, 0x00, // TransactionID
+		0xFF, 0xFF, 0xFF, 0xFF, // StorageID
+	}
+
+	var b bytes.Buffer
+	c := &Container{Type: ContainerTypeCommand, Code: uint16(packet.OperationCodeGetObjectHandles), TransactionID: 3, Payload: ParamsPayload(0xFFFFFFFF, 0, 0)}
+	if err := WriteContainer(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), golden) {
+		t.Fatalf("got\n% x\nexpected\n% x", b.Bytes(), golden)
+	}
+
+	got, err := ReadContainer(bytes.NewReader(golden))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != c.Type || got.Code != c.Code || got.TransactionID != c.TransactionID || !bytes.Equal(got.Payload, c.Payload) {
+		t.Errorf("got %+v expected %+v", got, c)
+	}
+}
+
+func TestReadContainerInvalid(t *testing.T) {
+	if _, err := ReadContainer(bytes.NewReader([]byte{0x04, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0})); err == nil {
+		t.Error("expected error for length shorter than the header")
+	}
+	if _, err := ReadContainer(bytes.NewReader([]byte{0xFF, 0xFF, 0xFF, 0xFF, 2, 0, 0, 0, 0, 0, 0, 0, 1})); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v expected ErrUnexpectedEOF", err)
+	}
+}
+
+func TestOperationRequestDataIn(t *testing.T) {
+	ep, d := newPipes()
+	done := d.run(t,
+		[][]byte{container(ContainerTypeCommand, uint16(packet.OperationCodeGetObject), 2, 0x10, 0, 0, 0)},
+		[][]byte{
+			container(ContainerTypeData, uint16(packet.OperationCodeGetObject), 2, 1, 2, 3),
+			container(ContainerTypeResponse, packet.ResponseCodeOK, 2),
+		})
+
+	req := &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn, OperationCode: uint16(packet.OperationCodeGetObject), TransactionID: 2, P1: 0x10}
+	data, _, err := NewTransport(ep).OperationRequest(req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Errorf("got data % x", data)
+	}
+	<-done
+}
+
+func TestOperationRequestDataOut(t *testing.T) {
+	ep, d := newPipes()
+	done := d.run(t,
+		[][]byte{
+			container(ContainerTypeCommand, uint16(packet.OperationCodeSetDevicePropValue), 4, 0x05, 0x50, 0, 0),
+			container(ContainerTypeData, uint16(packet.OperationCodeSetDevicePropValue), 4, 0x02, 0x00),
+		},
+		[][]byte{container(ContainerTypeResponse, packet.ResponseCodeOK, 4)})
+
+	req := &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoDataOut, OperationCode: uint16(packet.OperationCodeSetDevicePropValue), TransactionID: 4, P1: 0x5005}
+	if _, _, err := NewTransport(ep).OperationRequest(req, []byte{0x02, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+}
+
+func TestOperationRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies [][]byte
+		check   func(err error) bool
+	}{
+		{"response error", [][]byte{container(ContainerTypeResponse, packet.ResponseCodeDeviceBusy, 1)}, func(err error) bool {
+			e, ok := err.(*packet.ResponseError)
+			return ok && e.Code == packet.ResponseCodeDeviceBusy
+		}},
+		{"transaction id", [][]byte{container(ContainerTypeResponse, packet.ResponseCodeOK, 2)}, func(err error) bool {
+			_, ok := err.(*packet.ProtocolError)
+			return ok
+		}},
+		{"event on bulk", [][]byte{container(ContainerTypeEvent, uint16(packet.EventCodeObjectAdded), 1)}, func(err error) bool {
+			_, ok := err.(*packet.ProtocolError)
+			return ok
+		}},
+		{"two data containers", [][]byte{container(ContainerTypeData, 0x1009, 1), container(ContainerTypeData, 0x1009, 1)}, func(err error) bool {
+			_, ok := err.(*packet.ProtocolError)
+			return ok
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep, d := newPipes()
+			done := d.run(t, [][]byte{container(ContainerTypeCommand, 0x1009, 1)}, tt.replies)
+
+			req := &packet.OperationRequestPacket{DataPhaseInfo: packet.DataPhaseInfoNoDataOrDataIn, OperationCode: 0x1009, TransactionID: 1}
+			_, _, err := NewTransport(ep).OperationRequest(req, nil)
+			if !tt.check(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+			ep.Close()
+			<-done
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	ep, d := newPipes()
+	done := d.run(t,
+		[][]byte{
+			container(ContainerTypeCommand, uint16(packet.OperationCodeOpenSession), 0, 1, 0, 0, 0),
+		},
+		[][]byte{container(ContainerTypeResponse, packet.ResponseCodeOK, 0)})
+
+	c := NewClient(ep)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := c.Subscribe()
+	defer cancel()
+
+	if err := c.OpenSession(1); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	d.interrupt.Write(container(ContainerTypeEvent, uint16(packet.EventCodeObjectAdded), 0, 0x10, 0, 0, 0))
+	select {
+	case e := <-events:
+		if packet.EventCode(e.EventCode) != packet.EventCodeObjectAdded || e.P1 != 0x10 {
+			t.Errorf("got event 0x%04x P1 0x%x", e.EventCode, e.P1)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+
+	if err := c.Cancel(); err != ptpip.ErrNoTransaction {
+		t.Errorf("got %v expected ErrNoTransaction", err)
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCancelRequestData(t *testing.T) {
+	if got := CancelRequestData(5); !bytes.Equal(got, []byte{0x01, 0x40, 0x05, 0x00, 0x00, 0x00}) {
+		t.Errorf("got % x", got)
+	}
+}
+
+func TestDisconnectWithoutCloseAndInterrupt(t *testing.T) {
+	ep, _ := newPipes()
+	ep.Interrupt = nil
+	ep.Close = nil
+	c := NewClient(ep)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Disconnect() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Disconnect did not return")
+	}
+}
+
+func TestDisconnectWithoutClose(t *testing.T) {
+	ep, _ := newPipes()
+	ep.Close = nil
+	c := NewClient(ep)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Disconnect() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Disconnect did not return")
+	}
+}
@@ -0,0 +1,153 @@
+package ptpip
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/takurooo/ptpip/packet"
+)
+
+// DatasetDecoder decodes the data phase of a vendor operation into a typed value.
+type DatasetDecoder func(data []byte) (interface{}, error)
+
+// Vendor describes a vendor extension to PTP: the names of its operation,
+// event, device property and response codes, the data types of its device
+// properties and the decoders of its datasets.
+// Vendor packages register it with RegisterVendor from their init function.
+type Vendor struct {
+	Name string
+
+	// VendorExtensionID and Manufacturer are matched against DeviceInfo.
+	// Manufacturer is matched case-insensitively as a substring and is optional.
+	VendorExtensionID uint32
+	Manufacturer      string
+
+	Operations  map[uint16]string
+	Events      map[uint16]string
+	DeviceProps map[uint16]string
+	Responses   map[uint16]string
+
+	// DevicePropTypes maps a device property code to its PTP datatype code.
+	DevicePropTypes map[uint16]uint16
+
+	// Decoders maps an operation code to the decoder of its data phase.
+	Decoders map[uint16]DatasetDecoder
+}
+
+var (
+	vendorsMu sync.RWMutex
+	vendors   []*Vendor
+)
+
+// RegisterVendor makes a vendor extension available to Client.
+// It panics if v is nil or a vendor with the same name is already registered.
+func RegisterVendor(v *Vendor) {
+	if v == nil {
+		panic("ptpip: RegisterVendor vendor is nil")
+	}
+
+	vendorsMu.Lock()
+	defer vendorsMu.Unlock()
+
+	for _, r := range vendors {
+		if r.Name == v.Name {
+			panic("ptpip: RegisterVendor called twice for vendor " + v.Name)
+		}
+	}
+	vendors = append(vendors, v)
+}
+
+// LookupVendor returns the registered vendor extension that best matches
+// info, or nil if there is none. A match on both VendorExtensionID and
+// Manufacturer is preferred over a match on only one of them.
+func LookupVendor(info *packet.DeviceInfo) *Vendor {
+	if info == nil {
+		return nil
+	}
+
+	vendorsMu.RLock()
+	defer vendorsMu.RUnlock()
+
+	var (
+		best      *Vendor
+		bestScore int
+	)
+	manufacturer := strings.ToLower(info.Manufacturer)
+	for _, v := range vendors {
+		score := 0
+		if v.Manufacturer != "" && strings.Contains(manufacturer, strings.ToLower(v.Manufacturer)) {
+			score += 2
+		}
+		if v.VendorExtensionID != 0 && v.VendorExtensionID == info.VendorExtensionID {
+			score++
+		}
+		if bestScore < score {
+			best, bestScore = v, score
+		}
+	}
+
+	return best
+}
+
+// OperationName ...
+func (v *Vendor) OperationName(code uint16) (name string, ok bool) {
+	if v == nil {
+		return "", false
+	}
+	name, ok = v.Operations[code]
+	return name, ok
+}
+
+// EventName ...
+func (v *Vendor) EventName(code uint16) (name string, ok bool) {
+	if v == nil {
+		return "", false
+	}
+	name, ok = v.Events[code]
+	return name, ok
+}
+
+// DevicePropName ...
+func (v *Vendor) DevicePropName(code uint16) (name string, ok bool) {
+	if v == nil {
+		return "", false
+	}
+	name, ok = v.DeviceProps[code]
+	return name, ok
+}
+
+// ResponseName ...
+func (v *Vendor) ResponseName(code uint16) (name string, ok bool) {
+	if v == nil {
+		return "", false
+	}
+	name, ok = v.Responses[code]
+	return name, ok
+}
+
+// DevicePropType returns the PTP datatype code of a vendor device property.
+func (v *Vendor) DevicePropType(code uint16) (dataType uint16, ok bool) {
+	if v == nil {
+		return 0, false
+	}
+	dataType, ok = v.DevicePropTypes[code]
+	return dataType, ok
+}
+
+// Decode decodes the data phase of opCode with the registered decoder.
+// Without a decoder the data is returned as is.
+func (v *Vendor) Decode(opCode uint16, data []byte) (interface{}, error) {
+	if v == nil {
+		return data, nil
+	}
+	decode, ok := v.Decoders[opCode]
+	if !ok {
+		return data, nil
+	}
+	d, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decode 0x%04x: %v", v.Name, opCode, err)
+	}
+	return d, nil
+}